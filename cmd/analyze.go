@@ -0,0 +1,217 @@
+// Package cmd 提供API自动化测试命令行工具的命令实现
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/morsuning/ai-auto-test-cmd/models"
+	"github.com/morsuning/ai-auto-test-cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// analyzeCmd 表示分析批量请求失败用例的命令
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <report.json>",
+	Short: "分析批量请求结果中的失败用例",
+	Long: `读取 atc request 保存的JSON测试报告，在本地按状态码、错误信息和响应体特征对失败用例分组，
+再将每组的代表性请求/响应发送给配置的LLM，生成根因分析以及断言或报文的修正建议，并写回测试报告。
+
+测试报告需使用 --save-path xxx.json 保存为JSON格式。LLM参数读取配置文件中的 [llm] 配置。
+
+示例：
+  # 分析测试报告，分析结果写回原文件
+  atc analyze result.json
+
+  # 使用指定配置文件中的LLM，并将分析后的报告保存到新文件
+  atc analyze result.json -c config.toml -o result_analyzed.json
+
+  # 只在本地分组，不调用LLM
+  atc analyze result.json --no-llm
+
+也可以在批量请求时直接分析：
+  atc request -u https://xxx.system.com/xxx/xxx -m post -f xxx.csv --save-path result.json --analyze`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configFile, _ := cmd.Flags().GetString("config")
+		output, _ := cmd.Flags().GetString("output")
+		noLLM, _ := cmd.Flags().GetBool("no-llm")
+		debug, _ := cmd.Flags().GetBool("debug")
+
+		reportPath := args[0]
+		if output == "" {
+			output = reportPath
+		}
+
+		report, err := loadTestReport(reportPath)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		options := failureAnalysisOptions{Enabled: true, UseLLM: !noLLM, Debug: debug}
+		if options.UseLLM {
+			config, err := utils.LoadConfig(configFile)
+			if err != nil {
+				fmt.Printf("❌ 加载配置文件失败: %v\n", err)
+				fmt.Println("💡 提示: 使用 --no-llm 可以只在本地分组失败用例")
+				os.Exit(1)
+			}
+			options.LLM = config.LLM
+		}
+
+		report.Analysis = runFailureAnalysis(report.Results, options)
+		if report.Analysis == nil {
+			return
+		}
+
+		jsonBytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ 序列化测试报告失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(output, jsonBytes, 0644); err != nil {
+			fmt.Printf("❌ 写入测试报告失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n✅ 分析结果已写入: %s\n", output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+
+	analyzeCmd.Flags().StringP("config", "c", "config.toml", "配置文件路径，读取其中的LLM配置（默认为config.toml）")
+	analyzeCmd.Flags().StringP("output", "o", "", "分析后的测试报告保存路径（默认覆盖输入文件）")
+	analyzeCmd.Flags().Bool("no-llm", false, "只在本地分组失败用例，不调用LLM")
+	analyzeCmd.Flags().Bool("debug", false, "启用调试模式，输出LLM请求详情")
+
+	analyzeCmd.Flags().SortFlags = false
+}
+
+// failureAnalysisOptions 失败用例分析选项
+type failureAnalysisOptions struct {
+	Enabled bool            // 是否分析失败用例
+	UseLLM  bool            // 是否调用LLM进行根因分析
+	LLM     utils.LLMConfig // LLM配置
+	Debug   bool            // 是否输出LLM请求详情
+}
+
+// loadTestReport 读取JSON格式的测试报告
+func loadTestReport(path string) (models.TestReport, error) {
+	var report models.TestReport
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		return report, fmt.Errorf("仅支持分析JSON格式的测试报告，请使用 --save-path xxx.json 保存批量请求结果")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, fmt.Errorf("读取测试报告失败: %v", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, fmt.Errorf("解析测试报告失败: %v", err)
+	}
+	return report, nil
+}
+
+// runFailureAnalysis 对失败用例分组并调用LLM分析，没有失败用例时返回nil
+// LLM调用失败不影响本地分组结果，错误信息记录在分析结果中
+func runFailureAnalysis(results []models.TestResult, options failureAnalysisOptions) *models.FailureAnalysis {
+	var samples []utils.FailureSample
+	for _, result := range results {
+		if result.Success {
+			continue
+		}
+		samples = append(samples, utils.FailureSample{
+			TestCaseID:   result.TestCaseID,
+			StatusCode:   result.StatusCode,
+			Error:        result.Error,
+			RequestBody:  result.RequestBody,
+			ResponseBody: result.ResponseBody,
+		})
+	}
+	if len(samples) == 0 {
+		fmt.Println("\n🎉 没有失败用例，无需分析")
+		return nil
+	}
+
+	groups := utils.GroupFailures(samples)
+	utils.PrintFailureGroups(groups, len(results))
+
+	analysis := &models.FailureAnalysis{Groups: make([]models.FailureGroup, len(groups))}
+	for i, group := range groups {
+		analysis.Groups[i] = models.FailureGroup{
+			StatusCode:  group.StatusCode,
+			Error:       group.Error,
+			BodyPattern: group.BodyPattern,
+			Count:       len(group.TestCaseIDs),
+			TestCaseIDs: group.TestCaseIDs,
+		}
+	}
+	if !options.UseLLM {
+		return analysis
+	}
+
+	provider, err := utils.NewLLMProvider(options.LLM.AnalysisConfig())
+	if err != nil {
+		analysis.Error = err.Error()
+		fmt.Printf("⚠️  无法创建LLM提供方，跳过根因分析: %v\n", err)
+		return analysis
+	}
+	analysis.Provider = provider.Name()
+	analysis.Model = options.LLM.Model
+
+	fmt.Printf("\n🤖 正在调用LLM分析失败原因（%s）...\n", provider.Name())
+	summary, err := utils.AnalyzeFailures(provider, groups, len(results), options.Debug)
+	if usage, ok := utils.ProviderUsage(provider); ok {
+		if usage.Model != "" {
+			analysis.Model = usage.Model
+		}
+		pricing := utils.LLMPricing{PromptPrice: options.LLM.PromptPrice, CompletionPrice: options.LLM.CompletionPrice, Currency: options.LLM.Currency}
+		utils.PrintUsageSummary(pricing.Apply(usage))
+	}
+	if err != nil {
+		analysis.Error = err.Error()
+		fmt.Printf("⚠️  %v\n", err)
+		return analysis
+	}
+	analysis.Summary = summary
+	fmt.Printf("\n🔎 根因分析:\n%s\n", summary)
+	return analysis
+}
+
+// formatAnalysisMarkdown 将失败分析结果格式化为Markdown，用于CSV结果旁的分析文件
+func formatAnalysisMarkdown(analysis *models.FailureAnalysis, total int) string {
+	var builder strings.Builder
+	failed := 0
+	for _, group := range analysis.Groups {
+		failed += group.Count
+	}
+	builder.WriteString("# 失败用例分析\n\n")
+	builder.WriteString(fmt.Sprintf("共 %d 个用例，失败 %d 个，归为 %d 组。\n\n", total, failed, len(analysis.Groups)))
+	builder.WriteString("| 分组 | 用例数 | 状态码 | 错误信息 | 响应体特征 | 用例 |\n")
+	builder.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for i, group := range analysis.Groups {
+		builder.WriteString(fmt.Sprintf("| %d | %d | %d | %s | %s | %s |\n", i+1, group.Count, group.StatusCode,
+			escapeMarkdownCell(group.Error), escapeMarkdownCell(group.BodyPattern), strings.Join(group.TestCaseIDs, ", ")))
+	}
+
+	if analysis.Summary != "" {
+		builder.WriteString("\n## 根因分析")
+		if analysis.Model != "" {
+			builder.WriteString(fmt.Sprintf("（%s）", analysis.Model))
+		}
+		builder.WriteString("\n\n" + analysis.Summary + "\n")
+	}
+	if analysis.Error != "" {
+		builder.WriteString(fmt.Sprintf("\n> LLM分析失败: %s\n", analysis.Error))
+	}
+	return builder.String()
+}
+
+// escapeMarkdownCell 转义Markdown表格单元格中的竖线和换行
+func escapeMarkdownCell(text string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(text)
+}
@@ -0,0 +1,106 @@
+// Package cmd 提供API自动化测试命令行工具的命令实现
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/morsuning/ai-auto-test-cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd 表示管理LLM响应缓存的命令
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "管理LLM响应缓存",
+	Long: `管理llm-gen使用的LLM响应缓存。
+
+缓存键由LLM提供方、URL、模型、正例报文以及格式、数量、提示词等输入参数组成，
+相同的请求会直接复用缓存结果而不再调用LLM。
+
+示例：
+  # 列出所有缓存记录
+  atc cache ls
+
+  # 清空所有缓存记录
+  atc cache clear
+
+  # 使用配置文件中的缓存目录
+  atc cache ls -c config.toml`,
+}
+
+// cacheLsCmd 表示列出缓存记录的命令
+var cacheLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "列出LLM响应缓存记录",
+	Run: func(cmd *cobra.Command, args []string) {
+		cache := resolveLLMCache(cmd)
+		entries, err := cache.List()
+		if err != nil {
+			fmt.Printf("❌ 读取缓存失败: %v\n", err)
+			return
+		}
+
+		fmt.Printf("🗂️  缓存目录: %s\n", cache.Dir)
+		if len(entries) == 0 {
+			fmt.Println("📭 暂无缓存记录")
+			return
+		}
+
+		fmt.Printf("📋 共 %d 条缓存记录:\n\n", len(entries))
+		for _, entry := range entries {
+			fmt.Printf("🔑 %s  %s\n", entry.Hash[:12], entry.CreatedAt.Format("2006-01-02 15:04:05"))
+			fmt.Printf("   提供方: %s", entry.Key.Provider)
+			if entry.Key.Model != "" {
+				fmt.Printf("  模型: %s", entry.Key.Model)
+			}
+			fmt.Printf("  URL: %s\n", entry.Key.URL)
+			fmt.Printf("   格式: %v  数量: %v  响应大小: %d 字节\n", entry.Key.Inputs["post_type"], entry.Key.Inputs["test_num"], len(entry.Text))
+			fmt.Printf("   正例报文: %s\n", truncateString(entry.Key.PositiveExample, 80))
+		}
+	},
+}
+
+// cacheClearCmd 表示清空缓存的命令
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "清空LLM响应缓存",
+	Run: func(cmd *cobra.Command, args []string) {
+		cache := resolveLLMCache(cmd)
+		removed, err := cache.Clear()
+		if err != nil {
+			fmt.Printf("❌ 清空缓存失败: %v\n", err)
+			return
+		}
+		fmt.Printf("🧹 已删除 %d 条缓存记录（%s）\n", removed, cache.Dir)
+	},
+}
+
+// resolveLLMCache 根据命令行参数和配置文件确定缓存目录
+// 优先级：--dir > 配置文件中的 cache_dir > 默认目录
+func resolveLLMCache(cmd *cobra.Command) *utils.LLMCache {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir != "" {
+		return utils.NewLLMCache(dir)
+	}
+
+	configFile, _ := cmd.Flags().GetString("config")
+	if configFile == "" {
+		configFile = "config.toml"
+	}
+	if _, err := os.Stat(configFile); err == nil {
+		if config, err := utils.LoadConfig(configFile); err == nil {
+			return utils.NewLLMCache(config.LLM.CacheDir)
+		}
+	}
+	return utils.NewLLMCache("")
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheLsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	cacheCmd.PersistentFlags().String("dir", "", "缓存目录（可选，默认读取配置文件或使用用户缓存目录）")
+	cacheCmd.PersistentFlags().StringP("config", "c", "", "配置文件路径（默认为config.toml）")
+}
@@ -3,6 +3,8 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/morsuning/ai-auto-test-cmd/utils"
 	"github.com/spf13/cobra"
@@ -30,24 +32,64 @@ var llmGenCmd = &cobra.Command{
 	# 命令行参数覆盖配置文件中的正例报文
 	atc llm-gen -c config.toml --xml "<root><name>test</name></root>"
 
+	# 使用内置的边界值提示词模板生成测试用例
+	atc llm-gen -c config.toml --prompt-template boundary -n 20
+
+	# 列出内置模板和自定义模板目录中的模板
+	atc llm-gen --list-prompt-templates --prompt-template-dir ./prompts
+
+	# 分批生成大量测试用例：每批50条，同时进行4个批次
+	atc llm-gen -c config.toml -n 500 --batch-size 50 --batch-concurrency 4
+
+	# 生成后请求LLM重新生成结构与正例不一致的用例
+	atc llm-gen -c config.toml -n 20 --repair
+
+	# 将配置文件中的字段约束传给LLM，并校验生成的用例是否满足约束
+	atc llm-gen -c config.toml --check-constraints
+
+	# 跳过LLM响应缓存，强制重新调用LLM
+	atc llm-gen -c config.toml --no-cache
+
 	# 使用自定义提示词文件生成测试用例
 	atc llm-gen -c config.toml --prompt prompt.txt -n 3
 
+	# 使用OpenAI兼容接口（vLLM、Ollama等）生成测试用例，使用内置系统提示词
+	atc llm-gen --provider openai -u http://localhost:8000/v1 --model qwen2.5-7b-instruct --json '{"name":"test"}' -n 5
+
+	# 使用本地Ollama服务离线生成测试用例（默认地址 http://localhost:11434）
+	atc llm-gen --provider ollama --model qwen2.5:7b --json '{"name":"test"}' -n 5
+
 	# 生成测试用例并立即执行（从配置文件读取request参数）
 	atc llm-gen -c config.toml -e`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 获取命令行参数
 		baseURL, _ := cmd.Flags().GetString("url")
 		apiKey, _ := cmd.Flags().GetString("api-key")
+		provider, _ := cmd.Flags().GetString("provider")
+		model, _ := cmd.Flags().GetString("model")
 		configFile, _ := cmd.Flags().GetString("config")
 		xmlContent, _ := cmd.Flags().GetString("xml")
 		jsonContent, _ := cmd.Flags().GetString("json")
 		promptFile, _ := cmd.Flags().GetString("prompt")
+		promptTemplate, _ := cmd.Flags().GetString("prompt-template")
+		promptTemplateDir, _ := cmd.Flags().GetString("prompt-template-dir")
+		listPromptTemplates, _ := cmd.Flags().GetBool("list-prompt-templates")
 		num, _ := cmd.Flags().GetInt("num")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		batchConcurrency, _ := cmd.Flags().GetInt("batch-concurrency")
+		repair, _ := cmd.Flags().GetBool("repair")
+		checkConstraints, _ := cmd.Flags().GetBool("check-constraints")
+		noCache, _ := cmd.Flags().GetBool("no-cache")
 		output, _ := cmd.Flags().GetString("output")
 		debug, _ := cmd.Flags().GetBool("debug")
 		exec, _ := cmd.Flags().GetBool("exec")
 
+		// 列出可用的提示词模板后退出
+		if listPromptTemplates {
+			printPromptTemplates(configFile, promptTemplateDir)
+			return
+		}
+
 		// 从配置文件读取参数（如果指定了配置文件或使用默认配置文件）
 		var config *utils.Config
 		if configFile != "" || baseURL == "" || apiKey == "" || num == 5 || output == "" {
@@ -56,7 +98,7 @@ var llmGenCmd = &cobra.Command{
 			}
 			var err error
 			config, err = utils.LoadConfig(configFile)
-			if err != nil && (baseURL == "" || apiKey == "") {
+			if err != nil && baseURL == "" {
 				fmt.Printf("❌ 错误: 无法加载配置文件 %s: %v\n", configFile, err)
 				fmt.Println("请通过 -u 和 --api-key 参数显式指定，或创建配置文件")
 				return
@@ -76,12 +118,36 @@ var llmGenCmd = &cobra.Command{
 						fmt.Println("📄 从配置文件读取API Key")
 					}
 				}
+				if provider == "" && config.LLM.Provider != "" {
+					provider = config.LLM.Provider
+				}
+				if model == "" && config.LLM.Model != "" {
+					model = config.LLM.Model
+				}
 				if num == 5 && config.TestCase.Num != 0 { // 只有当num是默认值时才从配置文件读取
 					num = config.TestCase.Num
 				}
 				if output == "" && config.TestCase.Output != "" {
 					output = config.TestCase.Output
 				}
+				if promptTemplate == "" && config.LLM.PromptTemplate != "" {
+					promptTemplate = config.LLM.PromptTemplate
+				}
+				if promptTemplateDir == "" && config.LLM.PromptTemplateDir != "" {
+					promptTemplateDir = config.LLM.PromptTemplateDir
+				}
+				if batchSize == 0 && config.LLM.BatchSize != 0 {
+					batchSize = config.LLM.BatchSize
+				}
+				if batchConcurrency == 0 && config.LLM.BatchConcurrency != 0 {
+					batchConcurrency = config.LLM.BatchConcurrency
+				}
+				if !repair && config.LLM.Repair {
+					repair = true
+				}
+				if !checkConstraints && config.LLM.CheckConstraints {
+					checkConstraints = true
+				}
 			}
 		}
 
@@ -164,7 +230,15 @@ var llmGenCmd = &cobra.Command{
 				IsXML:         isXML,
 				IsJSON:        isJSON,
 				IgnoreTLS:     config.Request.IgnoreTLSErrors,
+				Redirect:      config.Request.RedirectPolicy(),
+			}
+
+			bodyOptions, err := config.Request.ResponseBodyOptions()
+			if err != nil {
+				fmt.Printf("❌ 配置文件中的request参数验证失败: %v\n", err)
+				return
 			}
+			requestParams.ResponseBody = bodyOptions
 
 			// 设置默认值
 			if requestParams.Method == "" {
@@ -184,20 +258,49 @@ var llmGenCmd = &cobra.Command{
 		}
 
 		// 验证必需参数
-		if baseURL == "" {
+		// Ollama提供方未指定URL时使用本机默认地址
+		if baseURL == "" && !strings.EqualFold(provider, utils.ProviderOllama) {
 			fmt.Println("❌ 错误: 必须指定LLM API Base URL（通过 -u 参数或配置文件）")
 			return
 		}
-		if apiKey == "" {
-			fmt.Println("❌ 错误: 必须指定LLM API Key（通过 --api-key 参数或配置文件）")
+		// 组装LLM配置，命令行参数优先于配置文件
+		llmConfig := utils.LLMConfig{}
+		if config != nil {
+			llmConfig = config.LLM
+		}
+		llmConfig.Provider = provider
+		llmConfig.URL = baseURL
+		llmConfig.APIKey = apiKey
+		llmConfig.Model = model
+
+		llmProvider, err := utils.NewLLMProvider(llmConfig)
+		if err != nil {
+			fmt.Printf("❌ 错误: %v\n", err)
 			return
 		}
 
+		// 除非显式关闭，否则通过磁盘缓存复用相同请求的LLM响应
+		var cachedProvider *utils.CachedProvider
+		if !noCache && (llmConfig.Cache == nil || *llmConfig.Cache) {
+			cachedProvider = &utils.CachedProvider{
+				Provider: llmProvider,
+				Cache:    utils.NewLLMCache(llmConfig.CacheDir),
+				URL:      baseURL,
+				Model:    model,
+				APIKey:   apiKey,
+			}
+			llmProvider = cachedProvider
+		}
+
 		// 验证生成数量限制
 		if num <= 0 {
 			fmt.Println("❌ 错误: 生成数量必须大于0")
 			return
 		}
+		if batchSize < 0 || batchConcurrency < 0 {
+			fmt.Println("❌ 错误: 批次大小和批次并发数不能为负数")
+			return
+		}
 
 		// 设置默认输出文件
 		if output == "" {
@@ -206,10 +309,19 @@ var llmGenCmd = &cobra.Command{
 
 		// 打印开始信息
 		fmt.Println("🚀 通过LLM API生成测试用例")
+		fmt.Printf("🤖 LLM提供方: %s\n", llmProvider.Name())
 		fmt.Printf("🌐 Base URL: %s\n", baseURL)
+		if model != "" {
+			fmt.Printf("🧠 模型: %s\n", model)
+		}
 		fmt.Printf("📝 报文格式: %s\n", getFormatName(isXML, isJSON))
 		fmt.Printf("🔢 生成数量: %d\n", num)
 		fmt.Printf("💾 输出文件: %s\n", output)
+		if cachedProvider != nil {
+			fmt.Printf("🗂️  响应缓存: %s\n", cachedProvider.Cache.Dir)
+		} else {
+			fmt.Println("🗂️  响应缓存: 已关闭")
+		}
 
 		// 读取自定义提示词（优先级：命令行文件 > 配置文件 > 无）
 		var userPrompt string
@@ -241,20 +353,90 @@ var llmGenCmd = &cobra.Command{
 		} else {
 			format = "json"
 		}
-		inputs := map[string]any{
-			"post_type": format, // 报文格式（json或xml）
-			"test_num":  num,    // 生成的用例个数
+
+		// 约束系统启用时，将字段约束传给LLM
+		var constraints map[string]utils.FieldConstraint
+		if config != nil && utils.IsConstraintsEnabled(config) && len(config.Constraints.Constraints) > 0 {
+			constraints = config.Constraints.Constraints
+			if err := utils.ValidateConstraintConfig(&utils.ConstraintConfig{Constraints: constraints}); err != nil {
+				fmt.Printf("❌ 约束配置验证失败: %v\n", err)
+				return
+			}
+			fmt.Printf("🔒 字段约束: %d 个\n", len(constraints))
+		} else if checkConstraints {
+			fmt.Println("⚠️  未配置字段约束，跳过约束校验")
 		}
 
-		// 如果有自定义提示词，添加到inputs中
-		if userPrompt != "" {
-			inputs["user_prompt"] = userPrompt
+		// 调用LLM提供方生成测试用例
+		genReq := utils.GenerationRequest{
+			PositiveExample:  inputContent,
+			Format:           format,
+			Num:              num,
+			UserPrompt:       userPrompt,
+			Debug:            debug,
+			RepairBroken:     repair,
+			Constraints:      constraints,
+			CheckConstraints: checkConstraints,
 		}
 
-		// 调用Dify API生成测试用例
-		err := utils.GenerateTestCasesWithDify(apiKey, baseURL, inputContent, inputs, format, output, debug)
-		if err != nil {
-			fmt.Printf("❌ 生成测试用例失败: %v\n", err)
+		// 使用提示词模板时，每个请求按各自的生成数量渲染，渲染结果放在自定义提示词之前
+		if promptTemplate != "" {
+			tmpl, err := loadPromptTemplate(promptTemplate, promptTemplateDir, genReq, debug)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return
+			}
+			genReq.PromptTemplate = tmpl
+		}
+		batchOptions := utils.BatchOptions{Size: batchSize, Concurrency: batchConcurrency}
+		_, genErr := utils.GenerateTestCasesInBatches(llmProvider, genReq, batchOptions, output)
+
+		var cacheHits int64
+		if cachedProvider != nil {
+			var misses int64
+			cacheHits, misses = cachedProvider.Stats()
+			fmt.Printf("💾 缓存命中 %d 次，调用LLM %d 次\n", cacheHits, misses)
+		}
+
+		// 输出用量统计并写入生成日志，生成失败时同样记录已消耗的用量
+		usage, _ := utils.ProviderUsage(llmProvider)
+		pricing := utils.LLMPricing{PromptPrice: llmConfig.PromptPrice, CompletionPrice: llmConfig.CompletionPrice, Currency: llmConfig.Currency}
+		if pricing.Currency == "" {
+			pricing.Currency = "USD"
+		}
+		usage = pricing.Apply(usage)
+		if usage.Model == "" {
+			usage.Model = model
+		}
+		utils.PrintUsageSummary(usage)
+
+		logEntry := utils.GenerationLogEntry{
+			Time:      time.Now(),
+			Team:      llmConfig.Team,
+			Provider:  llmProvider.Name(),
+			URL:       baseURL,
+			Model:     usage.Model,
+			Format:    format,
+			Num:       num,
+			Output:    output,
+			CacheHits: cacheHits,
+			Usage:     usage,
+		}
+		if genErr != nil {
+			logEntry.Error = genErr.Error()
+		}
+		if err := utils.AppendGenerationLog(llmConfig.UsageLog, logEntry); err != nil {
+			fmt.Printf("⚠️  写入生成日志失败: %v\n", err)
+		} else if debug {
+			logPath := llmConfig.UsageLog
+			if logPath == "" {
+				logPath = utils.DefaultGenerationLogPath()
+			}
+			fmt.Printf("📒 生成日志: %s\n", logPath)
+		}
+
+		if genErr != nil {
+			fmt.Printf("❌ 生成测试用例失败: %v\n", genErr)
 			return
 		}
 
@@ -280,11 +462,21 @@ func init() {
 	// API连接参数组
 	llmGenCmd.Flags().StringP("url", "u", "", "LLM API Base URL（可选，可从配置文件读取）")
 	llmGenCmd.Flags().String("api-key", "", "LLM API Key（可选，可从配置文件读取）")
+	llmGenCmd.Flags().String("provider", "", "LLM提供方：dify、openai 或 ollama（可选，默认dify）")
+	llmGenCmd.Flags().String("model", "", "模型名称（openai、ollama提供方必填，可从配置文件读取）")
 	llmGenCmd.Flags().StringP("config", "c", "", "配置文件路径（默认为config.toml）")
 
 	// 生成控制参数组
 	llmGenCmd.Flags().IntP("num", "n", 5, "生成用例数量（默认5）")
 	llmGenCmd.Flags().StringP("prompt", "p", "", "自定义提示词文件路径（可选，文件必须是UTF-8编码）")
+	llmGenCmd.Flags().String("prompt-template", "", "提示词模板名称（如 boundary、security、missing-fields、type-confusion、business-rules）")
+	llmGenCmd.Flags().String("prompt-template-dir", "", "自定义提示词模板目录（*.tmpl，同名模板覆盖内置模板）")
+	llmGenCmd.Flags().Bool("list-prompt-templates", false, "列出可用的提示词模板")
+	llmGenCmd.Flags().Int("batch-size", 0, "分批生成时每批的用例数量（默认50，生成数量超过时自动分批）")
+	llmGenCmd.Flags().Int("batch-concurrency", 0, "分批生成时同时进行的批次数（默认1）")
+	llmGenCmd.Flags().Bool("repair", false, "请求LLM重新生成结构与正例不一致的用例，无法修复的用例将被丢弃")
+	llmGenCmd.Flags().Bool("check-constraints", false, "使用配置文件[constraints]中的约束校验生成的用例并输出报告")
+	llmGenCmd.Flags().Bool("no-cache", false, "不使用LLM响应缓存（相同请求默认复用缓存结果）")
 
 	// 输出控制参数组
 	llmGenCmd.Flags().StringP("output", "o", "", "输出文件路径（可选，默认为当前目录下的test_cases.csv）")
@@ -303,3 +495,48 @@ func init() {
 	// 注意：url和api-key参数不再是必需的，可以从配置文件读取
 	// raw和file参数互斥，在Run函数中进行验证
 }
+
+// loadPromptTemplate 查找提示词模板，并使用生成请求试渲染一次以尽早发现模板和正例报文的错误
+// 模板变量包括正例报文、字段列表、约束描述和生成数量
+func loadPromptTemplate(name, dir string, req utils.GenerationRequest, debug bool) (*utils.PromptTemplate, error) {
+	tmpl, err := utils.FindPromptTemplate(name, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	req.PromptTemplate = tmpl
+	rendered, err := utils.RenderRequestPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("🧩 提示词模板: %s（版本 %s，%s）\n", tmpl.Name, tmpl.Version, tmpl.Source)
+	if debug {
+		fmt.Printf("📄 提示词内容预览: %s...\n", truncateString(rendered.UserPrompt, 100))
+	}
+	return tmpl, nil
+}
+
+// printPromptTemplates 列出可用的提示词模板
+// 未指定模板目录时尝试读取配置文件中的 prompt_template_dir
+func printPromptTemplates(configFile, dir string) {
+	if dir == "" {
+		if configFile == "" {
+			configFile = "config.toml"
+		}
+		if config, err := utils.LoadConfig(configFile); err == nil {
+			dir = config.LLM.PromptTemplateDir
+		}
+	}
+
+	templates, err := utils.LoadPromptTemplates(dir)
+	if err != nil {
+		fmt.Printf("❌ 加载提示词模板失败: %v\n", err)
+		return
+	}
+
+	fmt.Printf("🧩 可用的提示词模板（共 %d 个）:\n", len(templates))
+	for _, name := range utils.SortedPromptTemplateNames(templates) {
+		tmpl := templates[name]
+		fmt.Printf("  - %-16s v%-4s %s（%s）\n", tmpl.Name, tmpl.Version, tmpl.Description, tmpl.Source)
+	}
+}
@@ -4,6 +4,8 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/morsuning/ai-auto-test-cmd/models"
 	"github.com/morsuning/ai-auto-test-cmd/utils"
@@ -25,6 +27,27 @@ var localGenCmd = &cobra.Command{
 - 默认值为 0.5（50%变化程度）
 - 值越大，生成的数据变化越大；值越小，生成的数据越接近原始数据
 
+生成模式（--mode）：
+- random：随机变化模式（默认），对正例数据进行随机变化
+- negative：反例模式，对每个字段依次应用变异算子（缺失、null、空值、类型错误、超长、非法日期，以及 pattern 字段的近似不匹配字符串、
+  id_card/bank_card 字段的校验位错误）
+- mixed：混合模式，按 --negative-ratio 指定的比例生成反例，其余为随机变化的正例
+- boundary：边界值模式，对每个带 integer、float、date、datetime 约束的字段生成 min、min±1、max、max±1、
+  零值和精度边界等用例，其余字段保持正例中的值，依赖其他字段的字段（expr、after、depends_on）重新计算；结果固定且不受 -n 影响，需要启用约束系统
+组合策略（--strategy）：
+- pairwise：两两组合，生成覆盖任意两个字段所有取值组合的最少用例（贪心算法，结果固定）
+- N-wise（如 3-wise）：覆盖任意N个字段的所有取值组合
+- 字段的候选值依次来自约束中的 values 列表、integer/float/date/datetime 约束的有效边界值，布尔字段取 true 和 false
+- 带 expr、after、depends_on 依赖的字段不参与组合，按每个用例的取值重新计算
+- 未明确指定 -n 时生成完整的覆盖数组，指定 -n 时最多生成 -n 条用例，并输出实际达到的组合覆盖率
+反例、混合、边界值模式和组合策略的CSV会增加 LABEL 列，记录每个用例应用的变异（如 missing:name），执行时写入用例类型和说明
+
+随机数种子（--seed）：
+- 相同的种子、正例报文和配置生成完全相同的用例，便于复现失败的用例
+- 未指定时使用随机种子，种子会输出到命令行并写入CSV文件开头的元数据行（# seed=...）
+- 身份证号的年龄范围（min_age、max_age）按参考日期（--reference-date，默认当天）计算，参考日期同样写入元数据行（# reference_date=...），
+  复现时需同时指定 --seed 和 --reference-date
+
 约束系统开关：
 - 可通过配置文件中的 constraints.enable 控制
 - 如果未明确设置，有约束配置时默认启用
@@ -45,6 +68,24 @@ var localGenCmd = &cobra.Command{
   # 使用配置文件中的约束配置和自定义随机化因子生成智能测试用例
   atc local-gen -c config.toml -n 20
 
+  # 对每个字段应用变异算子生成反例
+  atc local-gen --json '{"name":"test","age":25,"birthday":"2000-01-01"}' --mode negative -n 50
+
+  # 生成20条用例，其中30%为反例
+  atc local-gen -c config.toml -n 20 --mode mixed --negative-ratio 0.3
+
+  # 根据配置文件中的约束生成边界值用例
+  atc local-gen -c config.toml --mode boundary
+
+  # 按约束中的候选值生成两两组合用例
+  atc local-gen -c config.toml --strategy pairwise
+
+  # 生成三三组合用例，最多30条
+  atc local-gen -c config.toml --strategy 3-wise -n 30
+
+  # 使用固定种子重新生成与上次相同的用例
+  atc local-gen -c config.toml -n 20 --seed 1700000000
+
   # 生成测试用例并立即执行（从配置文件读取request参数）
   atc local-gen -c config.toml -e`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -55,12 +96,21 @@ var localGenCmd = &cobra.Command{
 		output, _ := cmd.Flags().GetString("output")
 		configFile, _ := cmd.Flags().GetString("config")
 		exec, _ := cmd.Flags().GetBool("exec")
+		mode, _ := cmd.Flags().GetString("mode")
+		negativeRatio, _ := cmd.Flags().GetFloat64("negative-ratio")
+		strategy, _ := cmd.Flags().GetString("strategy")
+		seed, _ := cmd.Flags().GetInt64("seed")
+		referenceDate, _ := cmd.Flags().GetString("reference-date")
 
 		// 加载配置文件
 		var config *utils.Config
+		var constraintConfig *utils.ConstraintConfig
 		if configFile != "" {
 			var err error
-			config, err = utils.LoadConfigWithConstraints(configFile)
+			config, err = utils.LoadConfig(configFile)
+			if err == nil {
+				constraintConfig, err = config.ConstraintConfig()
+			}
 			if err != nil {
 				fmt.Printf("❌ 加载配置文件失败: %v\n", err)
 				return
@@ -74,6 +124,64 @@ var localGenCmd = &cobra.Command{
 			if output == "" && config.TestCase.Output != "" {
 				output = config.TestCase.Output
 			}
+			if mode == "" && config.TestCase.Mode != "" {
+				mode = config.TestCase.Mode
+			}
+			if negativeRatio == 0 && config.TestCase.NegativeRatio != 0 {
+				negativeRatio = config.TestCase.NegativeRatio
+			}
+			if strategy == "" && config.TestCase.Strategy != "" {
+				strategy = config.TestCase.Strategy
+			}
+			if !cmd.Flags().Changed("seed") && config.TestCase.Seed != 0 {
+				seed = config.TestCase.Seed
+			}
+			if referenceDate == "" && config.TestCase.ReferenceDate != "" {
+				referenceDate = config.TestCase.ReferenceDate
+			}
+		}
+		if !cmd.Flags().Changed("seed") && seed == 0 {
+			seed = utils.NewRandomSeed()
+		}
+
+		// 验证参考日期
+		reference := utils.Today()
+		if referenceDate != "" {
+			var err error
+			if reference, err = time.Parse(utils.ReferenceDateLayout, referenceDate); err != nil {
+				fmt.Printf("❌ 错误: 参考日期 '%s' 无效，格式应为 %s\n", referenceDate, utils.ReferenceDateLayout)
+				return
+			}
+		}
+
+		// 验证生成模式
+		if mode == "" {
+			mode = utils.GenerationModeRandom
+		}
+		if mode != utils.GenerationModeRandom && mode != utils.GenerationModeNegative && mode != utils.GenerationModeMixed && mode != utils.GenerationModeBoundary {
+			fmt.Printf("❌ 错误: 不支持的生成模式 '%s'，支持: %s, %s, %s, %s\n", mode, utils.GenerationModeRandom, utils.GenerationModeNegative, utils.GenerationModeMixed, utils.GenerationModeBoundary)
+			return
+		}
+		if negativeRatio == 0 {
+			negativeRatio = utils.DefaultNegativeRatio
+		}
+		if negativeRatio < 0 || negativeRatio > 1 {
+			fmt.Println("❌ 错误: 反例占比必须在0.0-1.0之间")
+			return
+		}
+
+		// 验证组合策略
+		var strength int
+		if strategy != "" {
+			if mode != utils.GenerationModeRandom {
+				fmt.Println("❌ 错误: 组合策略 --strategy 不能与反例、混合或边界值模式同时使用")
+				return
+			}
+			var err error
+			if strength, err = utils.ParseStrategyStrength(strategy); err != nil {
+				fmt.Printf("❌ 错误: %v\n", err)
+				return
+			}
 		}
 
 		// 确定输入格式和内容
@@ -155,7 +263,15 @@ var localGenCmd = &cobra.Command{
 				IsXML:         isXML,
 				IsJSON:        isJSON,
 				IgnoreTLS:     config.Request.IgnoreTLSErrors,
+				Redirect:      config.Request.RedirectPolicy(),
+			}
+
+			bodyOptions, err := config.Request.ResponseBodyOptions()
+			if err != nil {
+				fmt.Printf("❌ 配置文件中的request参数验证失败: %v\n", err)
+				return
 			}
+			requestParams.ResponseBody = bodyOptions
 
 			// 设置默认值
 			if requestParams.Method == "" {
@@ -184,6 +300,10 @@ var localGenCmd = &cobra.Command{
 		fmt.Printf("📝 报文格式: %s\n", getFormatName(isXML, isJSON))
 		fmt.Printf("📄 原始报文: %s\n", inputContent)
 		fmt.Printf("🔢 生成数量: %d\n", num)
+		fmt.Printf("🧭 生成模式: %s\n", mode)
+		if strategy != "" {
+			fmt.Printf("🧮 组合策略: %s\n", strategy)
+		}
 		fmt.Printf("💾 输出文件: %s\n", output)
 
 		// 检查约束系统是否启用
@@ -204,20 +324,33 @@ var localGenCmd = &cobra.Command{
 			}
 		}
 
+		// 获取随机化因子，优先使用配置文件中的设置，否则使用默认值0.5
+		variationRate := utils.DefaultVariationRate
+		if config != nil && config.TestCase.VariationRate > 0 {
+			variationRate = config.TestCase.VariationRate
+		}
+
+		// 创建本次生成使用的生成器，未启用约束时不使用约束配置
+		if !useConstraints {
+			constraintConfig = nil
+		}
+		generator := utils.NewGenerator(constraintConfig, variationRate, seed)
+		generator.ReferenceDate = reference
+
 		// 解析报文并生成测试用例
 		var data map[string]any
 		var err error
 
 		if isXML {
 			// 解析XML
-			data, err = utils.ParseXML(inputContent)
+			data, err = generator.ParseXML(inputContent)
 			if err != nil {
 				fmt.Printf("解析XML失败: %v\n", err)
 				return
 			}
 		} else {
 			// 解析JSON
-			data, err = utils.ParseJSON(inputContent)
+			data, err = generator.ParseJSON(inputContent)
 			if err != nil {
 				fmt.Printf("解析JSON失败: %v\n", err)
 				return
@@ -228,29 +361,77 @@ var localGenCmd = &cobra.Command{
 		fmt.Println("🔄 正在生成测试用例...")
 		var testCases []map[string]any
 
-		// 获取随机化因子，优先使用配置文件中的设置，否则使用默认值0.5
-		variationRate := 0.5
 		if config != nil && config.TestCase.VariationRate > 0 {
-			variationRate = config.TestCase.VariationRate
 			fmt.Printf("🎲 使用配置的随机化因子: %.2f\n", variationRate)
 		} else {
 			fmt.Printf("🎲 使用默认随机化因子: %.2f\n", variationRate)
 		}
-
-		if useConstraints {
-			testCases = utils.GenerateTestCasesWithVariationRate(data, num, variationRate, true)
-		} else {
-			testCases = utils.GenerateTestCasesWithVariationRate(data, num, variationRate, false)
+		fmt.Printf("🌱 随机数种子: %d，参考日期: %s（使用 --seed %d --reference-date %s 可复现本次用例）\n",
+			seed, reference.Format(utils.ReferenceDateLayout), seed, reference.Format(utils.ReferenceDateLayout))
+
+		// 反例、混合、边界值模式和组合策略生成带标签的用例
+		var labeledCases []utils.LabeledTestCase
+		labeled := mode != utils.GenerationModeRandom || strategy != ""
+		switch {
+		case strategy != "":
+			// 只有明确指定 -n 时才限制组合用例数量
+			maxCases := 0
+			if cmd.Flags().Changed("num") {
+				maxCases = num
+			}
+			result, err := generator.GenerateCombinatorialTestCases(data, strength, maxCases)
+			if err != nil {
+				fmt.Printf("❌ 错误: %v\n", err)
+				return
+			}
+			labeledCases = result.TestCases
+			for _, parameter := range result.Parameters {
+				fmt.Printf("🔀 组合字段 %s: %d 个候选值\n", parameter.Field, len(parameter.Values))
+			}
+			fmt.Printf("📊 %d-wise 组合覆盖率: %.1f%% (%d/%d)\n", result.Strength, result.Coverage()*100, result.CoveredTuples, result.TotalTuples)
+		case mode == utils.GenerationModeBoundary:
+			if !useConstraints {
+				fmt.Println("❌ 错误: 边界值模式需要在配置文件中启用约束系统并配置字段约束")
+				return
+			}
+			labeledCases = generator.GenerateBoundaryTestCases(data)
+			if len(labeledCases) == 0 {
+				fmt.Println("❌ 错误: 报文中没有配置了 integer、float、date 或 datetime 约束的字段，无法生成边界值用例")
+				return
+			}
+		case mode == utils.GenerationModeNegative:
+			labeledCases = generator.GenerateNegativeTestCases(data, num, isXML)
+		case mode == utils.GenerationModeMixed:
+			fmt.Printf("⚖️  反例占比: %.2f\n", negativeRatio)
+			labeledCases = generator.GenerateMixedTestCases(data, num, negativeRatio, isXML)
+		default:
+			testCases = generator.GenerateTestCases(data, num)
+		}
+		if err := generator.Err(); err != nil {
+			fmt.Printf("❌ 错误: %v\n", err)
+			return
+		}
+		if labeled {
+			testCases = make([]map[string]any, len(labeledCases))
+			for i, labeledCase := range labeledCases {
+				testCases[i] = labeledCase.Data
+			}
+			if mode == utils.GenerationModeNegative && len(testCases) < num {
+				fmt.Printf("⚠️  可应用的字段变异只有 %d 种，实际生成 %d 条测试用例\n", len(testCases), len(testCases))
+			}
 		}
 
 		// 根据格式转换数据
 		var csvData [][]string
-		if isXML {
+		if labeled {
+			// 反例、混合、边界值模式和组合策略：报文列之后增加用例标签列
+			csvData = generator.ConvertToLabeledRows(labeledCases, isXML)
+		} else if isXML {
 			// XML格式：每行一个完整的XML
-			csvData = utils.ConvertToXMLRows(testCases)
+			csvData = generator.ConvertToXMLRows(testCases)
 		} else {
 			// JSON格式：每行一个完整的JSON
-			csvData = utils.ConvertToJSONRows(testCases)
+			csvData = generator.ConvertToJSONRows(testCases)
 		}
 
 		// 在命令行输出生成的测试用例
@@ -259,17 +440,24 @@ var localGenCmd = &cobra.Command{
 				if i == 0 {
 					continue
 				}
-				fmt.Printf("🧪 测试用例 %d: %s\n", i, row[0])
+				if len(row) > 1 {
+					fmt.Printf("🧪 测试用例 %d [%s]: %s\n", i, row[1], row[0])
+				} else {
+					fmt.Printf("🧪 测试用例 %d: %s\n", i, row[0])
+				}
 			}
 		}
 
 		// 保存到文件
-		err = utils.SaveToCSV(csvData, output)
+		err = utils.SaveToCSVWithMetadata(csvData, output, map[string]string{
+			"seed":           strconv.FormatInt(seed, 10),
+			"reference_date": reference.Format(utils.ReferenceDateLayout),
+		})
 		if err != nil {
 			fmt.Printf("保存CSV文件失败: %v\n", err)
 			return
 		}
-		fmt.Printf("✅ 成功生成 %d 条测试用例并保存到 %s\n", num, output)
+		fmt.Printf("✅ 成功生成 %d 条测试用例并保存到 %s\n", len(testCases), output)
 
 		// 如果使用exec参数，执行生成的测试用例
 		if exec {
@@ -298,6 +486,10 @@ var localGenCmd = &cobra.Command{
 					Type:        "auto",
 					Data:        testData,
 				}
+				if labeledCases != nil {
+					modelTestCases[i].Type = labeledCases[i].Type
+					modelTestCases[i].Description = labeledCases[i].Description
+				}
 			}
 
 			// 直接执行测试用例
@@ -318,6 +510,11 @@ func init() {
 
 	// 生成控制参数组
 	localGenCmd.Flags().IntP("num", "n", 10, "生成用例数量（默认10）")
+	localGenCmd.Flags().String("mode", "", "生成模式：random（随机变化，默认）、negative（反例）、mixed（混合）、boundary（边界值），可从配置文件读取")
+	localGenCmd.Flags().Float64("negative-ratio", 0, "混合模式下反例的占比（0.0-1.0，默认0.3，可从配置文件读取）")
+	localGenCmd.Flags().String("strategy", "", "组合策略：pairwise（两两组合）或 N-wise（如 3-wise），可从配置文件读取")
+	localGenCmd.Flags().Int64("seed", 0, "随机数种子，相同的种子生成相同的用例（默认随机，可从配置文件读取）")
+	localGenCmd.Flags().String("reference-date", "", "计算身份证年龄范围的参考日期，格式为 2006-01-02（默认当天，可从配置文件读取）")
 
 	// 配置文件参数组
 	localGenCmd.Flags().StringP("config", "c", "", "配置文件路径（包含约束配置和其他设置）")
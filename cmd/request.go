@@ -2,14 +2,13 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/morsuning/ai-auto-test-cmd/models"
+	"github.com/morsuning/ai-auto-test-cmd/pkg/atc"
 	"github.com/morsuning/ai-auto-test-cmd/utils"
 	"github.com/spf13/cobra"
 )
@@ -75,6 +74,24 @@ URL查询参数示例：
 
   # 组合使用查询参数、鉴权和自定义头
   atc request -u https://xxx.system.com/xxx/xxx -m post -f xxx.csv --query "api_version=2.0" --auth-bearer "token" --header "X-Request-ID: 12345"
+
+重定向示例：
+  # 不跟随重定向，验证未登录时返回302跳转登录页
+  atc request -u https://xxx.system.com/xxx/xxx -m get -f xxx.csv --no-follow-redirects
+
+  # 最多跟随3次重定向，且只跟随同一主机内的重定向
+  atc request -u https://xxx.system.com/xxx/xxx -m get -f xxx.csv --max-redirects 3 --same-host-redirects
+
+  # 配置文件设置了 follow_redirects = false 时，本次执行重新跟随重定向
+  atc request -c config.toml --no-follow-redirects=false
+
+响应体处理示例：
+  # 限制内存中保留的响应体大小为1MB，二进制响应以Base64保存，并将完整响应体保存到bodies目录
+  atc request -u https://xxx.system.com/xxx/xxx -m get -f xxx.csv --max-body-size 1MB --binary-body base64 --save-bodies bodies
+
+失败分析示例：
+  # 请求完成后对失败用例分组，并调用配置文件中的LLM分析根因，分析结果写入JSON测试报告
+  atc request -u https://xxx.system.com/xxx/xxx -m post -f xxx.csv --save-path result.json --analyze
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 获取配置文件参数
@@ -105,6 +122,22 @@ URL查询参数示例：
 		// 获取TLS配置参数
 		ignoreTLS, _ := cmd.Flags().GetBool("ignore-tls")
 
+		// 获取重定向策略参数
+		noFollowRedirects, _ := cmd.Flags().GetBool("no-follow-redirects")
+		maxRedirects, _ := cmd.Flags().GetInt("max-redirects")
+		sameHostRedirects, _ := cmd.Flags().GetBool("same-host-redirects")
+
+		// 获取响应体处理参数
+		maxBodySize, _ := cmd.Flags().GetString("max-body-size")
+		binaryBody, _ := cmd.Flags().GetString("binary-body")
+		saveBodiesDir, _ := cmd.Flags().GetString("save-bodies")
+		maxSavedBody, _ := cmd.Flags().GetString("max-saved-body")
+
+		// 获取失败分析参数
+		analyze, _ := cmd.Flags().GetBool("analyze")
+		var llmConfig utils.LLMConfig
+		var redirectPolicy utils.RedirectPolicy
+
 		// 从配置文件读取参数（如果指定了配置文件）
 		if configFile != "" {
 			config, err := utils.LoadConfig(configFile)
@@ -150,6 +183,47 @@ URL查询参数示例：
 			if !ignoreTLS && config.Request.IgnoreTLSErrors {
 				ignoreTLS = config.Request.IgnoreTLSErrors
 			}
+			redirectPolicy = config.Request.RedirectPolicy()
+			if maxBodySize == "" && config.Request.MaxBodySize != "" {
+				maxBodySize = config.Request.MaxBodySize
+			}
+			if binaryBody == "" && config.Request.BinaryBody != "" {
+				binaryBody = config.Request.BinaryBody
+			}
+			if saveBodiesDir == "" && config.Request.SaveBodiesDir != "" {
+				saveBodiesDir = config.Request.SaveBodiesDir
+			}
+			if maxSavedBody == "" && config.Request.MaxSavedBodySize != "" {
+				maxSavedBody = config.Request.MaxSavedBodySize
+			}
+			if !analyze && config.Request.Analyze {
+				analyze = config.Request.Analyze
+			}
+			llmConfig = config.LLM
+		}
+
+		// 构建重定向策略：以配置文件为基础，只覆盖命令行中明确指定的参数
+		// （--no-follow-redirects=false 可以重新启用配置文件中关闭的重定向跟随）
+		if cmd.Flags().Changed("no-follow-redirects") {
+			redirectPolicy.Disabled = noFollowRedirects
+		}
+		if cmd.Flags().Changed("max-redirects") {
+			redirectPolicy.MaxRedirects = maxRedirects
+		}
+		if cmd.Flags().Changed("same-host-redirects") {
+			redirectPolicy.SameHostOnly = sameHostRedirects
+		}
+
+		// 构建响应体读取选项
+		bodyOptions, err := utils.RequestConfig{
+			MaxBodySize:      maxBodySize,
+			BinaryBody:       binaryBody,
+			SaveBodiesDir:    saveBodiesDir,
+			MaxSavedBodySize: maxSavedBody,
+		}.ResponseBodyOptions()
+		if err != nil {
+			fmt.Printf("❌ 错误: %v\n", err)
+			os.Exit(1)
 		}
 
 		// 验证必需参数
@@ -188,15 +262,16 @@ URL查询参数示例：
 			}
 
 			headers := data[0]
-			if len(headers) == 1 {
-				headerUpper := strings.ToUpper(headers[0])
-				if headerUpper == "XML" {
+			payloadFormat := utils.DetectCSVPayloadFormat(headers)
+			if len(headers) == 1 || payloadFormat != "" {
+				switch payloadFormat {
+				case "xml":
 					contentType = "xml"
 					fmt.Println("✅ 自动检测到XML格式")
-				} else if headerUpper == "JSON" {
+				case "json":
 					contentType = "json"
 					fmt.Println("✅ 自动检测到JSON格式")
-				} else {
+				default:
 					fmt.Printf("❌ 错误: 无法自动检测请求体格式。CSV文件第一行应该是 'xml' 或 'json'，当前为: '%s'\n", headers[0])
 					fmt.Println("提示: 请在CSV文件第一行写入 'xml' 或 'json'，或使用 --xml 或 --json 参数手动指定格式")
 					os.Exit(1)
@@ -215,18 +290,33 @@ URL查询参数示例：
 		fmt.Printf("内容类型: %s\n", contentType)
 		fmt.Printf("并发数: %d\n", concurrent)
 		fmt.Printf("请求超时时间: %d秒\n", timeout)
+		fmt.Printf("重定向策略: %s\n", describeRedirectPolicy(redirectPolicy))
 		fmt.Println()
 
-		// 构建鉴权配置
-		authConfig := AuthConfig{
-			BearerToken:   authBearer,
-			BasicAuth:     authBasic,
-			APIKey:        authAPIKey,
-			CustomHeaders: customHeaders,
+		// 构建批量请求选项
+		runOptions := atc.RunOptions{
+			URL:         url,
+			Method:      method,
+			Format:      contentType,
+			Timeout:     timeout,
+			Concurrency: concurrent,
+			Auth: atc.Auth{
+				BearerToken:   authBearer,
+				BasicAuth:     authBasic,
+				APIKey:        authAPIKey,
+				CustomHeaders: customHeaders,
+			},
+			Query:        queryParams,
+			IgnoreTLS:    ignoreTLS,
+			Redirect:     redirectPolicy,
+			ResponseBody: bodyOptions,
 		}
 
+		// 构建失败分析选项
+		analysisOptions := failureAnalysisOptions{Enabled: analyze, UseLLM: true, LLM: llmConfig, Debug: debug}
+
 		// 执行批量请求
-		if err := executeBatchRequestsWithAuth(url, method, filePath, savePath, timeout, concurrent, contentType, debug, authConfig, queryParams, ignoreTLS); err != nil {
+		if err := executeBatchRequestsWithAuth(filePath, savePath, debug, runOptions, analysisOptions); err != nil {
 			fmt.Printf("❌ 执行失败: %v\n", err)
 			os.Exit(1)
 		}
@@ -269,6 +359,20 @@ func init() {
 	// TLS配置参数组
 	requestCmd.Flags().Bool("ignore-tls", false, "忽略TLS证书验证错误（可选，可从配置文件读取）")
 
+	// 重定向参数组
+	requestCmd.Flags().Bool("no-follow-redirects", false, "不跟随重定向，直接记录3xx响应（可选，可从配置文件读取，--no-follow-redirects=false 覆盖配置文件重新跟随）")
+	requestCmd.Flags().Int("max-redirects", 0, "最大重定向次数（默认10，可从配置文件读取）")
+	requestCmd.Flags().Bool("same-host-redirects", false, "只跟随同一主机内的重定向（可选，可从配置文件读取，--same-host-redirects=false 覆盖配置文件）")
+
+	// 响应体处理参数组
+	requestCmd.Flags().String("max-body-size", "", "内存中保留的最大响应体大小，如 \"512KB\"、\"10MB\"（默认10MB，可从配置文件读取）")
+	requestCmd.Flags().String("binary-body", "", "二进制响应体保存方式：hash 或 base64（默认hash，可从配置文件读取）")
+	requestCmd.Flags().String("save-bodies", "", "完整响应体保存目录，每个用例一个子目录（可选，可从配置文件读取）")
+	requestCmd.Flags().String("max-saved-body", "", "保存到文件和统计大小时最多读取的响应体大小，超出时文件标记为截断（默认为max-body-size的10倍，可从配置文件读取）")
+
+	// 失败分析参数组
+	requestCmd.Flags().Bool("analyze", false, "请求完成后对失败用例分组，并调用配置的LLM分析根因（可选，可从配置文件读取）")
+
 	// 调试参数组
 	requestCmd.Flags().Bool("debug", false, "启用调试模式，输出详细的请求信息")
 
@@ -277,7 +381,7 @@ func init() {
 }
 
 // executeBatchRequestsWithAuth 执行批量请求（支持鉴权）
-func executeBatchRequestsWithAuth(url, method, filePath string, savePath string, timeout, concurrent int, contentType string, debug bool, authConfig AuthConfig, queryParams []string, ignoreTLS bool) error {
+func executeBatchRequestsWithAuth(filePath, savePath string, debug bool, runOptions atc.RunOptions, analysisOptions failureAnalysisOptions) error {
 	// 读取CSV文件
 	fmt.Println("📖 正在读取测试用例文件...")
 	data, err := utils.ReadCSV(filePath)
@@ -290,7 +394,7 @@ func executeBatchRequestsWithAuth(url, method, filePath string, savePath string,
 	}
 
 	// 解析CSV数据为测试用例
-	testCases, err := parseCSVToTestCases(data)
+	testCases, err := atc.TestCasesFromCSV(data)
 	if err != nil {
 		return fmt.Errorf("解析测试用例失败: %v", err)
 	}
@@ -298,9 +402,7 @@ func executeBatchRequestsWithAuth(url, method, filePath string, savePath string,
 	fmt.Printf("✅ 成功读取 %d 个测试用例\n\n", len(testCases))
 
 	// 构建HTTP请求
-	useJSON := strings.ToLower(contentType) == "json"
-	useXML := strings.ToLower(contentType) == "xml"
-	requests, err := buildHTTPRequestsWithAuth(testCases, url, method, timeout, useJSON, useXML, authConfig, queryParams, ignoreTLS)
+	requests, err := buildHTTPRequests(testCases, runOptions)
 	if err != nil {
 		return err
 	}
@@ -313,99 +415,41 @@ func executeBatchRequestsWithAuth(url, method, filePath string, savePath string,
 	// 执行批量请求
 	fmt.Println("🚀 开始执行批量请求...")
 	start := time.Now()
-	responses := utils.SendConcurrentRequests(requests, concurrent)
+	responses := utils.SendConcurrentRequests(requests, runOptions.Concurrency)
 	duration := time.Since(start)
 
 	// 处理响应结果
-	results := processResponses(testCases, responses, requests)
+	results := atc.ProcessResponses(testCases, responses, requests)
 
 	// 显示结果统计
 	displayResults(results, duration, debug)
 
+	// 分析失败用例
+	var analysis *models.FailureAnalysis
+	if analysisOptions.Enabled {
+		analysis = runFailureAnalysis(results, analysisOptions)
+	}
+
 	// 保存结果（默认保存）
-	if err := saveResults(results, savePath); err != nil {
+	if err := saveResults(results, analysis, savePath); err != nil {
 		return fmt.Errorf("保存结果失败: %v", err)
 	}
 
 	return nil
 }
 
-// parseCSVToTestCases 将CSV数据解析为测试用例
-func parseCSVToTestCases(data [][]string) ([]models.TestCase, error) {
-	if len(data) < 2 {
-		return nil, fmt.Errorf("CSV文件至少需要包含标题行和一行数据")
+// describeRedirectPolicy 生成重定向策略的描述文本
+func describeRedirectPolicy(policy utils.RedirectPolicy) string {
+	if policy.Disabled {
+		return "不跟随"
 	}
-
-	headers := data[0]
-	testCases := make([]models.TestCase, 0, len(data)-1)
-
-	// 检查是否是XML单列格式（只有一列且列名为XML）
-	isXMLFormat := len(headers) == 1 && strings.ToUpper(headers[0]) == "XML"
-	// 检查是否是JSON单列格式（只有一列且列名为JSON）
-	isJSONFormat := len(headers) == 1 && strings.ToUpper(headers[0]) == "JSON"
-
-	for i, row := range data[1:] {
-		if len(row) != len(headers) {
-			return nil, fmt.Errorf("第%d行数据列数与标题行不匹配", i+2)
-		}
-
-		var testData map[string]any
-
-		if isXMLFormat {
-			// XML格式：直接使用XML字符串
-			testData = map[string]any{
-				"_xml_content": row[0], // 使用特殊键存储XML内容
-			}
-		} else if isJSONFormat {
-			// JSON格式：直接使用JSON字符串
-			testData = map[string]any{
-				"_json_content": row[0], // 使用特殊键存储JSON内容
-			}
-		} else {
-			// 普通格式：构建测试数据
-			testData = make(map[string]any)
-			for j, value := range row {
-				testData[headers[j]] = parseValue(value)
-			}
-		}
-
-		testCase := models.TestCase{
-			ID:          fmt.Sprintf("test_%d", i+1),
-			Name:        fmt.Sprintf("测试用例_%d", i+1),
-			Description: fmt.Sprintf("从CSV第%d行生成的测试用例", i+2),
-			Type:        "auto",
-			Data:        testData,
-		}
-
-		testCases = append(testCases, testCase)
-	}
-
-	return testCases, nil
-}
-
-// parseValue 解析字符串值为合适的类型
-func parseValue(value string) any {
-	// 尝试解析为数字
-	if intVal, err := strconv.Atoi(value); err == nil {
-		return intVal
+	maxRedirects := policy.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = 10
 	}
-
-	// 尝试解析为浮点数
-	if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
-		return floatVal
-	}
-
-	// 尝试解析为布尔值
-	if boolVal, err := strconv.ParseBool(value); err == nil {
-		return boolVal
+	description := fmt.Sprintf("跟随（最多%d次）", maxRedirects)
+	if policy.SameHostOnly {
+		description += "，仅限同一主机"
 	}
-
-	// 尝试解析为JSON
-	var jsonVal any
-	if err := json.Unmarshal([]byte(value), &jsonVal); err == nil {
-		return jsonVal
-	}
-
-	// 默认返回字符串
-	return value
+	return description
 }
@@ -2,35 +2,63 @@
 package cmd
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/morsuning/ai-auto-test-cmd/models"
+	"github.com/morsuning/ai-auto-test-cmd/pkg/atc"
 	"github.com/morsuning/ai-auto-test-cmd/utils"
 )
 
 // RequestParams 包含request命令的所有参数
 type RequestParams struct {
-	URL           string   // 目标URL
-	Method        string   // 请求方法
-	SavePath      string   // 结果保存路径
-	Timeout       int      // 请求超时时间
-	Concurrent    int      // 并发请求数
-	Debug         bool     // 调试模式
-	AuthBearer    string   // Bearer Token认证
-	AuthBasic     string   // Basic Auth认证
-	AuthAPIKey    string   // API Key认证
-	CustomHeaders []string // 自定义HTTP头
-	QueryParams   []string // URL查询参数
-	IsXML         bool     // 使用XML格式
-	IsJSON        bool     // 使用JSON格式
-	IgnoreTLS     bool     // 忽略TLS证书验证
+	URL           string                    // 目标URL
+	Method        string                    // 请求方法
+	SavePath      string                    // 结果保存路径
+	Timeout       int                       // 请求超时时间
+	Concurrent    int                       // 并发请求数
+	Debug         bool                      // 调试模式
+	AuthBearer    string                    // Bearer Token认证
+	AuthBasic     string                    // Basic Auth认证
+	AuthAPIKey    string                    // API Key认证
+	CustomHeaders []string                  // 自定义HTTP头
+	QueryParams   []string                  // URL查询参数
+	IsXML         bool                      // 使用XML格式
+	IsJSON        bool                      // 使用JSON格式
+	IgnoreTLS     bool                      // 忽略TLS证书验证
+	Redirect      utils.RedirectPolicy      // 重定向策略
+	ResponseBody  utils.ResponseBodyOptions // 响应体读取选项
+}
+
+// runOptions 将request参数转换为批量请求选项
+func (p RequestParams) runOptions() atc.RunOptions {
+	format := atc.FormatJSON
+	if p.IsXML {
+		format = atc.FormatXML
+	}
+	return atc.RunOptions{
+		URL:         p.URL,
+		Method:      p.Method,
+		Format:      format,
+		Timeout:     p.Timeout,
+		Concurrency: p.Concurrent,
+		Auth: atc.Auth{
+			BearerToken:   p.AuthBearer,
+			BasicAuth:     p.AuthBasic,
+			APIKey:        p.AuthAPIKey,
+			CustomHeaders: p.CustomHeaders,
+		},
+		Query:        p.QueryParams,
+		IgnoreTLS:    p.IgnoreTLS,
+		Redirect:     p.Redirect,
+		ResponseBody: p.ResponseBody,
+	}
 }
 
 // validateRequestParams 验证request参数
@@ -73,16 +101,8 @@ func executeGeneratedTestCases(outputFile string, params RequestParams) error {
 	fmt.Printf("请求超时时间: %d秒\n", params.Timeout)
 	fmt.Println()
 
-	// 构建鉴权配置
-	authConfig := AuthConfig{
-		BearerToken:   params.AuthBearer,
-		BasicAuth:     params.AuthBasic,
-		APIKey:        params.AuthAPIKey,
-		CustomHeaders: params.CustomHeaders,
-	}
-
 	// 执行批量请求
-	if err := executeBatchRequestsWithAuth(params.URL, params.Method, outputFile, params.SavePath, params.Timeout, params.Concurrent, contentType, params.Debug, authConfig, params.QueryParams, params.IgnoreTLS); err != nil {
+	if err := executeBatchRequestsWithAuth(outputFile, params.SavePath, params.Debug, params.runOptions(), failureAnalysisOptions{}); err != nil {
 		return fmt.Errorf("执行测试用例失败: %v", err)
 	}
 
@@ -108,18 +128,8 @@ func executeTestCasesDirectly(testCases []models.TestCase, params RequestParams)
 	fmt.Printf("请求超时时间: %d秒\n", params.Timeout)
 	fmt.Println()
 
-	// 构建鉴权配置
-	authConfig := AuthConfig{
-		BearerToken:   params.AuthBearer,
-		BasicAuth:     params.AuthBasic,
-		APIKey:        params.AuthAPIKey,
-		CustomHeaders: params.CustomHeaders,
-	}
-
 	// 构建HTTP请求
-	useJSON := strings.ToLower(contentType) == "json"
-	useXML := strings.ToLower(contentType) == "xml"
-	requests, err := buildHTTPRequestsWithAuth(testCases, params.URL, params.Method, params.Timeout, useJSON, useXML, authConfig, params.QueryParams, params.IgnoreTLS)
+	requests, err := buildHTTPRequests(testCases, params.runOptions())
 	if err != nil {
 		return fmt.Errorf("构建HTTP请求失败: %v", err)
 	}
@@ -136,13 +146,13 @@ func executeTestCasesDirectly(testCases []models.TestCase, params RequestParams)
 	duration := time.Since(start)
 
 	// 处理响应结果
-	results := processResponses(testCases, responses, requests)
+	results := atc.ProcessResponses(testCases, responses, requests)
 
 	// 显示结果统计
 	displayResults(results, duration, params.Debug)
 
 	// 保存结果（如果需要）
-	if err := saveResults(results, params.SavePath); err != nil {
+	if err := saveResults(results, nil, params.SavePath); err != nil {
 		return fmt.Errorf("保存结果失败: %v", err)
 	}
 
@@ -167,225 +177,15 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen]
 }
 
-// AuthConfig 鉴权配置结构体
-type AuthConfig struct {
-	BearerToken   string   // Bearer Token认证
-	BasicAuth     string   // Basic Auth认证（username:password格式）
-	APIKey        string   // API Key认证
-	CustomHeaders []string // 自定义HTTP头（Key: Value格式）
-}
-
-// buildHTTPRequestsWithAuth 构建HTTP请求列表（支持鉴权）
-func buildHTTPRequestsWithAuth(testCases []models.TestCase, url, method string, timeout int, useJSON, useXML bool, authConfig AuthConfig, queryParams []string, ignoreTLS bool) ([]utils.HTTPRequest, error) {
-	// 检查并添加默认协议
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		url = "http://" + url
-		fmt.Printf("ℹ️  URL 未指定协议，默认使用 HTTP: %s\n", url)
+// buildHTTPRequests 构建HTTP请求列表，并提示URL未指定协议和Basic Auth格式不正确的情况
+func buildHTTPRequests(testCases []models.TestCase, opts atc.RunOptions) ([]utils.HTTPRequest, error) {
+	if !atc.HasScheme(opts.URL) {
+		fmt.Printf("ℹ️  URL 未指定协议，默认使用 HTTP: %s\n", "http://"+opts.URL)
 	}
-
-	requests := make([]utils.HTTPRequest, len(testCases))
-
-	for i, testCase := range testCases {
-		// 构建请求体
-		body := ""
-		headers := make(map[string]string)
-
-		// 应用鉴权配置
-		if err := applyAuthConfig(headers, authConfig); err != nil {
-			return nil, err
-		}
-
-		if strings.ToUpper(method) == "POST" {
-			// POST请求，根据格式化数据
-			if useXML {
-				// XML格式
-				if xmlContent, exists := testCase.Data["_xml_content"]; exists {
-					// 直接使用XML内容
-					body = fmt.Sprintf("%v", xmlContent)
-					headers["Content-Type"] = "application/xml"
-				} else {
-					// 从字段数据转换为XML
-					xmlData, err := convertToXML(testCase.Data)
-					if err != nil {
-						// 如果转换失败，回退到JSON
-						jsonData, _ := json.Marshal(testCase.Data)
-						body = string(jsonData)
-						headers["Content-Type"] = "application/json"
-					} else {
-						body = xmlData
-						headers["Content-Type"] = "application/xml"
-					}
-				}
-			}
-			if useJSON {
-				// JSON格式（默认）
-				if jsonContent, exists := testCase.Data["_json_content"]; exists {
-					// 直接使用JSON内容
-					body = fmt.Sprintf("%v", jsonContent)
-					headers["Content-Type"] = "application/json"
-				} else {
-					// 从字段数据转换为JSON
-					jsonData, _ := json.Marshal(testCase.Data)
-					body = string(jsonData)
-					headers["Content-Type"] = "application/json"
-				}
-			}
-		} else if strings.ToUpper(method) == "GET" {
-			// GET请求现在支持在body中放置JSON/XML数据
-			if useXML {
-				// XML格式
-				if xmlContent, exists := testCase.Data["_xml_content"]; exists {
-					// 直接使用XML内容
-					body = fmt.Sprintf("%v", xmlContent)
-					headers["Content-Type"] = "application/xml"
-				} else {
-					// 从字段数据转换为XML
-					xmlData, err := convertToXML(testCase.Data)
-					if err != nil {
-						// 如果转换失败，回退到JSON
-						jsonData, _ := json.Marshal(testCase.Data)
-						body = string(jsonData)
-						headers["Content-Type"] = "application/json"
-					} else {
-						body = xmlData
-						headers["Content-Type"] = "application/xml"
-					}
-				}
-			}
-			if useJSON {
-				// JSON格式
-				if jsonContent, exists := testCase.Data["_json_content"]; exists {
-					// 直接使用JSON内容
-					body = fmt.Sprintf("%v", jsonContent)
-					headers["Content-Type"] = "application/json"
-				} else {
-					// 从字段数据转换为JSON
-					jsonData, _ := json.Marshal(testCase.Data)
-					body = string(jsonData)
-					headers["Content-Type"] = "application/json"
-				}
-			}
-
-			// 查询参数将在最后统一处理
-			headers["Accept"] = "application/json"
-		} else {
-			// 其他请求方法
-			headers["Accept"] = "application/json"
-		}
-		// 构建最终URL（包含查询参数）
-		finalURL := url
-		if len(queryParams) > 0 {
-			separator := "?"
-			if strings.Contains(url, "?") {
-				separator = "&"
-			}
-			finalURL = url + separator + strings.Join(queryParams, "&")
-		}
-
-		requests[i] = utils.HTTPRequest{
-			URL:       finalURL,
-			Method:    strings.ToUpper(method),
-			Body:      body,
-			Headers:   headers,
-			Timeout:   timeout,
-			IgnoreTLS: ignoreTLS,
-		}
-	}
-	return requests, nil
-}
-
-// applyAuthConfig 应用鉴权配置到HTTP头
-func applyAuthConfig(headers map[string]string, authConfig AuthConfig) error {
-	// 应用Bearer Token认证
-	if authConfig.BearerToken != "" {
-		headers["Authorization"] = "Bearer " + authConfig.BearerToken
-	}
-
-	// 应用Basic Auth认证
-	if authConfig.BasicAuth != "" {
-		// 解析username:password格式
-		parts := strings.SplitN(authConfig.BasicAuth, ":", 2)
-		if len(parts) == 2 {
-			// 编码为Base64
-			credentials := base64.StdEncoding.EncodeToString([]byte(authConfig.BasicAuth))
-			headers["Authorization"] = "Basic " + credentials
-		} else {
-			fmt.Printf("⚠️  警告: Basic Auth格式不正确，应为 'username:password'，跳过Basic Auth认证\n")
-		}
-	}
-
-	// 应用API Key认证
-	if authConfig.APIKey != "" {
-		parts := strings.SplitN(authConfig.APIKey, ":", 2)
-		if len(parts) == 2 {
-			headers[parts[0]] = parts[1]
-		} else {
-			// 如果格式不正确，默认使用X-API-Key作为header名
-			headers["X-API-Key"] = authConfig.APIKey
-		}
-	}
-
-	// 应用自定义HTTP头
-	for _, header := range authConfig.CustomHeaders {
-		// 解析Key: Value格式
-		parts := strings.SplitN(header, ":", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("自定义HTTP头格式错误: %s，正确格式应为 'HeaderName: HeaderValue'", header)
-		}
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		if key == "" {
-			return fmt.Errorf("自定义HTTP头名称不能为空: %s", header)
-		}
-		headers[key] = value
-	}
-	return nil
-}
-
-// convertToXML 将数据转换为XML格式
-func convertToXML(data map[string]any) (string, error) {
-	// 由于Go的xml包对map支持有限，我们手动构建XML字符串
-	var xmlBuilder strings.Builder
-	xmlBuilder.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-	xmlBuilder.WriteString("<data>\n")
-
-	for key, value := range data {
-		// 清理XML标签名（移除特殊字符）
-		cleanKey := strings.ReplaceAll(key, " ", "_")
-		cleanKey = strings.ReplaceAll(cleanKey, "-", "_")
-
-		xmlBuilder.WriteString(fmt.Sprintf("  <%s>", cleanKey))
-
-		// 根据值的类型进行处理
-		switch v := value.(type) {
-		case string:
-			// 转义XML特殊字符
-			escapedValue := strings.ReplaceAll(v, "&", "&amp;")
-			escapedValue = strings.ReplaceAll(escapedValue, "<", "&lt;")
-			escapedValue = strings.ReplaceAll(escapedValue, ">", "&gt;")
-			escapedValue = strings.ReplaceAll(escapedValue, "\"", "&quot;")
-			escapedValue = strings.ReplaceAll(escapedValue, "'", "&apos;")
-			xmlBuilder.WriteString(escapedValue)
-		case int, int32, int64, float32, float64, bool:
-			xmlBuilder.WriteString(fmt.Sprintf("%v", v))
-		default:
-			// 对于复杂类型，尝试JSON序列化后转义
-			jsonBytes, err := json.Marshal(v)
-			if err != nil {
-				xmlBuilder.WriteString(fmt.Sprintf("%v", v))
-			} else {
-				escapedValue := strings.ReplaceAll(string(jsonBytes), "&", "&amp;")
-				escapedValue = strings.ReplaceAll(escapedValue, "<", "&lt;")
-				escapedValue = strings.ReplaceAll(escapedValue, ">", "&gt;")
-				xmlBuilder.WriteString(escapedValue)
-			}
-		}
-
-		xmlBuilder.WriteString(fmt.Sprintf("</%s>\n", cleanKey))
+	if opts.Auth.BasicAuth != "" && !atc.ValidBasicAuth(opts.Auth.BasicAuth) {
+		fmt.Printf("⚠️  警告: Basic Auth格式不正确，应为 'username:password'，跳过Basic Auth认证\n")
 	}
-
-	xmlBuilder.WriteString("</data>")
-	return xmlBuilder.String(), nil
+	return atc.BuildRequests(testCases, opts)
 }
 
 // printDebugInfo 打印调试信息
@@ -433,38 +233,6 @@ func printDebugInfo(requests []utils.HTTPRequest) {
 	fmt.Println("=== 调试信息结束 ===")
 }
 
-// processResponses 处理响应结果
-func processResponses(testCases []models.TestCase, responses []utils.HTTPResponse, requests []utils.HTTPRequest) []models.TestResult {
-	results := make([]models.TestResult, len(testCases))
-
-	for i, response := range responses {
-		result := models.TestResult{
-			TestCaseID:   testCases[i].ID,
-			StatusCode:   response.StatusCode,
-			ResponseBody: response.Body,
-			RequestBody:  "", // 默认为空，下面会设置
-			Duration:     response.Duration.Milliseconds(),
-		}
-
-		// 设置原始请求报文
-		if i < len(requests) {
-			result.RequestBody = requests[i].Body
-		}
-
-		if response.Error != nil {
-			result.Success = false
-			result.Error = response.Error.Error()
-		} else {
-			// 简单判断：状态码2xx为成功
-			result.Success = response.StatusCode >= 200 && response.StatusCode < 300
-		}
-
-		results[i] = result
-	}
-
-	return results
-}
-
 // displayResults 显示结果统计
 func displayResults(results []models.TestResult, duration time.Duration, debug bool) {
 	fmt.Println("\n=== 执行结果 ===")
@@ -504,7 +272,9 @@ func displayResults(results []models.TestResult, duration time.Duration, debug b
 }
 
 // saveResults 保存结果到文件
-func saveResults(results []models.TestResult, savePath string) error {
+// 保存路径以 .json 结尾时输出JSON格式的测试报告，否则输出CSV
+// 有失败分析结果时，JSON报告中包含分析结果，CSV结果旁另存一份Markdown分析文件
+func saveResults(results []models.TestResult, analysis *models.FailureAnalysis, savePath string) error {
 	// 确定保存路径
 	if savePath == "" {
 		savePath = "result.csv"
@@ -518,9 +288,19 @@ func saveResults(results []models.TestResult, savePath string) error {
 
 	fmt.Printf("💾 正在保存结果到: %s\n", savePath)
 
+	if strings.EqualFold(filepath.Ext(savePath), ".json") {
+		if err := saveResultsJSON(results, analysis, savePath); err != nil {
+			return err
+		}
+		fmt.Printf("✅ 结果已保存到: %s\n", savePath)
+		return nil
+	}
+
 	// 构建CSV数据
 	csvData := [][]string{
-		{"测试用例ID", "原始请求报文", "响应体", "是否成功", "状态码", "错误信息", "耗时(ms)"},
+		{"测试用例ID", "原始请求报文", "响应体", "是否成功", "状态码", "错误信息", "耗时(ms)",
+			"最终URL", "重定向链", "请求头", "响应头", "DNS(ms)", "连接(ms)", "TLS(ms)", "首字节(ms)", "下载(ms)",
+			"响应大小(字节)", "响应是否截断", "响应体编码", "压缩编码", "压缩大小(字节)", "响应体文件"},
 	}
 
 	for _, result := range results {
@@ -532,7 +312,30 @@ func saveResults(results []models.TestResult, savePath string) error {
 			strconv.Itoa(result.StatusCode),
 			result.Error,
 			strconv.FormatInt(result.Duration, 10),
+			result.FinalURL,
+			formatRedirectChain(result.Redirects),
+			formatHeadersForCSV(result.RequestHeaders),
+			formatHeadersForCSV(result.ResponseHeaders),
+		}
+		if result.Timing != nil {
+			row = append(row,
+				formatMillis(result.Timing.DNS),
+				formatMillis(result.Timing.Connect),
+				formatMillis(result.Timing.TLS),
+				formatMillis(result.Timing.TTFB),
+				formatMillis(result.Timing.Download),
+			)
+		} else {
+			row = append(row, "", "", "", "", "")
 		}
+		row = append(row,
+			formatResponseSize(result.ResponseSize),
+			strconv.FormatBool(result.Truncated),
+			result.BodyEncoding,
+			result.ContentEncoding,
+			strconv.FormatInt(result.CompressedSize, 10),
+			result.BodyFile,
+		)
 		csvData = append(csvData, row)
 	}
 
@@ -542,9 +345,58 @@ func saveResults(results []models.TestResult, savePath string) error {
 	}
 
 	fmt.Printf("✅ 结果已保存到: %s\n", savePath)
+
+	if analysis != nil {
+		analysisPath := strings.TrimSuffix(savePath, filepath.Ext(savePath)) + "_analysis.md"
+		if err := os.WriteFile(analysisPath, []byte(formatAnalysisMarkdown(analysis, len(results))), 0644); err != nil {
+			return fmt.Errorf("写入失败分析文件失败: %v", err)
+		}
+		fmt.Printf("✅ 失败分析已保存到: %s\n", analysisPath)
+	}
 	return nil
 }
 
+// saveResultsJSON 将结果保存为JSON格式的测试报告
+func saveResultsJSON(results []models.TestResult, analysis *models.FailureAnalysis, savePath string) error {
+	report := atc.NewReport(results)
+	report.Analysis = analysis
+	return atc.SaveReport(report, savePath)
+}
+
+// formatHeadersForCSV 将HTTP头序列化为JSON字符串，便于在CSV中保存
+func formatHeadersForCSV(headers map[string][]string) string {
+	if len(headers) == 0 {
+		return ""
+	}
+	jsonBytes, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Sprintf("%v", headers)
+	}
+	return string(jsonBytes)
+}
+
+// formatRedirectChain 将重定向链格式化为单行文本，如 "302 http://a/old -> /login"
+func formatRedirectChain(hops []models.RedirectHop) string {
+	parts := make([]string, len(hops))
+	for i, hop := range hops {
+		parts[i] = fmt.Sprintf("%d %s -> %s", hop.StatusCode, hop.URL, hop.Location)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatMillis 格式化毫秒耗时，保留3位小数
+func formatMillis(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', 3, 64)
+}
+
+// formatResponseSize 格式化响应体大小，超过统计上限时为空
+func formatResponseSize(size int64) string {
+	if size == utils.UnknownBodySize {
+		return ""
+	}
+	return strconv.FormatInt(size, 10)
+}
+
 // printResponseDetails 打印响应详细信息（用于debug模式）
 func printResponseDetails(testCaseNum int, result models.TestResult) {
 	fmt.Printf("📄 测试用例 %d 响应详情:\n", testCaseNum)
@@ -560,8 +412,51 @@ func printResponseDetails(testCaseNum int, result models.TestResult) {
 		}
 		return "❌ 失败"
 	}())
+	if result.FinalURL != "" {
+		fmt.Printf("│ 最终URL:    %s\n", result.FinalURL)
+	}
+	for i, hop := range result.Redirects {
+		fmt.Printf("│ 重定向 %d:   %d %s -> %s\n", i+1, hop.StatusCode, hop.URL, hop.Location)
+	}
+	if result.ResponseSize == utils.UnknownBodySize {
+		fmt.Print("│ 响应大小:   未知（超过统计上限）")
+	} else {
+		fmt.Printf("│ 响应大小:   %d字节", result.ResponseSize)
+	}
+	if result.ContentEncoding != "" {
+		fmt.Printf("（%s压缩，传输%d字节）", result.ContentEncoding, result.CompressedSize)
+	}
+	if result.Truncated {
+		fmt.Print("，已截断")
+	}
+	fmt.Println()
+	if result.BodyFile != "" {
+		if result.BodyFileTruncated {
+			fmt.Printf("│ 响应体文件: %s（超过保存上限，已截断）\n", result.BodyFile)
+		} else {
+			fmt.Printf("│ 响应体文件: %s\n", result.BodyFile)
+		}
+	}
+	if result.Timing != nil {
+		fmt.Printf("│ 耗时分解:   DNS %.3fms | 连接 %.3fms | TLS %.3fms | 首字节 %.3fms | 下载 %.3fms\n",
+			result.Timing.DNS, result.Timing.Connect, result.Timing.TLS, result.Timing.TTFB, result.Timing.Download)
+	}
 	fmt.Println("│")
 
+	// 输出响应头
+	if len(result.ResponseHeaders) > 0 {
+		fmt.Println("│ 响应头:")
+		headerKeys := make([]string, 0, len(result.ResponseHeaders))
+		for key := range result.ResponseHeaders {
+			headerKeys = append(headerKeys, key)
+		}
+		sort.Strings(headerKeys)
+		for _, key := range headerKeys {
+			fmt.Printf("│   %s: %s\n", key, strings.Join(result.ResponseHeaders[key], ", "))
+		}
+		fmt.Println("│")
+	}
+
 	// 输出错误信息（如果有）
 	if result.Error != "" {
 		fmt.Println("│ 错误信息:")
@@ -576,6 +471,10 @@ func printResponseDetails(testCaseNum int, result models.TestResult) {
 	fmt.Println("│ 响应体:")
 	if result.ResponseBody == "" {
 		fmt.Println("│   (空响应体)")
+	} else if result.BodyEncoding == utils.BodyEncodingSHA256 {
+		fmt.Printf("│   (二进制内容，SHA-256: %s)\n", result.ResponseBody)
+	} else if result.BodyEncoding == utils.BodyEncodingBase64 {
+		fmt.Printf("│   (二进制内容，Base64编码，%d字符)\n", len(result.ResponseBody))
 	} else {
 		// 尝试格式化JSON响应体
 		var jsonData any
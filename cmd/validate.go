@@ -4,6 +4,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/morsuning/ai-auto-test-cmd/utils"
 	"github.com/spf13/cobra"
@@ -22,8 +23,11 @@ var validateCmd = &cobra.Command{
 - 日期格式和范围是否正确
 - 数值范围是否合理
 - 内置数据是否完整
+- 约束名称中的路径选择器是否有效
 - 配置项是否符合规范
 
+使用 --verbose 时还会列出正例报文中每个字段匹配到的约束，以及没有匹配任何字段的约束。
+
 如果不指定配置文件，将验证默认的 config.toml 文件。`,
 	Example: `  # 验证默认配置文件
   atc validate
@@ -76,6 +80,38 @@ func runValidate(cmd *cobra.Command, args []string) {
 	// 如果启用详细模式，显示配置统计信息
 	if verbose {
 		showConfigStats(config)
+		showConstraintMatches(config)
+	}
+}
+
+// showConstraintMatches 显示正例报文中每个字段匹配到的约束
+func showConstraintMatches(config *utils.Config) {
+	if !utils.IsConstraintsEnabled(config) || len(config.Constraints.Constraints) == 0 || config.TestCase.PositiveExample == "" {
+		return
+	}
+
+	format := config.TestCase.Type
+	if format == "" {
+		format = "json"
+	}
+	constraints := config.Constraints.Constraints
+	matches, err := utils.MatchCaseConstraints(config.TestCase.PositiveExample, format, constraints)
+	if err != nil {
+		fmt.Printf("\n⚠️  无法解析正例报文，跳过约束匹配: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n🔗 正例字段的约束匹配:")
+	for _, match := range matches {
+		if match.Constraint == "" {
+			fmt.Printf("  - %s: 无约束\n", match.Field)
+		} else {
+			fmt.Printf("  - %s → %s (%s)\n", match.Field, match.Constraint, constraints[match.Constraint].Type)
+		}
+	}
+
+	if unmatched := utils.UnmatchedConstraints(matches, constraints); len(unmatched) > 0 {
+		fmt.Printf("\n⚠️  以下约束没有匹配正例中的任何字段: %s\n", strings.Join(unmatched, ", "))
 	}
 }
 
@@ -159,13 +195,13 @@ func showConfigStats(config *utils.Config) {
 			fmt.Printf("    - 邮箱域名: %d 个\n", len(builtinData.EmailDomains))
 		}
 		if len(builtinData.BankCards) > 0 {
-			fmt.Printf("    - 银行卡号: %d 个\n", len(builtinData.BankCards))
+			fmt.Printf("    - 银行卡号: %d 个（未设置 bin_prefixes、length 的 bank_card 约束从中选择）\n", len(builtinData.BankCards))
 		}
 		if len(builtinData.PhoneNumbers) > 0 {
 			fmt.Printf("    - 手机号: %d 个\n", len(builtinData.PhoneNumbers))
 		}
 		if len(builtinData.IDCards) > 0 {
-			fmt.Printf("    - 身份证号: %d 个\n", len(builtinData.IDCards))
+			fmt.Printf("    - 身份证号: %d 个（未设置 min_age、max_age、gender 的 id_card 约束从中选择）\n", len(builtinData.IDCards))
 		}
 	}
 
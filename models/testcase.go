@@ -13,13 +13,44 @@ type TestCase struct {
 
 // TestResult 表示一个测试结果
 type TestResult struct {
-	TestCaseID   string `json:"test_case_id"`    // 测试用例ID
-	Success      bool   `json:"success"`         // 是否成功
-	StatusCode   int    `json:"status_code"`     // HTTP状态码
-	ResponseBody string `json:"response_body"`   // 响应体
-	RequestBody  string `json:"request_body"`    // 原始请求报文
-	Error        string `json:"error,omitempty"` // 错误信息（如果有）
-	Duration     int64  `json:"duration"`        // 执行时间（毫秒）
+	TestCaseID        string              `json:"test_case_id"`                  // 测试用例ID
+	Success           bool                `json:"success"`                       // 是否成功
+	StatusCode        int                 `json:"status_code"`                   // HTTP状态码
+	ResponseBody      string              `json:"response_body"`                 // 响应体
+	RequestBody       string              `json:"request_body"`                  // 原始请求报文
+	Error             string              `json:"error,omitempty"`               // 错误信息（如果有）
+	Duration          int64               `json:"duration"`                      // 执行时间（毫秒）
+	FinalURL          string              `json:"final_url,omitempty"`           // 跟随重定向后的最终URL
+	RequestHeaders    map[string][]string `json:"request_headers,omitempty"`     // 实际发送的请求头
+	ResponseHeaders   map[string][]string `json:"response_headers,omitempty"`    // 响应头
+	Timing            *TimingBreakdown    `json:"timing,omitempty"`              // 耗时分解
+	Redirects         []RedirectHop       `json:"redirects,omitempty"`           // 重定向链
+	ResponseSize      int64               `json:"response_size"`                 // 响应体大小（解压后，字节），超过统计上限时为-1（未知）
+	Truncated         bool                `json:"truncated,omitempty"`           // 响应体是否因超过大小限制被截断
+	BodyEncoding      string              `json:"body_encoding,omitempty"`       // 响应体编码方式（text/base64/sha256）
+	ContentEncoding   string              `json:"content_encoding,omitempty"`    // 响应压缩编码（如gzip）
+	CompressedSize    int64               `json:"compressed_size,omitempty"`     // 压缩响应的传输大小（字节）
+	BodyFile          string              `json:"body_file,omitempty"`           // 完整响应体的保存路径
+	BodyFileTruncated bool                `json:"body_file_truncated,omitempty"` // 保存的响应体文件是否因超过保存上限被截断
+}
+
+// RedirectHop 表示重定向链中的一跳
+type RedirectHop struct {
+	URL        string `json:"url"`         // 返回重定向的请求URL
+	StatusCode int    `json:"status_code"` // 重定向状态码
+	Location   string `json:"location"`    // Location响应头
+}
+
+// TimingBreakdown 表示一次请求各阶段的耗时（毫秒）
+// 未发生的阶段（如复用连接时的DNS、连接、TLS）为0
+// 发生重定向时各阶段只统计最后一跳，Total 包含所有跳
+type TimingBreakdown struct {
+	DNS      float64 `json:"dns_ms"`      // DNS解析耗时
+	Connect  float64 `json:"connect_ms"`  // TCP连接耗时
+	TLS      float64 `json:"tls_ms"`      // TLS握手耗时
+	TTFB     float64 `json:"ttfb_ms"`     // 请求发送完成到收到首字节的耗时（服务端处理时间）
+	Download float64 `json:"download_ms"` // 收到首字节到读取完响应体的耗时
+	Total    float64 `json:"total_ms"`    // 总耗时
 }
 
 // TestSuite 表示一组测试用例
@@ -41,4 +72,23 @@ type TestReport struct {
 		Success int `json:"success"` // 成功数
 		Failed  int `json:"failed"`  // 失败数
 	} `json:"summary"` // 摘要
+	Analysis *FailureAnalysis `json:"analysis,omitempty"` // 失败用例分析
+}
+
+// FailureAnalysis 表示失败用例的分组和根因分析
+type FailureAnalysis struct {
+	Groups   []FailureGroup `json:"groups"`             // 按状态码、错误信息和响应体特征归类的失败分组
+	Summary  string         `json:"summary,omitempty"`  // LLM给出的根因分析和修正建议（Markdown）
+	Provider string         `json:"provider,omitempty"` // 进行分析的LLM提供方
+	Model    string         `json:"model,omitempty"`    // 进行分析的模型
+	Error    string         `json:"error,omitempty"`    // LLM分析失败时的错误信息
+}
+
+// FailureGroup 表示一组特征相同的失败用例
+type FailureGroup struct {
+	StatusCode  int      `json:"status_code"`            // HTTP状态码（0表示未收到响应）
+	Error       string   `json:"error,omitempty"`        // 归一化后的错误信息
+	BodyPattern string   `json:"body_pattern,omitempty"` // 归一化后的响应体特征
+	Count       int      `json:"count"`                  // 用例数量
+	TestCaseIDs []string `json:"test_case_ids"`          // 用例ID列表
 }
@@ -0,0 +1,160 @@
+// Package atc 提供可嵌入Go测试代码的API自动化测试接口
+//
+// 包内函数与命令行工具的 local-gen、llm-gen、request 命令行为一致，便于在 go test 中直接调用，
+// 无需启动命令行程序。本地生成和批量请求不向标准输出打印信息：
+//
+//	result, err := atc.GenerateLocal(atc.LocalOptions{Payload: `{"name":"张三"}`, Num: 20})
+//	report, err := atc.Run(result.TestCases, atc.RunOptions{URL: server.URL, Method: "POST"})
+//	if report.Summary.Failed > 0 { ... }
+package atc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/morsuning/ai-auto-test-cmd/models"
+	"github.com/morsuning/ai-auto-test-cmd/utils"
+)
+
+// 报文格式
+const (
+	FormatJSON = "json" // JSON报文
+	FormatXML  = "xml"  // XML报文
+)
+
+// 本地生成模式
+const (
+	ModeRandom   = utils.GenerationModeRandom   // 随机变化模式（默认）
+	ModeNegative = utils.GenerationModeNegative // 反例模式
+	ModeMixed    = utils.GenerationModeMixed    // 混合模式，按比例生成正例和反例
+	ModeBoundary = utils.GenerationModeBoundary // 边界值模式
+)
+
+// 测试用例类型
+const (
+	CaseTypePositive = utils.CaseTypePositive // 正例
+	CaseTypeNegative = utils.CaseTypeNegative // 反例
+)
+
+// 以下类型是 utils 包中对应类型的别名，调用方只需导入 atc 包即可构造各项选项
+type (
+	ConstraintConfig    = utils.ConstraintConfig    // 约束配置
+	FieldConstraint     = utils.FieldConstraint     // 字段约束
+	BuiltinData         = utils.BuiltinData         // 约束使用的内置数据
+	LLMConfig           = utils.LLMConfig           // LLM配置
+	LLMProvider         = utils.LLMProvider         // LLM提供方，可自行实现以接入其他服务或在测试中返回固定结果
+	GenerationRequest   = utils.GenerationRequest   // LLM生成请求，实现 LLMProvider 时使用
+	BatchOptions        = utils.BatchOptions        // 分批生成选项
+	RedirectPolicy      = utils.RedirectPolicy      // 重定向策略
+	ResponseBodyOptions = utils.ResponseBodyOptions // 响应体读取选项
+	HTTPRequest         = utils.HTTPRequest         // 构建好的HTTP请求
+	HTTPResponse        = utils.HTTPResponse        // HTTP响应
+)
+
+// 测试用例数据中保存完整报文的特殊键，请求时直接作为请求体发送
+const (
+	JSONContentKey = "_json_content" // 完整的JSON报文
+	XMLContentKey  = "_xml_content"  // 完整的XML报文
+)
+
+// LoadTestCases 读取CSV测试用例文件并解析为测试用例
+func LoadTestCases(path string) ([]models.TestCase, error) {
+	data, err := utils.ReadCSV(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV文件失败: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("CSV文件为空")
+	}
+	return TestCasesFromCSV(data)
+}
+
+// TestCasesFromCSV 将CSV数据解析为测试用例
+// 第一列标题为 xml 或 json 时每行是一个完整报文（之后可以有一列用例标签），否则每列是一个字段
+func TestCasesFromCSV(data [][]string) ([]models.TestCase, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("CSV文件至少需要包含标题行和一行数据")
+	}
+
+	headers := data[0]
+	testCases := make([]models.TestCase, 0, len(data)-1)
+
+	// 检查是否是XML或JSON单列格式（报文列之后可以有一列用例标签）
+	payloadFormat := utils.DetectCSVPayloadFormat(headers)
+	isXMLFormat := payloadFormat == FormatXML
+	isJSONFormat := payloadFormat == FormatJSON
+	hasLabel := payloadFormat != "" && len(headers) == 2
+
+	for i, row := range data[1:] {
+		if len(row) != len(headers) {
+			return nil, fmt.Errorf("第%d行数据列数与标题行不匹配", i+2)
+		}
+
+		var testData map[string]any
+
+		if isXMLFormat {
+			// XML格式：直接使用XML字符串
+			testData = map[string]any{
+				XMLContentKey: row[0], // 使用特殊键存储XML内容
+			}
+		} else if isJSONFormat {
+			// JSON格式：直接使用JSON字符串
+			testData = map[string]any{
+				JSONContentKey: row[0], // 使用特殊键存储JSON内容
+			}
+		} else {
+			// 普通格式：构建测试数据
+			testData = make(map[string]any)
+			for j, value := range row {
+				testData[headers[j]] = parseValue(value)
+			}
+		}
+
+		testCase := models.TestCase{
+			ID:          fmt.Sprintf("test_%d", i+1),
+			Name:        fmt.Sprintf("测试用例_%d", i+1),
+			Description: fmt.Sprintf("从CSV第%d行生成的测试用例", i+2),
+			Type:        "auto",
+			Data:        testData,
+		}
+
+		// 带有用例标签时，使用标签中的用例类型和变异说明
+		if hasLabel {
+			caseType, description := utils.ParseCaseLabel(row[1])
+			testCase.Type = caseType
+			testCase.Description = fmt.Sprintf("%s（CSV第%d行）", description, i+2)
+		}
+
+		testCases = append(testCases, testCase)
+	}
+
+	return testCases, nil
+}
+
+// parseValue 解析字符串值为合适的类型
+func parseValue(value string) any {
+	// 尝试解析为数字
+	if intVal, err := strconv.Atoi(value); err == nil {
+		return intVal
+	}
+
+	// 尝试解析为浮点数
+	if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+		return floatVal
+	}
+
+	// 尝试解析为布尔值
+	if boolVal, err := strconv.ParseBool(value); err == nil {
+		return boolVal
+	}
+
+	// 尝试解析为JSON
+	var jsonVal any
+	if err := json.Unmarshal([]byte(value), &jsonVal); err == nil {
+		return jsonVal
+	}
+
+	// 默认返回字符串
+	return value
+}
@@ -0,0 +1,161 @@
+package atc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/morsuning/ai-auto-test-cmd/models"
+)
+
+// TestGenerateLocalAndRun 测试本地生成的用例可以直接批量请求并得到测试报告
+func TestGenerateLocalAndRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var data map[string]any
+		if r.Header.Get("Authorization") != "Bearer token" || json.Unmarshal(body, &data) != nil || data["age"] == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":"ok"}`))
+	}))
+	defer server.Close()
+
+	result, err := GenerateLocal(LocalOptions{Payload: `{"name":"张三","age":30}`, Mode: ModeNegative, Num: 4, Seed: 1})
+	if err != nil {
+		t.Fatalf("生成用例失败: %v", err)
+	}
+	testCases := result.TestCases
+	if result.Seed != 1 {
+		t.Errorf("返回的种子错误: %d", result.Seed)
+	}
+	if len(testCases) != 4 || testCases[0].Type != CaseTypeNegative || testCases[0].Data[JSONContentKey] == nil {
+		t.Fatalf("生成的用例错误: %+v", testCases)
+	}
+	again, _ := GenerateLocal(LocalOptions{Payload: `{"name":"张三","age":30}`, Mode: ModeNegative, Num: 4, Seed: 1})
+	if again.TestCases[3].Data[JSONContentKey] != testCases[3].Data[JSONContentKey] {
+		t.Error("相同种子生成的用例应相同")
+	}
+
+	// 未指定种子时返回随机生成的种子，传回选项可以复现相同的用例
+	random, err := GenerateLocal(LocalOptions{Payload: `{"name":"张三","age":30}`, Num: 5})
+	if err != nil || random.Seed == 0 || random.ReferenceDate.IsZero() {
+		t.Fatalf("应返回实际使用的种子和参考日期: %+v %v", random, err)
+	}
+	replay, _ := GenerateLocal(LocalOptions{Payload: `{"name":"张三","age":30}`, Num: 5, Seed: random.Seed, ReferenceDate: random.ReferenceDate})
+	for i := range random.TestCases {
+		if replay.TestCases[i].Data[JSONContentKey] != random.TestCases[i].Data[JSONContentKey] {
+			t.Fatalf("使用返回的种子应复现相同的用例: %v != %v", replay.TestCases[i].Data, random.TestCases[i].Data)
+		}
+	}
+
+	report, err := Run(testCases, RunOptions{URL: server.URL, Auth: Auth{BearerToken: "token"}, Concurrency: 2, ReportName: "集成测试"})
+	if err != nil {
+		t.Fatalf("批量请求失败: %v", err)
+	}
+	if report.Name != "集成测试" || report.Summary.Total != 4 || report.Summary.Success+report.Summary.Failed != 4 {
+		t.Errorf("测试报告统计错误: %+v", report.Summary)
+	}
+	// 删除age字段的反例应返回400
+	if report.Summary.Failed == 0 || len(FailedResults(report)) != report.Summary.Failed {
+		t.Errorf("失败用例统计错误: %+v", report.Summary)
+	}
+
+	path := filepath.Join(t.TempDir(), "report", "result.json")
+	if err := SaveReport(report, path); err != nil {
+		t.Fatalf("保存测试报告失败: %v", err)
+	}
+
+	if _, err := Run(testCases, RunOptions{}); err == nil {
+		t.Error("未指定URL时应返回错误")
+	}
+}
+
+// TestBuildRequests 测试请求体格式、鉴权和查询参数
+func TestBuildRequests(t *testing.T) {
+	testCases, err := TestCasesFromCSV([][]string{{"name", "age"}, {"张三", "30"}})
+	if err != nil {
+		t.Fatalf("解析CSV失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    RunOptions
+		body    string
+		headers map[string]string
+		url     string
+	}{
+		{
+			name:    "默认POST JSON",
+			opts:    RunOptions{URL: "localhost:8080/api", Auth: Auth{APIKey: "X-Token:abc"}},
+			body:    `{"age":30,"name":"张三"}`,
+			headers: map[string]string{"Content-Type": "application/json", "X-Token": "abc"},
+			url:     "http://localhost:8080/api",
+		},
+		{
+			name:    "GET XML带查询参数",
+			opts:    RunOptions{URL: "https://example.com/api?a=1", Method: "get", Format: "xml", Query: []string{"b=2"}, Auth: Auth{BasicAuth: "user:pass"}},
+			body:    "<name>张三</name>",
+			headers: map[string]string{"Content-Type": "application/xml", "Accept": "application/json", "Authorization": "Basic dXNlcjpwYXNz"},
+			url:     "https://example.com/api?a=1&b=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, err := BuildRequests(testCases, tt.opts)
+			if err != nil {
+				t.Fatalf("构建请求失败: %v", err)
+			}
+			request := requests[0]
+			if request.URL != tt.url || !strings.Contains(request.Body, tt.body) {
+				t.Errorf("请求错误: %s %s", request.URL, request.Body)
+			}
+			for key, value := range tt.headers {
+				if request.Headers[key] != value {
+					t.Errorf("请求头 %s 错误: %q", key, request.Headers[key])
+				}
+			}
+		})
+	}
+
+	if _, err := BuildRequests(testCases, RunOptions{URL: "localhost", Format: "yaml"}); err == nil {
+		t.Error("不支持的请求体格式应返回错误")
+	}
+	if _, err := BuildRequests(testCases, RunOptions{URL: "localhost", Auth: Auth{CustomHeaders: []string{"invalid"}}}); err == nil {
+		t.Error("格式错误的自定义HTTP头应返回错误")
+	}
+}
+
+// TestBuildRequestsSaveDir 测试保存响应体的子目录不会因用例ID逃逸出保存目录
+func TestBuildRequestsSaveDir(t *testing.T) {
+	saveDir := filepath.Join("out", "bodies")
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{name: "普通ID", id: "test_1", want: filepath.Join(saveDir, "test_1")},
+		{name: "上级目录", id: "..", want: filepath.Join(saveDir, "case_1")},
+		{name: "包含路径分隔符", id: "../../etc", want: filepath.Join(saveDir, "case_1")},
+		{name: "反斜杠", id: `..\evil`, want: filepath.Join(saveDir, "case_1")},
+		{name: "空ID", id: "", want: filepath.Join(saveDir, "case_1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCases := []models.TestCase{{ID: tt.id, Data: map[string]any{"name": "张三"}}}
+			requests, err := BuildRequests(testCases, RunOptions{URL: "localhost", ResponseBody: ResponseBodyOptions{SaveDir: saveDir}})
+			if err != nil {
+				t.Fatalf("构建请求失败: %v", err)
+			}
+			if got := requests[0].Response.SaveDir; got != tt.want {
+				t.Errorf("保存目录错误: %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package atc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/morsuning/ai-auto-test-cmd/models"
+	"github.com/morsuning/ai-auto-test-cmd/utils"
+)
+
+// LocalOptions 本地生成选项，零值字段使用与 local-gen 命令相同的默认值
+type LocalOptions struct {
+	Payload       string            // 正例报文
+	Format        string            // 报文格式（json或xml，为空时根据报文内容检测）
+	Num           int               // 生成数量（默认10，组合策略下为最大数量，0表示不限制）
+	Mode          string            // 生成模式（random、negative、mixed或boundary，默认random）
+	NegativeRatio float64           // 混合模式下反例的占比（默认0.3）
+	Strategy      string            // 组合策略（pairwise或N-wise，为空时不使用）
+	Seed          int64             // 随机数种子（0表示随机），种子和其他选项相同时生成的用例相同
+	VariationRate float64           // 随机化因子（默认0.5）
+	ReferenceDate time.Time         // 计算身份证年龄范围的参考日期（零值表示当天），与种子一起决定生成结果
+	Constraints   *ConstraintConfig // 约束配置，为nil时不使用约束
+}
+
+// LocalResult 本地生成的结果
+type LocalResult struct {
+	TestCases     []models.TestCase // 生成的测试用例
+	Seed          int64             // 实际使用的随机数种子，LocalOptions.Seed 为0时为随机生成的种子
+	ReferenceDate time.Time         // 实际使用的参考日期，LocalOptions.ReferenceDate 为零值时为当天
+}
+
+// GenerateLocal 根据正例报文在本地生成测试用例，不调用LLM
+// 生成的用例数据中保存完整报文（JSONContentKey 或 XMLContentKey），可以直接传给 Run；
+// 结果中的种子和参考日期传回 LocalOptions 可以复现本次生成的用例
+func GenerateLocal(opts LocalOptions) (LocalResult, error) {
+	format, err := detectFormat(opts.Payload, opts.Format)
+	if err != nil {
+		return LocalResult{}, err
+	}
+	isXML := format == FormatXML
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = utils.GenerationModeRandom
+	}
+	if mode != utils.GenerationModeRandom && mode != utils.GenerationModeNegative && mode != utils.GenerationModeMixed && mode != utils.GenerationModeBoundary {
+		return LocalResult{}, fmt.Errorf("不支持的生成模式 '%s'，支持: %s, %s, %s, %s", mode, utils.GenerationModeRandom, utils.GenerationModeNegative, utils.GenerationModeMixed, utils.GenerationModeBoundary)
+	}
+	negativeRatio := opts.NegativeRatio
+	if negativeRatio == 0 {
+		negativeRatio = utils.DefaultNegativeRatio
+	}
+	if negativeRatio < 0 || negativeRatio > 1 {
+		return LocalResult{}, fmt.Errorf("反例占比必须在0.0-1.0之间")
+	}
+	var strength int
+	if opts.Strategy != "" {
+		if mode != utils.GenerationModeRandom {
+			return LocalResult{}, fmt.Errorf("组合策略不能与反例、混合或边界值模式同时使用")
+		}
+		if strength, err = utils.ParseStrategyStrength(opts.Strategy); err != nil {
+			return LocalResult{}, err
+		}
+	}
+
+	num := opts.Num
+	if num == 0 && opts.Strategy == "" {
+		num = 10
+	}
+	if num < 0 {
+		return LocalResult{}, fmt.Errorf("生成数量不能为负数")
+	}
+	variationRate := opts.VariationRate
+	if variationRate <= 0 {
+		variationRate = utils.DefaultVariationRate
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = utils.NewRandomSeed()
+	}
+	generator := utils.NewGenerator(opts.Constraints, variationRate, seed)
+	if !opts.ReferenceDate.IsZero() {
+		generator.ReferenceDate = opts.ReferenceDate
+	}
+
+	// 解析报文
+	var data map[string]any
+	if isXML {
+		data, err = generator.ParseXML(opts.Payload)
+	} else {
+		data, err = generator.ParseJSON(opts.Payload)
+	}
+	if err != nil {
+		return LocalResult{}, fmt.Errorf("解析%s报文失败: %v", strings.ToUpper(format), err)
+	}
+
+	// 生成带标签的用例，随机模式下全部标记为正例
+	var labeledCases []utils.LabeledTestCase
+	switch {
+	case opts.Strategy != "":
+		result, err := generator.GenerateCombinatorialTestCases(data, strength, num)
+		if err != nil {
+			return LocalResult{}, err
+		}
+		labeledCases = result.TestCases
+	case mode == utils.GenerationModeBoundary:
+		if opts.Constraints == nil {
+			return LocalResult{}, fmt.Errorf("边界值模式需要指定约束配置")
+		}
+		labeledCases = generator.GenerateBoundaryTestCases(data)
+		if len(labeledCases) == 0 {
+			return LocalResult{}, fmt.Errorf("报文中没有配置了 integer、float、date 或 datetime 约束的字段，无法生成边界值用例")
+		}
+	case mode == utils.GenerationModeNegative:
+		labeledCases = generator.GenerateNegativeTestCases(data, num, isXML)
+	case mode == utils.GenerationModeMixed:
+		labeledCases = generator.GenerateMixedTestCases(data, num, negativeRatio, isXML)
+	default:
+		labeledCases = utils.LabelPositiveTestCases(generator.GenerateTestCases(data, num))
+	}
+	if err := generator.Err(); err != nil {
+		return LocalResult{}, err
+	}
+
+	// 按原始报文格式序列化后作为完整报文保存
+	rows := generator.ConvertToLabeledRows(labeledCases, isXML)
+	contentKey := JSONContentKey
+	if isXML {
+		contentKey = XMLContentKey
+	}
+	testCases := make([]models.TestCase, 0, len(labeledCases))
+	for i, labeledCase := range labeledCases {
+		testCases = append(testCases, models.TestCase{
+			ID:          fmt.Sprintf("test_%d", i+1),
+			Name:        fmt.Sprintf("测试用例_%d", i+1),
+			Description: labeledCase.Description,
+			Type:        labeledCase.Type,
+			Data:        map[string]any{contentKey: rows[i+1][0]},
+		})
+	}
+	return LocalResult{TestCases: testCases, Seed: seed, ReferenceDate: generator.ReferenceDate}, nil
+}
+
+// detectFormat 检查报文格式并验证报文，未指定格式时以 < 开头的报文视为XML
+func detectFormat(payload, format string) (string, error) {
+	if strings.TrimSpace(payload) == "" {
+		return "", fmt.Errorf("必须指定正例报文")
+	}
+	format = strings.ToLower(format)
+	if format == "" {
+		format = FormatJSON
+		if strings.HasPrefix(strings.TrimSpace(payload), "<") {
+			format = FormatXML
+		}
+	}
+
+	switch format {
+	case FormatXML:
+		if err := utils.ValidateXMLFormat(payload); err != nil {
+			return "", fmt.Errorf("XML格式验证失败: %v", err)
+		}
+	case FormatJSON:
+		if err := utils.ValidateJSONFormat(payload); err != nil {
+			return "", fmt.Errorf("JSON格式验证失败: %v", err)
+		}
+	default:
+		return "", fmt.Errorf("不支持的报文格式 '%s'，支持: %s, %s", format, FormatJSON, FormatXML)
+	}
+	return format, nil
+}
+
+// LLMOptions LLM生成选项
+type LLMOptions struct {
+	Config           LLMConfig                  // LLM配置，Provider为nil时用于创建提供方
+	Provider         LLMProvider                // LLM提供方（可选，指定时忽略Config中的连接参数）
+	Payload          string                     // 正例报文
+	Format           string                     // 报文格式（json或xml，为空时根据报文内容检测）
+	Num              int                        // 生成数量（默认10）
+	UserPrompt       string                     // 自定义提示词
+	Constraints      map[string]FieldConstraint // 字段约束，作为constraints输入传给LLM
+	CheckConstraints bool                       // 使用约束校验生成的用例
+	Repair           bool                       // 请求LLM重新生成结构损坏的用例
+	Batch            BatchOptions               // 分批生成选项
+	Output           string                     // 生成的CSV文件路径（为空时使用临时文件，生成后删除）
+}
+
+// GenerateLLM 调用LLM生成测试用例，流程与 llm-gen 命令相同（分批、去重、结构校验）
+// 由Config创建的提供方与命令行一样默认使用磁盘缓存；LLM流式返回的文本不实时输出，
+// 分批进度、用例校验结果等汇总信息仍输出到标准输出
+func GenerateLLM(opts LLMOptions) ([]models.TestCase, error) {
+	format, err := detectFormat(opts.Payload, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	num := opts.Num
+	if num == 0 {
+		num = 10
+	}
+	if num < 0 {
+		return nil, fmt.Errorf("生成数量不能为负数")
+	}
+
+	provider := opts.Provider
+	if provider == nil {
+		if provider, err = utils.NewLLMProvider(opts.Config); err != nil {
+			return nil, err
+		}
+		if opts.Config.Cache == nil || *opts.Config.Cache {
+			provider = &utils.CachedProvider{
+				Provider: provider,
+				Cache:    utils.NewLLMCache(opts.Config.CacheDir),
+				URL:      opts.Config.URL,
+				Model:    opts.Config.Model,
+				APIKey:   opts.Config.APIKey,
+			}
+		}
+	}
+
+	output := opts.Output
+	if output == "" {
+		dir, err := os.MkdirTemp("", "atc-llm-")
+		if err != nil {
+			return nil, fmt.Errorf("创建临时目录失败: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		output = filepath.Join(dir, "test_cases.csv")
+	}
+
+	req := utils.GenerationRequest{
+		PositiveExample:  opts.Payload,
+		Format:           format,
+		Num:              num,
+		UserPrompt:       opts.UserPrompt,
+		Constraints:      opts.Constraints,
+		CheckConstraints: opts.CheckConstraints,
+		RepairBroken:     opts.Repair,
+		Quiet:            true,
+	}
+	if _, err := utils.GenerateTestCasesInBatches(provider, req, opts.Batch, output); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(output); err != nil {
+		return nil, fmt.Errorf("LLM未返回测试用例数据")
+	}
+	return LoadTestCases(output)
+}
@@ -0,0 +1,62 @@
+package atc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/morsuning/ai-auto-test-cmd/models"
+)
+
+// NewReport 根据测试结果构建测试报告并统计成功和失败数量
+func NewReport(results []models.TestResult) models.TestReport {
+	now := time.Now()
+	report := models.TestReport{
+		ID:        fmt.Sprintf("report_%s", now.Format("20060102_150405")),
+		Name:      "批量请求测试报告",
+		Timestamp: now.Unix(),
+		Results:   results,
+	}
+	report.Summary.Total = len(results)
+	for _, result := range results {
+		if result.Success {
+			report.Summary.Success++
+		} else {
+			report.Summary.Failed++
+		}
+	}
+	return report
+}
+
+// SaveReport 将测试报告保存为JSON文件，目录不存在时自动创建
+func SaveReport(report models.TestReport, path string) error {
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化测试报告失败: %v", err)
+	}
+
+	// 确保目录存在
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(path, jsonBytes, 0644); err != nil {
+		return fmt.Errorf("写入测试报告失败: %v", err)
+	}
+	return nil
+}
+
+// FailedResults 返回测试报告中失败的测试结果
+func FailedResults(report models.TestReport) []models.TestResult {
+	var failed []models.TestResult
+	for _, result := range report.Results {
+		if !result.Success {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
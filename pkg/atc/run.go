@@ -0,0 +1,334 @@
+package atc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/morsuning/ai-auto-test-cmd/models"
+	"github.com/morsuning/ai-auto-test-cmd/utils"
+)
+
+// Auth 鉴权配置
+type Auth struct {
+	BearerToken   string   // Bearer Token认证
+	BasicAuth     string   // Basic Auth认证（username:password格式，格式不正确时忽略）
+	APIKey        string   // API Key认证（Header:Value格式，只有值时使用X-API-Key）
+	CustomHeaders []string // 自定义HTTP头（Key: Value格式）
+}
+
+// RunOptions 批量请求选项，零值字段使用默认值
+type RunOptions struct {
+	URL          string              // 目标URL，未指定协议时使用HTTP
+	Method       string              // 请求方法（默认POST）
+	Format       string              // 请求体格式（json或xml，默认json）
+	Timeout      int                 // 请求超时时间（秒，默认30）
+	Concurrency  int                 // 并发请求数（默认1）
+	Auth         Auth                // 鉴权配置
+	Query        []string            // URL查询参数（key=value格式）
+	IgnoreTLS    bool                // 忽略TLS证书验证错误
+	Redirect     RedirectPolicy      // 重定向策略
+	ResponseBody ResponseBodyOptions // 响应体读取选项
+	ReportName   string              // 测试报告名称（默认"批量请求测试报告"）
+}
+
+// withDefaults 返回填充默认值后的批量请求选项
+func (o RunOptions) withDefaults() RunOptions {
+	if o.Method == "" {
+		o.Method = "POST"
+	}
+	if o.Format == "" {
+		o.Format = FormatJSON
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 30
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	return o
+}
+
+// Run 并发发送测试用例请求，返回包含全部结果的测试报告
+// 请求失败或状态码不是2xx的用例记为失败，不作为错误返回；只有选项不合法时返回错误
+func Run(testCases []models.TestCase, opts RunOptions) (models.TestReport, error) {
+	if opts.URL == "" {
+		return models.TestReport{}, fmt.Errorf("必须指定目标URL")
+	}
+	opts = opts.withDefaults()
+
+	requests, err := BuildRequests(testCases, opts)
+	if err != nil {
+		return models.TestReport{}, err
+	}
+	responses := utils.SendConcurrentRequests(requests, opts.Concurrency)
+
+	report := NewReport(ProcessResponses(testCases, responses, requests))
+	if opts.ReportName != "" {
+		report.Name = opts.ReportName
+	}
+	return report, nil
+}
+
+// BuildRequests 根据测试用例构建HTTP请求列表
+func BuildRequests(testCases []models.TestCase, opts RunOptions) ([]HTTPRequest, error) {
+	opts = opts.withDefaults()
+	format := strings.ToLower(opts.Format)
+	if format != FormatJSON && format != FormatXML {
+		return nil, fmt.Errorf("不支持的请求体格式 '%s'，支持: %s, %s", opts.Format, FormatJSON, FormatXML)
+	}
+	method := strings.ToUpper(opts.Method)
+
+	// 检查并添加默认协议
+	url := opts.URL
+	if !HasScheme(url) {
+		url = "http://" + url
+	}
+
+	// 构建最终URL（包含查询参数）
+	if len(opts.Query) > 0 {
+		separator := "?"
+		if strings.Contains(url, "?") {
+			separator = "&"
+		}
+		url = url + separator + strings.Join(opts.Query, "&")
+	}
+
+	requests := make([]utils.HTTPRequest, len(testCases))
+	for i, testCase := range testCases {
+		headers := make(map[string]string)
+
+		// 应用鉴权配置
+		if err := applyAuth(headers, opts.Auth); err != nil {
+			return nil, err
+		}
+
+		// POST和GET请求在请求体中放置JSON/XML数据
+		body := ""
+		if method == "POST" || method == "GET" {
+			var contentType string
+			body, contentType = buildRequestBody(testCase.Data, format)
+			headers["Content-Type"] = contentType
+		}
+		if method != "POST" {
+			headers["Accept"] = "application/json"
+		}
+
+		// 保存完整响应体时，每个测试用例使用独立的子目录
+		caseBodyOptions := opts.ResponseBody
+		if caseBodyOptions.SaveDir != "" {
+			caseBodyOptions.SaveDir = filepath.Join(caseBodyOptions.SaveDir, caseBodyDir(testCase.ID, i))
+		}
+
+		requests[i] = utils.HTTPRequest{
+			URL:       url,
+			Method:    method,
+			Body:      body,
+			Headers:   headers,
+			Timeout:   opts.Timeout,
+			IgnoreTLS: opts.IgnoreTLS,
+			Redirect:  opts.Redirect,
+			Response:  caseBodyOptions,
+		}
+	}
+	return requests, nil
+}
+
+// caseBodyDir 返回保存用例响应体的子目录名
+// 用例ID为空、包含路径分隔符或为 . 、.. 时不能安全地作为目录名，改用用例序号，避免写入保存目录之外
+func caseBodyDir(id string, index int) string {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) || filepath.Base(id) != id {
+		return fmt.Sprintf("case_%d", index+1)
+	}
+	return id
+}
+
+// HasScheme 判断URL是否指定了HTTP或HTTPS协议
+func HasScheme(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+// buildRequestBody 根据请求体格式构建请求体，返回请求体和Content-Type
+// 测试数据中有完整报文时直接使用，否则由字段数据转换
+func buildRequestBody(data map[string]any, format string) (string, string) {
+	if format == FormatXML {
+		if xmlContent, exists := data[XMLContentKey]; exists {
+			return fmt.Sprintf("%v", xmlContent), "application/xml"
+		}
+		return convertToXML(data), "application/xml"
+	}
+
+	if jsonContent, exists := data[JSONContentKey]; exists {
+		return fmt.Sprintf("%v", jsonContent), "application/json"
+	}
+	jsonData, _ := json.Marshal(data)
+	return string(jsonData), "application/json"
+}
+
+// applyAuth 应用鉴权配置到HTTP头
+func applyAuth(headers map[string]string, auth Auth) error {
+	// 应用Bearer Token认证
+	if auth.BearerToken != "" {
+		headers["Authorization"] = "Bearer " + auth.BearerToken
+	}
+
+	// 应用Basic Auth认证（username:password格式，编码为Base64）
+	if ValidBasicAuth(auth.BasicAuth) {
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.BasicAuth))
+	}
+
+	// 应用API Key认证
+	if auth.APIKey != "" {
+		parts := strings.SplitN(auth.APIKey, ":", 2)
+		if len(parts) == 2 {
+			headers[parts[0]] = parts[1]
+		} else {
+			// 如果格式不正确，默认使用X-API-Key作为header名
+			headers["X-API-Key"] = auth.APIKey
+		}
+	}
+
+	// 应用自定义HTTP头
+	for _, header := range auth.CustomHeaders {
+		// 解析Key: Value格式
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("自定义HTTP头格式错误: %s，正确格式应为 'HeaderName: HeaderValue'", header)
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if key == "" {
+			return fmt.Errorf("自定义HTTP头名称不能为空: %s", header)
+		}
+		headers[key] = value
+	}
+	return nil
+}
+
+// ValidBasicAuth 判断Basic Auth认证信息是否为 username:password 格式
+func ValidBasicAuth(basicAuth string) bool {
+	return strings.Contains(basicAuth, ":")
+}
+
+// convertToXML 将字段数据转换为XML格式
+func convertToXML(data map[string]any) string {
+	// 由于Go的xml包对map支持有限，我们手动构建XML字符串
+	var xmlBuilder strings.Builder
+	xmlBuilder.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	xmlBuilder.WriteString("<data>\n")
+
+	for key, value := range data {
+		// 清理XML标签名（移除特殊字符）
+		cleanKey := strings.ReplaceAll(key, " ", "_")
+		cleanKey = strings.ReplaceAll(cleanKey, "-", "_")
+
+		xmlBuilder.WriteString(fmt.Sprintf("  <%s>", cleanKey))
+
+		// 根据值的类型进行处理
+		switch v := value.(type) {
+		case string:
+			// 转义XML特殊字符
+			escapedValue := strings.ReplaceAll(v, "&", "&amp;")
+			escapedValue = strings.ReplaceAll(escapedValue, "<", "&lt;")
+			escapedValue = strings.ReplaceAll(escapedValue, ">", "&gt;")
+			escapedValue = strings.ReplaceAll(escapedValue, "\"", "&quot;")
+			escapedValue = strings.ReplaceAll(escapedValue, "'", "&apos;")
+			xmlBuilder.WriteString(escapedValue)
+		case int, int32, int64, float32, float64, bool:
+			xmlBuilder.WriteString(fmt.Sprintf("%v", v))
+		default:
+			// 对于复杂类型，尝试JSON序列化后转义
+			jsonBytes, err := json.Marshal(v)
+			if err != nil {
+				xmlBuilder.WriteString(fmt.Sprintf("%v", v))
+			} else {
+				escapedValue := strings.ReplaceAll(string(jsonBytes), "&", "&amp;")
+				escapedValue = strings.ReplaceAll(escapedValue, "<", "&lt;")
+				escapedValue = strings.ReplaceAll(escapedValue, ">", "&gt;")
+				xmlBuilder.WriteString(escapedValue)
+			}
+		}
+
+		xmlBuilder.WriteString(fmt.Sprintf("</%s>\n", cleanKey))
+	}
+
+	xmlBuilder.WriteString("</data>")
+	return xmlBuilder.String()
+}
+
+// ProcessResponses 将HTTP响应转换为测试结果，状态码为2xx的用例记为成功
+func ProcessResponses(testCases []models.TestCase, responses []HTTPResponse, requests []HTTPRequest) []models.TestResult {
+	results := make([]models.TestResult, len(testCases))
+
+	for i, response := range responses {
+		result := models.TestResult{
+			TestCaseID:        testCases[i].ID,
+			StatusCode:        response.StatusCode,
+			ResponseBody:      response.Body,
+			Duration:          response.Duration.Milliseconds(),
+			FinalURL:          response.FinalURL,
+			RequestHeaders:    response.RequestHeaders,
+			ResponseHeaders:   response.Headers,
+			Timing:            buildTimingBreakdown(response),
+			Redirects:         buildRedirectChain(response.Redirects),
+			ResponseSize:      response.BodyInfo.Size,
+			Truncated:         response.BodyInfo.Truncated,
+			BodyEncoding:      response.BodyInfo.Encoding,
+			ContentEncoding:   response.BodyInfo.ContentEncoding,
+			CompressedSize:    response.BodyInfo.CompressedSize,
+			BodyFile:          response.BodyInfo.SavedPath,
+			BodyFileTruncated: response.BodyInfo.SavedTruncated,
+		}
+
+		// 设置原始请求报文
+		if i < len(requests) {
+			result.RequestBody = requests[i].Body
+		}
+
+		if response.Error != nil {
+			result.Success = false
+			result.Error = response.Error.Error()
+		} else {
+			// 简单判断：状态码2xx为成功
+			result.Success = response.StatusCode >= 200 && response.StatusCode < 300
+		}
+
+		results[i] = result
+	}
+
+	return results
+}
+
+// buildTimingBreakdown 将HTTP响应中的阶段耗时转换为以毫秒为单位的耗时分解
+func buildTimingBreakdown(response utils.HTTPResponse) *models.TimingBreakdown {
+	toMillis := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	return &models.TimingBreakdown{
+		DNS:      toMillis(response.Timing.DNS),
+		Connect:  toMillis(response.Timing.Connect),
+		TLS:      toMillis(response.Timing.TLS),
+		TTFB:     toMillis(response.Timing.TTFB),
+		Download: toMillis(response.Timing.Download),
+		Total:    toMillis(response.Duration),
+	}
+}
+
+// buildRedirectChain 将HTTP响应中的重定向链转换为结果模型
+func buildRedirectChain(hops []utils.RedirectHop) []models.RedirectHop {
+	if len(hops) == 0 {
+		return nil
+	}
+	chain := make([]models.RedirectHop, len(hops))
+	for i, hop := range hops {
+		chain[i] = models.RedirectHop{
+			URL:        hop.URL,
+			StatusCode: hop.StatusCode,
+			Location:   hop.Location,
+		}
+	}
+	return chain
+}
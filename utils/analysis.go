@@ -0,0 +1,208 @@
+// Package utils 提供批量请求失败用例的本地分组和LLM根因分析
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 失败分析的默认参数
+const (
+	maxAnalysisGroups     = 10   // 发送给LLM的最大分组数
+	maxSamplesPerGroup    = 2    // 每组保留的代表性用例数
+	maxAnalysisBodyLength = 1000 // 发送给LLM的报文最大长度
+	maxBodyPatternLength  = 80   // 响应体特征的最大长度
+)
+
+// 归一化错误信息和响应体时使用的规则
+var (
+	digitsPattern     = regexp.MustCompile(`\d+`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	urlPattern        = regexp.MustCompile(`https?://\S+`)
+)
+
+// bodyPatternFields 响应体中常见的表示错误类型的字段
+var bodyPatternFields = []string{"code", "errcode", "error_code", "status", "error", "message", "msg", "errmsg"}
+
+// FailureSample 表示一个失败用例的请求和响应
+type FailureSample struct {
+	TestCaseID   string // 测试用例ID
+	StatusCode   int    // HTTP状态码
+	Error        string // 错误信息
+	RequestBody  string // 请求报文
+	ResponseBody string // 响应体
+}
+
+// FailureGroup 表示按状态码、错误信息和响应体特征归类的一组失败用例
+type FailureGroup struct {
+	Key         string          // 分组标识
+	StatusCode  int             // HTTP状态码
+	Error       string          // 归一化后的错误信息
+	BodyPattern string          // 归一化后的响应体特征
+	TestCaseIDs []string        // 组内所有用例ID
+	Samples     []FailureSample // 代表性用例
+}
+
+// GroupFailures 将失败用例按状态码、错误信息和响应体特征分组，按用例数从多到少排序
+func GroupFailures(samples []FailureSample) []FailureGroup {
+	var groups []*FailureGroup
+	index := make(map[string]*FailureGroup)
+	for _, sample := range samples {
+		errorPattern := normalizeFailureText(sample.Error)
+		bodyPattern := ""
+		if sample.Error == "" {
+			bodyPattern = responseBodyPattern(sample.ResponseBody)
+		}
+		key := fmt.Sprintf("%d|%s|%s", sample.StatusCode, errorPattern, bodyPattern)
+
+		group, ok := index[key]
+		if !ok {
+			group = &FailureGroup{Key: key, StatusCode: sample.StatusCode, Error: errorPattern, BodyPattern: bodyPattern}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.TestCaseIDs = append(group.TestCaseIDs, sample.TestCaseID)
+		if len(group.Samples) < maxSamplesPerGroup {
+			group.Samples = append(group.Samples, sample)
+		}
+	}
+
+	// 稳定排序，用例数相同时保持首次出现的顺序
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].TestCaseIDs) > len(groups[j].TestCaseIDs)
+	})
+	result := make([]FailureGroup, len(groups))
+	for i, group := range groups {
+		result[i] = *group
+	}
+	return result
+}
+
+// Describe 返回分组的简短描述
+func (g FailureGroup) Describe() string {
+	parts := []string{fmt.Sprintf("状态码 %d", g.StatusCode)}
+	if g.StatusCode == 0 {
+		parts[0] = "无响应"
+	}
+	if g.Error != "" {
+		parts = append(parts, "错误: "+g.Error)
+	}
+	if g.BodyPattern != "" {
+		parts = append(parts, "响应: "+g.BodyPattern)
+	}
+	return strings.Join(parts, "，")
+}
+
+// PrintFailureGroups 输出失败分组统计
+func PrintFailureGroups(groups []FailureGroup, total int) {
+	failed := 0
+	for _, group := range groups {
+		failed += len(group.TestCaseIDs)
+	}
+	fmt.Printf("\n🧩 失败用例分组: 共 %d 个用例，失败 %d 个，归为 %d 组\n", total, failed, len(groups))
+	for i, group := range groups {
+		ids := group.TestCaseIDs
+		suffix := ""
+		if len(ids) > 5 {
+			ids = ids[:5]
+			suffix = fmt.Sprintf(" 等%d个", len(group.TestCaseIDs))
+		}
+		fmt.Printf("   %d. [%d个] %s\n", i+1, len(group.TestCaseIDs), group.Describe())
+		fmt.Printf("      用例: %s%s\n", strings.Join(ids, ", "), suffix)
+	}
+}
+
+// AnalyzeFailures 将失败分组和代表性请求/响应发送给LLM，返回根因分析文本
+func AnalyzeFailures(provider LLMProvider, groups []FailureGroup, total int, debug bool) (string, error) {
+	if len(groups) == 0 {
+		return "", nil
+	}
+	req := GenerationRequest{
+		Task:       TaskAnalyze,
+		UserPrompt: BuildFailureAnalysisPrompt(groups, total),
+		Debug:      debug,
+	}
+	text, err := provider.Generate(req)
+	if err != nil {
+		return "", fmt.Errorf("LLM分析失败: %v", err)
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// BuildFailureAnalysisPrompt 构建失败分析请求，只包含用例数最多的若干分组
+func BuildFailureAnalysisPrompt(groups []FailureGroup, total int) string {
+	failed := 0
+	for _, group := range groups {
+		failed += len(group.TestCaseIDs)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("本次批量测试共 %d 个用例，失败 %d 个，归为 %d 组。\n", total, failed, len(groups)))
+	if len(groups) > maxAnalysisGroups {
+		builder.WriteString(fmt.Sprintf("以下仅列出失败用例最多的 %d 组。\n", maxAnalysisGroups))
+		groups = groups[:maxAnalysisGroups]
+	}
+
+	for i, group := range groups {
+		builder.WriteString(fmt.Sprintf("\n## 分组 %d（%d 个用例）\n", i+1, len(group.TestCaseIDs)))
+		builder.WriteString(fmt.Sprintf("特征: %s\n", group.Describe()))
+		for j, sample := range group.Samples {
+			builder.WriteString(fmt.Sprintf("\n### 代表用例 %d（%s）\n", j+1, sample.TestCaseID))
+			builder.WriteString(fmt.Sprintf("请求报文:\n%s\n", truncateForAnalysis(sample.RequestBody)))
+			if sample.Error != "" {
+				builder.WriteString(fmt.Sprintf("错误信息: %s\n", sample.Error))
+			} else {
+				builder.WriteString(fmt.Sprintf("响应状态码: %d\n响应体:\n%s\n", sample.StatusCode, truncateForAnalysis(sample.ResponseBody)))
+			}
+		}
+	}
+	return builder.String()
+}
+
+// normalizeFailureText 归一化错误信息，去掉URL、数字等易变部分以便归类
+func normalizeFailureText(text string) string {
+	text = urlPattern.ReplaceAllString(text, "<url>")
+	text = digitsPattern.ReplaceAllString(text, "#")
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+// responseBodyPattern 提取响应体特征
+// JSON响应优先使用错误码、错误信息等字段，其余响应使用归一化后的文本开头
+func responseBodyPattern(body string) string {
+	var object map[string]any
+	if json.Unmarshal([]byte(body), &object) == nil {
+		var parts []string
+		for _, field := range bodyPatternFields {
+			if value, ok := object[field]; ok {
+				if _, nested := value.(map[string]any); nested {
+					continue
+				}
+				parts = append(parts, fmt.Sprintf("%s=%v", field, value))
+			}
+		}
+		if len(parts) > 0 {
+			return truncateRunes(normalizeFailureText(strings.Join(parts, " ")), maxBodyPatternLength)
+		}
+	}
+	return truncateRunes(normalizeFailureText(body), maxBodyPatternLength)
+}
+
+// truncateForAnalysis 截断发送给LLM的报文
+func truncateForAnalysis(text string) string {
+	if text == "" {
+		return "（空）"
+	}
+	return truncateRunes(text, maxAnalysisBodyLength)
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "..."
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGroupFailures 测试失败用例按状态码、错误信息和响应体特征分组
+func TestGroupFailures(t *testing.T) {
+	samples := []FailureSample{
+		{TestCaseID: "test_1", StatusCode: 400, ResponseBody: `{"code":1001,"msg":"手机号格式错误"}`},
+		{TestCaseID: "test_2", StatusCode: 500, ResponseBody: "Internal Server Error"},
+		{TestCaseID: "test_3", StatusCode: 400, ResponseBody: `{"code":1002,"msg":"手机号格式错误","data":{"id":3}}`},
+		{TestCaseID: "test_4", Error: "Post http://localhost:8080/api: dial tcp 127.0.0.1:8080: connect: connection refused"},
+		{TestCaseID: "test_5", StatusCode: 400, ResponseBody: `{"code":1003,"msg":"手机号格式错误"}`},
+		{TestCaseID: "test_6", Error: "Post http://localhost:8080/api: dial tcp 127.0.0.1:9090: connect: connection refused"},
+	}
+
+	groups := GroupFailures(samples)
+	tests := []struct {
+		name        string
+		statusCode  int
+		bodyPattern string
+		error       string
+		ids         []string
+	}{
+		{name: "相同错误码格式的JSON响应", statusCode: 400, bodyPattern: "code=# msg=手机号格式错误", ids: []string{"test_1", "test_3", "test_5"}},
+		{name: "网络错误忽略URL和端口", error: "Post <url> dial tcp #.#.#.#:#: connect: connection refused", ids: []string{"test_4", "test_6"}},
+		{name: "纯文本响应", statusCode: 500, bodyPattern: "Internal Server Error", ids: []string{"test_2"}},
+	}
+	if len(groups) != len(tests) {
+		t.Fatalf("分组数量错误: 期望 %d，实际 %d（%+v）", len(tests), len(groups), groups)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := groups[i]
+			if group.StatusCode != tt.statusCode || group.BodyPattern != tt.bodyPattern || group.Error != tt.error {
+				t.Errorf("分组特征错误: %+v", group)
+			}
+			if strings.Join(group.TestCaseIDs, ",") != strings.Join(tt.ids, ",") {
+				t.Errorf("分组用例错误: 期望 %v，实际 %v", tt.ids, group.TestCaseIDs)
+			}
+			if len(group.Samples) > maxSamplesPerGroup {
+				t.Errorf("代表性用例不应超过 %d 个: %d", maxSamplesPerGroup, len(group.Samples))
+			}
+		})
+	}
+}
+
+// TestAnalyzeFailures 测试将失败分组发送给LLM进行根因分析
+func TestAnalyzeFailures(t *testing.T) {
+	groups := GroupFailures([]FailureSample{
+		{TestCaseID: "test_1", StatusCode: 400, RequestBody: `{"phone":"123"}`, ResponseBody: `{"code":1001,"msg":"手机号格式错误"}`},
+		{TestCaseID: "test_2", Error: "请求超时"},
+	})
+
+	var received GenerationRequest
+	provider := &stubProvider{generate: func(call int, req GenerationRequest) (string, error) {
+		received = req
+		return "  ## 分组 1\n根因: 手机号校验  \n", nil
+	}}
+
+	summary, err := AnalyzeFailures(provider, groups, 10, false)
+	if err != nil {
+		t.Fatalf("分析失败: %v", err)
+	}
+	if summary != "## 分组 1\n根因: 手机号校验" {
+		t.Errorf("分析结果应去除首尾空白: %q", summary)
+	}
+	if received.Task != TaskAnalyze {
+		t.Errorf("请求任务类型错误: %q", received.Task)
+	}
+	for _, want := range []string{"共 10 个用例，失败 2 个，归为 2 组", `{"phone":"123"}`, "响应状态码: 400", "错误信息: 请求超时"} {
+		if !strings.Contains(received.UserPrompt, want) {
+			t.Errorf("分析请求缺少 %q:\n%s", want, received.UserPrompt)
+		}
+	}
+	if buildUserMessage(received) != received.UserPrompt {
+		t.Error("分析任务的用户消息应直接使用分析请求")
+	}
+	if systemPromptFor(received, "自定义提示词") != builtinAnalysisPrompt {
+		t.Error("分析任务应使用内置的分析系统提示词")
+	}
+
+	if summary, err := AnalyzeFailures(provider, nil, 10, false); err != nil || summary != "" || provider.calls != 1 {
+		t.Errorf("没有失败分组时不应调用LLM: %q %v", summary, err)
+	}
+}
+
+// TestLLMConfigAnalysisConfig 测试失败分析使用单独配置的地址和密钥
+func TestLLMConfigAnalysisConfig(t *testing.T) {
+	config := LLMConfig{URL: "http://dify/v1", APIKey: "app-gen"}
+	if got := config.AnalysisConfig(); got.URL != config.URL || got.APIKey != config.APIKey {
+		t.Errorf("未配置分析地址时应使用生成用例的配置: %+v", got)
+	}
+
+	config.AnalysisURL, config.AnalysisAPIKey = "http://dify-analysis/v1", "app-analysis"
+	if got := config.AnalysisConfig(); got.URL != "http://dify-analysis/v1" || got.APIKey != "app-analysis" {
+		t.Errorf("应使用单独配置的分析地址和密钥: %+v", got)
+	}
+	if config.URL != "http://dify/v1" {
+		t.Error("不应修改原配置")
+	}
+}
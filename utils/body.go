@@ -0,0 +1,330 @@
+// Package utils 提供了一系列用于数据处理和测试用例生成的工具函数。
+// 包含XML解析、JSON解析以及基于原始数据生成测试用例的功能。
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 二进制响应体的保存方式
+const (
+	BinaryBodyHash   = "hash"   // 只保存SHA-256哈希（默认）
+	BinaryBodyBase64 = "base64" // 保存Base64编码后的内容
+)
+
+// 响应体在结果中的编码方式
+const (
+	BodyEncodingText   = "text"   // 原始文本
+	BodyEncodingBase64 = "base64" // Base64编码
+	BodyEncodingSHA256 = "sha256" // SHA-256哈希
+)
+
+// DefaultMaxBodySize 默认最大响应体大小（10MB）
+const DefaultMaxBodySize int64 = 10 * 1024 * 1024
+
+// defaultMaxSavedSizeMultiplier 未设置 MaxSavedSize 时，保存和统计的上限为 MaxSize 的倍数
+const defaultMaxSavedSizeMultiplier = 10
+
+// UnknownBodySize 响应体超过统计上限、解压后大小未知时 ResponseBodyInfo.Size 的取值
+const UnknownBodySize int64 = -1
+
+// ResponseBodyOptions 响应体读取选项
+type ResponseBodyOptions struct {
+	MaxSize    int64  `json:"max_size"`    // 内存中保留的最大响应体字节数（0表示使用默认值10MB）
+	BinaryMode string `json:"binary_mode"` // 二进制响应体的保存方式：hash 或 base64
+	SaveDir    string `json:"save_dir"`    // 完整响应体的保存目录（为空则不保存）
+	// MaxSavedSize 保存到文件和统计大小时最多读取的解压后字节数，防止压缩炸弹写满磁盘（0表示MaxSize的10倍）
+	MaxSavedSize int64 `json:"max_saved_size"`
+}
+
+// ResponseBodyInfo 响应体读取统计信息
+type ResponseBodyInfo struct {
+	Size            int64  // 解压后的字节数，超过 MaxSavedSize 时为 UnknownBodySize
+	Truncated       bool   // 是否因超过最大大小而截断
+	SavedTruncated  bool   // 保存的文件是否因超过 MaxSavedSize 而截断
+	Encoding        string // 响应体在结果中的编码方式：text、base64 或 sha256
+	ContentEncoding string // 响应的Content-Encoding（如gzip）
+	CompressedSize  int64  // 压缩响应在网络上传输的字节数（未压缩时为0）
+	SavedPath       string // 完整响应体的保存路径
+}
+
+// countingReader 统计读取字节数的Reader
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+// Read 实现io.Reader接口
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// readResponseBody 按选项读取响应体
+// 负责解压gzip/deflate、限制内存中保留的大小、识别二进制内容并可选地保存完整响应体
+func readResponseBody(rawBody io.Reader, header map[string][]string, options ResponseBodyOptions) (string, ResponseBodyInfo, error) {
+	info := ResponseBodyInfo{Encoding: BodyEncodingText}
+
+	maxSize := options.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBodySize
+	}
+
+	// 处理压缩编码
+	counter := &countingReader{reader: rawBody}
+	var reader io.Reader = counter
+	contentEncoding := strings.ToLower(strings.TrimSpace(headerValue(header, "Content-Encoding")))
+	switch contentEncoding {
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(counter)
+		if err != nil {
+			return "", info, fmt.Errorf("解压gzip响应失败: %v", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+		info.ContentEncoding = contentEncoding
+	case "deflate":
+		// RFC 9110 中的deflate为zlib格式，部分服务端直接返回原始deflate数据，zlib头校验失败时按原始deflate解压
+		buffered := bufio.NewReader(counter)
+		if prefix, _ := buffered.Peek(2); isZlibHeader(prefix) {
+			zlibReader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return "", info, fmt.Errorf("解压deflate响应失败: %v", err)
+			}
+			defer zlibReader.Close()
+			reader = zlibReader
+		} else {
+			flateReader := flate.NewReader(buffered)
+			defer flateReader.Close()
+			reader = flateReader
+		}
+		info.ContentEncoding = contentEncoding
+	}
+
+	maxSavedSize := options.MaxSavedSize
+	if maxSavedSize <= 0 {
+		maxSavedSize = maxSize * defaultMaxSavedSizeMultiplier
+	}
+	if maxSavedSize < maxSize {
+		maxSavedSize = maxSize
+	}
+
+	// 读取响应体：内存中最多保留maxSize字节，最多读取maxSavedSize字节用于保存和统计大小，多读1字节用于判断是否超出
+	var kept bytes.Buffer
+	hasher := sha256.New()
+	var n int64
+	var err error
+	if options.SaveDir != "" {
+		info.SavedPath, n, err = saveFullBody(reader, options.SaveDir, headerValue(header, "Content-Type"), &kept, maxSize, maxSavedSize, hasher)
+	} else {
+		n, err = io.Copy(&limitedBuffer{buffer: &kept, remaining: maxSize}, io.LimitReader(reader, maxSavedSize+1))
+	}
+	if err != nil {
+		return "", info, fmt.Errorf("读取响应失败: %v", err)
+	}
+	info.Truncated = n > maxSize
+	info.Size = n
+	if n > maxSavedSize {
+		info.Size = UnknownBodySize
+		info.SavedTruncated = options.SaveDir != ""
+	}
+
+	if info.ContentEncoding != "" {
+		info.CompressedSize = counter.count
+	}
+
+	// 识别二进制内容
+	body := kept.Bytes()
+	if !isBinaryBody(headerValue(header, "Content-Type"), body) {
+		return string(body), info, nil
+	}
+
+	if options.BinaryMode == BinaryBodyBase64 {
+		info.Encoding = BodyEncodingBase64
+		return base64.StdEncoding.EncodeToString(body), info, nil
+	}
+	// 保存了响应体时为保存内容的哈希，否则为内存中保留部分的哈希
+	info.Encoding = BodyEncodingSHA256
+	if options.SaveDir == "" {
+		hasher.Write(body)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), info, nil
+}
+
+// saveFullBody 将响应体写入保存目录，同时在内存中保留前maxSize字节
+// 文件最多写入maxSavedSize字节，超出时文件名标记为 response.truncated.*，返回的字节数为maxSavedSize+1
+func saveFullBody(reader io.Reader, dir, contentType string, kept *bytes.Buffer, maxSize, maxSavedSize int64, hasher hash.Hash) (string, int64, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, fmt.Errorf("创建响应体保存目录失败: %v", err)
+	}
+
+	path := filepath.Join(dir, "response"+bodyFileExtension(contentType))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("创建响应体文件失败: %v", err)
+	}
+	defer file.Close()
+
+	limited := &limitedBuffer{buffer: kept, remaining: maxSize}
+	n, err := io.Copy(io.MultiWriter(file, limited, hasher), io.LimitReader(reader, maxSavedSize))
+	if err != nil {
+		return path, n, err
+	}
+
+	// 再读1字节判断是否超出保存上限
+	extra, err := io.CopyN(io.Discard, reader, 1)
+	if err != nil && err != io.EOF {
+		return path, n, err
+	}
+	if extra == 0 {
+		return path, n, nil
+	}
+
+	truncatedPath := filepath.Join(dir, "response.truncated"+bodyFileExtension(contentType))
+	if err := file.Close(); err != nil {
+		return path, n, err
+	}
+	if err := os.Rename(path, truncatedPath); err != nil {
+		return path, n, fmt.Errorf("标记截断的响应体文件失败: %v", err)
+	}
+	return truncatedPath, n + extra, nil
+}
+
+// isZlibHeader 判断数据是否以有效的zlib头开始（压缩方法为deflate且校验位正确）
+func isZlibHeader(prefix []byte) bool {
+	return len(prefix) == 2 && prefix[0]&0x0f == 8 && (uint16(prefix[0])<<8|uint16(prefix[1]))%31 == 0
+}
+
+// limitedBuffer 只保留前remaining字节的Writer，超出部分丢弃但不报错
+type limitedBuffer struct {
+	buffer    *bytes.Buffer
+	remaining int64
+}
+
+// Write 实现io.Writer接口
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.remaining > 0 {
+		n := int64(len(p))
+		if n > b.remaining {
+			n = b.remaining
+		}
+		b.buffer.Write(p[:n])
+		b.remaining -= n
+	}
+	return len(p), nil
+}
+
+// headerValue 获取HTTP头的第一个值（大小写不敏感）
+func headerValue(header map[string][]string, key string) string {
+	for k, values := range header {
+		if strings.EqualFold(k, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// isBinaryBody 根据Content-Type和内容判断响应体是否为二进制
+func isBinaryBody(contentType string, body []byte) bool {
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil {
+			if isTextMediaType(mediaType) {
+				return !utf8.Valid(body)
+			}
+			return true
+		}
+	}
+
+	// 没有可用的Content-Type时根据内容判断
+	return !utf8.Valid(body) || bytes.IndexByte(body, 0) != -1
+}
+
+// isTextMediaType 判断媒体类型是否为文本类型
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	textSuffixes := []string{"json", "xml", "javascript", "ecmascript", "x-www-form-urlencoded", "yaml", "csv", "html"}
+	for _, suffix := range textSuffixes {
+		if strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyFileExtension 根据Content-Type确定响应体文件扩展名
+func bodyFileExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ".bin"
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return ".json"
+	case strings.HasSuffix(mediaType, "xml"):
+		return ".xml"
+	case mediaType == "text/html":
+		return ".html"
+	case strings.HasPrefix(mediaType, "text/"):
+		return ".txt"
+	}
+	if extensions, err := mime.ExtensionsByType(mediaType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ".bin"
+}
+
+// ParseByteSize 解析字节大小字符串，支持B、KB、MB、GB单位（1024进制），如 "512KB"、"10MB"
+func ParseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1024 * 1024 * 1024},
+		{"MB", 1024 * 1024},
+		{"KB", 1024},
+		{"G", 1024 * 1024 * 1024},
+		{"M", 1024 * 1024},
+		{"K", 1024},
+		{"B", 1},
+	}
+
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			multiplier = unit.multiplier
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("无效的大小 '%s'，示例：512KB、10MB", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,192 @@
+// Package utils 提供基于字段约束的边界值分析
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// GenerationModeBoundary 边界值模式，对每个带范围约束的字段生成边界值用例
+const GenerationModeBoundary = "boundary"
+
+// BoundaryPoint 表示字段的一个边界值
+type BoundaryPoint struct {
+	Name  string // 边界名称，如 min、min-1、max+1
+	Value any    // 字段值
+	Valid bool   // 是否满足约束
+}
+
+// boundaryCandidate 表示待转换为字段值的数值边界
+type boundaryCandidate struct {
+	name  string
+	value float64
+}
+
+// timeBoundary 表示待格式化的日期或日期时间边界
+type timeBoundary struct {
+	name  string
+	value time.Time
+}
+
+// BoundaryValues 根据字段约束计算边界值，按 min、min-1、min+1、max、max-1、max+1、零值、精度边界的顺序去重
+// 只支持 integer、float、date、datetime 约束，其余约束返回nil
+// original 为正例中的字段值，用于保持字段值的类型（数值或字符串）
+func BoundaryValues(constraint *FieldConstraint, original any) []BoundaryPoint {
+	if constraint == nil || (constraint.KeepOriginal != nil && *constraint.KeepOriginal) {
+		return nil
+	}
+
+	var points []BoundaryPoint
+	switch constraint.Type {
+	case "integer":
+		for _, candidate := range numberBoundaries(constraint, 1) {
+			points = append(points, BoundaryPoint{Name: candidate.name, Value: boundaryNumber(int64(candidate.value), original)})
+		}
+	case "float":
+		precision := 2
+		if constraint.Precision != nil {
+			precision = *constraint.Precision
+		}
+		step := math.Pow10(-precision)
+		for _, candidate := range numberBoundaries(constraint, step) {
+			points = append(points, BoundaryPoint{Name: candidate.name, Value: boundaryFloat(candidate.value, precision, original)})
+		}
+		// 精度边界：比约束多一位小数
+		if constraint.Precision != nil {
+			base := 0.0
+			if constraint.Min != nil {
+				base = *constraint.Min
+			} else if constraint.Max != nil {
+				base = *constraint.Max - step
+			}
+			points = append(points, BoundaryPoint{Name: "precision+1", Value: boundaryFloat(base+step/10, precision+1, original)})
+		}
+	case "date":
+		format := constraint.Format
+		if format == "" {
+			format = constraintDateLayout
+		}
+		day := 24 * time.Hour
+		for _, candidate := range timeBoundaries(constraint.MinDate, constraint.MaxDate, constraintDateLayout, day) {
+			points = append(points, BoundaryPoint{Name: candidate.name, Value: candidate.value.Format(format)})
+		}
+	case "datetime":
+		location := constraintLocation(constraint)
+		for _, candidate := range timeBoundaries(constraint.MinDatetime, constraint.MaxDatetime, time.RFC3339, time.Second) {
+			points = append(points, BoundaryPoint{Name: candidate.name, Value: candidate.value.In(location).Format("2006-01-02T15:04:05.000Z07:00")})
+		}
+	default:
+		return nil
+	}
+
+	// 去重并标记是否满足约束
+	seen := make(map[string]bool)
+	result := make([]BoundaryPoint, 0, len(points))
+	for _, point := range points {
+		key := fmt.Sprint(point.Value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		point.Valid = CheckConstrainedValue(constraint, point.Value) == nil
+		result = append(result, point)
+	}
+	return result
+}
+
+// GenerateBoundaryTestCases 使用默认生成器的约束配置生成边界值用例
+func GenerateBoundaryTestCases(data map[string]any) []LabeledTestCase {
+	return defaultGenerator.GenerateBoundaryTestCases(data)
+}
+
+// GenerateBoundaryTestCases 对每个带范围约束的字段生成边界值用例，其余字段保持正例中的值，
+// 依赖其他字段的字段（expr、after、depends_on）按边界值重新计算。没有 after 依赖时结果只取决于正例和约束配置，
+// 多次生成的结果相同；after 依赖的日期在范围内随机生成，需要固定种子才能复现
+func (g *Generator) GenerateBoundaryTestCases(data map[string]any) []LabeledTestCase {
+	var testCases []LabeledTestCase
+	for _, field := range collectMutationFields(data, g.mutationKeyOrder(data), "") {
+		switch field.value.(type) {
+		case map[string]any, []any:
+			continue
+		}
+		for _, point := range BoundaryValues(g.FindFieldConstraint(field.path), field.value) {
+			operator, caseType := BoundaryValid, CaseTypePositive
+			if !point.Valid {
+				operator, caseType = BoundaryInvalid, CaseTypeNegative
+			}
+			mutation := Mutation{Field: field.path + "=" + point.Name, Operator: operator}
+			value := point.Value
+			testCase := updateField(data, field.path, func(any) (any, bool) { return value, true })
+			g.applyDependentConstraints(testCase, field.path)
+			testCases = append(testCases, LabeledTestCase{
+				Data:        testCase,
+				Type:        caseType,
+				Label:       mutation.Label(),
+				Description: mutation.Description(),
+			})
+		}
+	}
+	return testCases
+}
+
+// numberBoundaries 计算数值约束的边界，step为相邻取值的间隔
+func numberBoundaries(constraint *FieldConstraint, step float64) []boundaryCandidate {
+	var candidates []boundaryCandidate
+	if constraint.Min != nil {
+		minValue := *constraint.Min
+		candidates = append(candidates,
+			boundaryCandidate{"min", minValue},
+			boundaryCandidate{"min-1", minValue - step},
+			boundaryCandidate{"min+1", minValue + step},
+		)
+	}
+	if constraint.Max != nil {
+		maxValue := *constraint.Max
+		candidates = append(candidates,
+			boundaryCandidate{"max", maxValue},
+			boundaryCandidate{"max-1", maxValue - step},
+			boundaryCandidate{"max+1", maxValue + step},
+		)
+	}
+	return append(candidates, boundaryCandidate{"zero", 0})
+}
+
+// timeBoundaries 计算日期或日期时间约束的边界，step为相邻取值的间隔
+func timeBoundaries(minValue, maxValue, layout string, step time.Duration) []timeBoundary {
+	var candidates []timeBoundary
+	if minTime, err := time.Parse(layout, minValue); err == nil {
+		candidates = append(candidates,
+			timeBoundary{"min", minTime},
+			timeBoundary{"min-1", minTime.Add(-step)},
+			timeBoundary{"min+1", minTime.Add(step)},
+		)
+	}
+	if maxTime, err := time.Parse(layout, maxValue); err == nil {
+		candidates = append(candidates,
+			timeBoundary{"max", maxTime},
+			timeBoundary{"max-1", maxTime.Add(-step)},
+			timeBoundary{"max+1", maxTime.Add(step)},
+		)
+	}
+	return candidates
+}
+
+// boundaryNumber 按正例字段的类型返回整数边界值
+func boundaryNumber(value int64, original any) any {
+	if _, ok := original.(string); ok {
+		return strconv.FormatInt(value, 10)
+	}
+	return int(value)
+}
+
+// boundaryFloat 按精度舍入浮点数边界值，并保持正例字段的类型
+func boundaryFloat(value float64, precision int, original any) any {
+	multiplier := math.Pow10(precision)
+	value = math.Round(value*multiplier) / multiplier
+	if _, ok := original.(string); ok {
+		return strconv.FormatFloat(value, 'f', precision, 64)
+	}
+	return value
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestBoundaryValues 测试根据约束计算边界值
+func TestBoundaryValues(t *testing.T) {
+	minAge, maxAge := 18.0, 60.0
+	minPrice, maxPrice := 0.01, 100.0
+	precision := 2
+
+	tests := []struct {
+		name       string
+		constraint FieldConstraint
+		original   any
+		want       string
+	}{
+		{
+			name:       "整数",
+			constraint: FieldConstraint{Type: "integer", Min: &minAge, Max: &maxAge},
+			original:   30,
+			want:       "min=18 min-1=17! min+1=19 max=60 max-1=59 max+1=61! zero=0!",
+		},
+		{
+			name:       "字符串形式的浮点数保持字符串类型并去重",
+			constraint: FieldConstraint{Type: "float", Min: &minPrice, Max: &maxPrice, Precision: &precision},
+			original:   "9.99",
+			want:       "min=0.01 min-1=0.00! min+1=0.02 max=100.00 max-1=99.99 max+1=100.01! precision+1=0.011!",
+		},
+		{
+			name:       "日期",
+			constraint: FieldConstraint{Type: "date", Format: "2006-01-02", MinDate: "19900101", MaxDate: "20001231"},
+			original:   "1995-06-01",
+			want:       "min=1990-01-01 min-1=1989-12-31! min+1=1990-01-02 max=2000-12-31 max-1=2000-12-30 max+1=2001-01-01!",
+		},
+		{
+			name:       "日期时间",
+			constraint: FieldConstraint{Type: "datetime", MaxDatetime: "2024-12-31T23:59:59Z", Timezone: "+08:00"},
+			original:   "2024-06-01T00:00:00Z",
+			want:       "max=2025-01-01T07:59:59.000+08:00 max-1=2025-01-01T07:59:58.000+08:00 max+1=2025-01-01T08:00:00.000+08:00!",
+		},
+		{
+			name:       "不支持的约束类型",
+			constraint: FieldConstraint{Type: "phone"},
+			original:   "13800138000",
+			want:       "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parts []string
+			for _, point := range BoundaryValues(&tt.constraint, tt.original) {
+				part := fmt.Sprintf("%s=%v", point.Name, point.Value)
+				if !point.Valid {
+					part += "!"
+				}
+				parts = append(parts, part)
+			}
+			if got := strings.Join(parts, " "); got != tt.want {
+				t.Errorf("边界值错误:\n期望 %s\n实际 %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestGenerateBoundaryTestCases 测试边界值用例只修改目标字段且结果固定
+func TestGenerateBoundaryTestCases(t *testing.T) {
+	minAge, maxAge := 18.0, 60.0
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"age": {Type: "integer", Min: &minAge, Max: &maxAge},
+	}}, DefaultVariationRate, 1)
+	g.Schema.KeyOrder = []string{"name", "user"}
+
+	data := map[string]any{"name": "张三", "user": map[string]any{"age": "30"}}
+	testCases := g.GenerateBoundaryTestCases(data)
+	if len(testCases) != 7 {
+		t.Fatalf("边界值用例数量错误: %d", len(testCases))
+	}
+
+	first, last := testCases[0], testCases[6]
+	if first.Label != "boundary:user.age=min" || first.Type != CaseTypePositive || first.Data["user"].(map[string]any)["age"] != "18" {
+		t.Errorf("最小值用例错误: %+v", first)
+	}
+	if last.Label != "boundary_invalid:user.age=zero" || last.Type != CaseTypeNegative || last.Data["name"] != "张三" {
+		t.Errorf("零值用例错误: %+v", last)
+	}
+	if caseType, description := ParseCaseLabel(first.Label); caseType != CaseTypePositive || description != "字段 user.age 取边界值 min" {
+		t.Errorf("解析边界值标签错误: %s %s", caseType, description)
+	}
+	if data["user"].(map[string]any)["age"] != "30" {
+		t.Error("生成边界值用例不应修改正例")
+	}
+
+	again := g.GenerateBoundaryTestCases(data)
+	for i := range testCases {
+		if testCases[i].Label != again[i].Label {
+			t.Fatalf("多次生成的边界值用例应相同: %s != %s", testCases[i].Label, again[i].Label)
+		}
+	}
+}
+
+// TestGenerateBoundaryTestCasesArrayElements 测试数组元素字段按路径选择器匹配约束并生成边界值用例
+func TestGenerateBoundaryTestCasesArrayElements(t *testing.T) {
+	minPrice, maxPrice, precision := 1.0, 100.0, 2
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"items[*].price": {Type: "float", Min: &minPrice, Max: &maxPrice, Precision: &precision},
+	}}, DefaultVariationRate, 1)
+	g.Schema.KeyOrder = []string{"items"}
+
+	data := map[string]any{"items": []any{
+		map[string]any{"name": "苹果", "price": 9.9},
+		map[string]any{"name": "香蕉", "price": 5.5},
+	}}
+	testCases := g.GenerateBoundaryTestCases(data)
+
+	labels := make(map[string]LabeledTestCase)
+	for _, testCase := range testCases {
+		labels[testCase.Label] = testCase
+	}
+	for _, label := range []string{"boundary:items[0].price=min", "boundary_invalid:items[1].price=max+1"} {
+		if _, ok := labels[label]; !ok {
+			t.Errorf("缺少边界值用例 %s，实际: %d 个用例", label, len(testCases))
+		}
+	}
+
+	minCase := labels["boundary:items[0].price=min"].Data
+	if minCase == nil {
+		t.FailNow()
+	}
+	items := minCase["items"].([]any)
+	if items[0].(map[string]any)["price"] != 1.0 || items[1].(map[string]any)["price"] != 5.5 {
+		t.Errorf("边界值只应修改目标数组元素: %v", items)
+	}
+	if data["items"].([]any)[0].(map[string]any)["price"] != 9.9 {
+		t.Error("生成边界值用例不应修改正例")
+	}
+}
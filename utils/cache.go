@@ -0,0 +1,237 @@
+// Package utils 提供LLM响应的磁盘缓存
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LLMCacheKey 表示缓存键的组成部分，相同的键返回相同的缓存结果
+type LLMCacheKey struct {
+	Provider        string         `json:"provider"`                // LLM提供方
+	URL             string         `json:"url"`                     // API Base URL
+	Model           string         `json:"model,omitempty"`         // 模型名称
+	APIKey          string         `json:"api_key,omitempty"`       // API Key的SHA-256指纹（不保存原始Key）
+	Temperature     *float64       `json:"temperature,omitempty"`   // 采样温度
+	MaxTokens       int            `json:"max_tokens,omitempty"`    // 最大生成token数
+	SystemPrompt    string         `json:"system_prompt,omitempty"` // 系统提示词的SHA-256摘要
+	PositiveExample string         `json:"positive_example"`        // 正例报文
+	Inputs          map[string]any `json:"inputs"`                  // 其余输入参数（格式、数量、提示词等）
+}
+
+// Hash 返回缓存键的SHA-256摘要
+// json.Marshal对map的键排序，保证相同内容得到相同的摘要
+func (k LLMCacheKey) Hash() string {
+	data, _ := json.Marshal(k)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LLMCacheEntry 表示一条缓存记录
+type LLMCacheEntry struct {
+	Key       LLMCacheKey `json:"key"`        // 缓存键
+	Hash      string      `json:"hash"`       // 缓存键摘要，同时作为文件名
+	CreatedAt time.Time   `json:"created_at"` // 缓存时间
+	Text      string      `json:"text"`       // LLM返回的测试用例文本
+}
+
+// LLMCache 基于目录的LLM响应缓存，每条记录保存为一个JSON文件
+type LLMCache struct {
+	Dir string // 缓存目录
+}
+
+// DefaultLLMCacheDir 返回默认的缓存目录（用户缓存目录下的 atc/llm）
+func DefaultLLMCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "atc", "llm")
+}
+
+// NewLLMCache 创建LLM响应缓存，dir为空时使用默认目录
+func NewLLMCache(dir string) *LLMCache {
+	if dir == "" {
+		dir = DefaultLLMCacheDir()
+	}
+	return &LLMCache{Dir: dir}
+}
+
+// Get 读取缓存记录
+func (c *LLMCache) Get(key LLMCacheKey) (*LLMCacheEntry, bool) {
+	data, err := os.ReadFile(filepath.Join(c.Dir, key.Hash()+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var entry LLMCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Put 写入缓存记录
+func (c *LLMCache) Put(key LLMCacheKey, text string) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+
+	entry := LLMCacheEntry{Key: key, Hash: key.Hash(), CreatedAt: time.Now(), Text: text}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化缓存记录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免并发读取到不完整的记录
+	path := filepath.Join(c.Dir, entry.Hash+".json")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	return nil
+}
+
+// List 列出所有缓存记录，按缓存时间从新到旧排序
+func (c *LLMCache) List() ([]LLMCacheEntry, error) {
+	files, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存目录失败: %v", err)
+	}
+
+	var entries []LLMCacheEntry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry LLMCacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// Clear 删除所有缓存记录，返回删除的记录数
+func (c *LLMCache) Clear() (int, error) {
+	files, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("读取缓存目录失败: %v", err)
+	}
+
+	removed := 0
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return removed, fmt.Errorf("删除缓存文件失败: %v", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// CachedProvider 为LLM提供方增加磁盘缓存，缓存命中时不再调用LLM
+type CachedProvider struct {
+	Provider LLMProvider // 被缓存的提供方
+	Cache    *LLMCache   // 缓存
+	URL      string      // API Base URL（缓存键的一部分）
+	Model    string      // 模型名称（缓存键的一部分）
+	APIKey   string      // API Key（只以SHA-256指纹写入缓存键）
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Name 返回被缓存的提供方名称
+func (p *CachedProvider) Name() string {
+	return p.Provider.Name()
+}
+
+// Generate 优先返回缓存的结果，未命中时调用LLM并只缓存完整的非空结果
+// 返回错误时（包括重试耗尽后只有部分用例的 ErrPartialResult）不写入缓存
+func (p *CachedProvider) Generate(req GenerationRequest) (string, error) {
+	key := p.cacheKey(req)
+	if entry, ok := p.Cache.Get(key); ok {
+		p.hits.Add(1)
+		printCacheHit(entry)
+		return entry.Text, nil
+	}
+
+	p.misses.Add(1)
+	text, err := p.Provider.Generate(req)
+	if err != nil || strings.TrimSpace(text) == "" {
+		return text, err
+	}
+	if err := p.Cache.Put(key, text); err != nil {
+		fmt.Printf("⚠️  保存LLM响应缓存失败: %v\n", err)
+	}
+	return text, nil
+}
+
+// Usage 返回被缓存的提供方的累计用量，缓存命中的请求不计入
+func (p *CachedProvider) Usage() LLMUsage {
+	usage, _ := ProviderUsage(p.Provider)
+	return usage
+}
+
+// Stats 返回缓存命中和未命中的次数
+func (p *CachedProvider) Stats() (hits, misses int64) {
+	return p.hits.Load(), p.misses.Load()
+}
+
+// printCacheHit 输出缓存命中信息
+func printCacheHit(entry *LLMCacheEntry) {
+	fmt.Printf("💾 命中LLM响应缓存 %s（缓存于 %s）\n", entry.Hash[:12], entry.CreatedAt.Format("2006-01-02 15:04:05"))
+}
+
+// cacheKey 根据生成请求构建缓存键
+func (p *CachedProvider) cacheKey(req GenerationRequest) LLMCacheKey {
+	inputs := generationInputs(req)
+	// 分批生成的各批次请求参数可能完全相同，需要区分批次
+	if req.Batch > 0 {
+		inputs["batch"] = req.Batch
+	}
+	key := LLMCacheKey{
+		Provider:        p.Provider.Name(),
+		URL:             p.URL,
+		Model:           p.Model,
+		PositiveExample: req.PositiveExample,
+		Inputs:          inputs,
+	}
+	// 不同的API Key可能对应不同的Dify工作流或账号，只记录指纹避免Key落盘
+	if p.APIKey != "" {
+		sum := sha256.Sum256([]byte(p.APIKey))
+		key.APIKey = hex.EncodeToString(sum[:])
+	}
+
+	// 采样参数和系统提示词同样影响生成结果（Dify的提示词和参数由工作流维护，不在此处）
+	var systemPrompt string
+	switch provider := p.Provider.(type) {
+	case *OpenAIProvider:
+		key.Temperature, key.MaxTokens = provider.Temperature, provider.MaxTokens
+		systemPrompt = systemPromptFor(req, provider.SystemPrompt)
+	case *OllamaProvider:
+		key.Temperature, key.MaxTokens = provider.Temperature, provider.MaxTokens
+		systemPrompt = systemPromptFor(req, provider.SystemPrompt)
+	}
+	if systemPrompt != "" {
+		sum := sha256.Sum256([]byte(systemPrompt))
+		key.SystemPrompt = hex.EncodeToString(sum[:])
+	}
+	return key
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestCachedProvider 测试LLM响应缓存的命中、区分和清理
+func TestCachedProvider(t *testing.T) {
+	stub := &stubProvider{
+		generate: func(call int, req GenerationRequest) (string, error) {
+			return fmt.Sprintf(`[{"call":%d}]`, call), nil
+		},
+	}
+	cache := NewLLMCache(t.TempDir())
+	provider := &CachedProvider{Provider: stub, Cache: cache, URL: "http://localhost/v1"}
+
+	req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 2, UserPrompt: "边界值"}
+	first, err := provider.Generate(req)
+	if err != nil {
+		t.Fatalf("生成失败: %v", err)
+	}
+	second, _ := provider.Generate(req)
+	if first != second || stub.calls != 1 {
+		t.Errorf("相同请求应命中缓存: 第一次 %s，第二次 %s，调用LLM %d 次", first, second, stub.calls)
+	}
+
+	// 提示词、批次或URL不同时不应命中缓存
+	changedPrompt := req
+	changedPrompt.UserPrompt = "安全"
+	batch := req
+	batch.Batch = 2
+	for _, r := range []GenerationRequest{changedPrompt, batch} {
+		if _, err := provider.Generate(r); err != nil {
+			t.Fatalf("生成失败: %v", err)
+		}
+	}
+	otherURL := &CachedProvider{Provider: stub, Cache: cache, URL: "http://other/v1"}
+	if _, err := otherURL.Generate(req); err != nil {
+		t.Fatalf("生成失败: %v", err)
+	}
+	if stub.calls != 4 {
+		t.Errorf("期望调用LLM 4 次，实际 %d 次", stub.calls)
+	}
+
+	hits, misses := provider.Stats()
+	if hits != 1 || misses != 3 {
+		t.Errorf("缓存统计错误: 命中 %d，未命中 %d", hits, misses)
+	}
+
+	entries, err := cache.List()
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("期望4条缓存记录，实际 %d 条，错误: %v", len(entries), err)
+	}
+	removed, err := cache.Clear()
+	if err != nil || removed != 4 {
+		t.Errorf("期望删除4条缓存记录，实际 %d 条，错误: %v", removed, err)
+	}
+	if entries, _ := cache.List(); len(entries) != 0 {
+		t.Errorf("清空后仍有 %d 条缓存记录", len(entries))
+	}
+}
+
+// TestCachedProviderSkipsEmptyResponse 测试空响应和错误不写入缓存
+func TestCachedProviderSkipsEmptyResponse(t *testing.T) {
+	stub := &stubProvider{
+		generate: func(call int, req GenerationRequest) (string, error) {
+			switch call {
+			case 0:
+				return "", fmt.Errorf("模拟失败")
+			case 1:
+				return `[{"id":1}]`, fmt.Errorf("%w: 模拟中断", ErrPartialResult)
+			}
+			return "", nil
+		},
+	}
+	cache := NewLLMCache(t.TempDir())
+	provider := &CachedProvider{Provider: stub, Cache: cache}
+
+	req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 2}
+	_, _ = provider.Generate(req)
+	// 不完整的响应原样返回给调用方，但不写入缓存
+	if text, err := provider.Generate(req); text == "" || !errors.Is(err, ErrPartialResult) {
+		t.Errorf("不完整的响应应返回部分用例和 ErrPartialResult: %q %v", text, err)
+	}
+	_, _ = provider.Generate(req)
+	if entries, _ := cache.List(); len(entries) != 0 {
+		t.Errorf("失败、不完整或空响应不应写入缓存，实际 %d 条", len(entries))
+	}
+}
+
+// TestCachedProviderKeyIncludesSamplingParams 测试采样温度、最大token数和系统提示词不同时不命中缓存
+func TestCachedProviderKeyIncludesSamplingParams(t *testing.T) {
+	low, high := 0.2, 0.8
+	req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 2}
+	base := &OpenAIProvider{Model: "m", Temperature: &low, MaxTokens: 1000}
+	variants := []*OpenAIProvider{
+		{Model: "m", Temperature: &high, MaxTokens: 1000},
+		{Model: "m", Temperature: &low, MaxTokens: 2000},
+		{Model: "m", Temperature: &low, MaxTokens: 1000, SystemPrompt: "自定义系统提示词"},
+	}
+
+	baseKey := (&CachedProvider{Provider: base}).cacheKey(req)
+	if baseKey.Hash() != (&CachedProvider{Provider: &OpenAIProvider{Model: "m", Temperature: &low, MaxTokens: 1000}}).cacheKey(req).Hash() {
+		t.Error("相同参数的缓存键应相同")
+	}
+	for i, variant := range variants {
+		if (&CachedProvider{Provider: variant}).cacheKey(req).Hash() == baseKey.Hash() {
+			t.Errorf("第 %d 个变体的缓存键不应与基准相同", i+1)
+		}
+	}
+}
+
+// TestCachedProviderKeyIncludesAPIKeyFingerprint 测试缓存键区分API Key且不保存原始Key
+func TestCachedProviderKeyIncludesAPIKeyFingerprint(t *testing.T) {
+	req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 2}
+	keyA := (&CachedProvider{Provider: &DifyProvider{}, URL: "http://dify", APIKey: "app-key-a"}).cacheKey(req)
+	keyB := (&CachedProvider{Provider: &DifyProvider{}, URL: "http://dify", APIKey: "app-key-b"}).cacheKey(req)
+
+	if keyA.Hash() == keyB.Hash() {
+		t.Error("不同API Key的缓存键不应相同")
+	}
+	data, err := json.Marshal(keyA)
+	if err != nil {
+		t.Fatalf("序列化缓存键失败: %v", err)
+	}
+	if strings.Contains(string(data), "app-key-a") {
+		t.Errorf("缓存键不应包含原始API Key: %s", data)
+	}
+	if keyA.APIKey == "" {
+		t.Error("缓存键应包含API Key指纹")
+	}
+}
@@ -0,0 +1,296 @@
+// Package utils 提供身份证号和银行卡号的生成与校验位计算
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// idCardRegionCodes 生成身份证号使用的县级行政区划代码
+var idCardRegionCodes = []string{
+	"110101", "110102", "110105", "110108", "120101", "120104", "130102", "130104",
+	"140105", "150102", "210102", "210202", "220102", "230102", "310101", "310104",
+	"310115", "320102", "320505", "330102", "330106", "340102", "350102", "350203",
+	"360102", "370102", "370202", "410105", "420106", "420111", "430102", "440103",
+	"440106", "440305", "450103", "460106", "500103", "510104", "510107", "520102",
+	"530102", "540102", "610113", "620102", "630102", "640104", "650102",
+}
+
+// defaultBankCardPrefixes 未配置 bin_prefixes 时使用的银行卡BIN前缀
+var defaultBankCardPrefixes = []string{
+	"622202", "622848", "622700", "621700", "621661", "622588", "622262", "622609", "621483", "622908",
+}
+
+// 身份证号出生日期的默认范围（未设置年龄范围时使用，保证相同种子生成的结果相同）
+var (
+	defaultIDCardMinBirth = time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)
+	defaultIDCardMaxBirth = time.Date(2005, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// 银行卡号的默认长度和允许的长度范围
+const (
+	defaultBankCardLength = 16
+	minBankCardLength     = 12
+	maxBankCardLength     = 19
+)
+
+// idCardWeights 身份证号前17位的加权系数（GB 11643，ISO 7064 MOD 11-2）
+var idCardWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// idCardCheckDigits 加权和除以11的余数对应的校验码
+const idCardCheckDigits = "10X98765432"
+
+// idCardCheckDigit 计算身份证号前17位数字的校验码
+func idCardCheckDigit(body string) byte {
+	sum := 0
+	for i, weight := range idCardWeights {
+		sum += int(body[i]-'0') * weight
+	}
+	return idCardCheckDigits[sum%11]
+}
+
+// luhnCheckDigit 计算银行卡号（不含校验位）的Luhn校验位
+func luhnCheckDigit(body string) byte {
+	sum := 0
+	for i := len(body) - 1; i >= 0; i-- {
+		digit := int(body[i] - '0')
+		// 从校验位左侧第一位开始每隔一位乘2
+		if (len(body)-i)%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// idCardBirthRange 根据年龄范围和参考日期返回出生日期范围，未设置年龄范围时返回默认范围
+// 年龄按参考日期计算，相同的种子和参考日期生成相同的身份证号
+func idCardBirthRange(constraint *FieldConstraint, reference time.Time) (time.Time, time.Time) {
+	if constraint.MinAge == nil && constraint.MaxAge == nil {
+		return defaultIDCardMinBirth, defaultIDCardMaxBirth
+	}
+
+	today := time.Date(reference.Year(), reference.Month(), reference.Day(), 0, 0, 0, 0, time.UTC)
+	minBirth := today.AddDate(-100, 0, 1)
+	maxBirth := today
+	if constraint.MaxAge != nil {
+		// 年龄不超过 max_age：出生日期晚于 max_age+1 年前的参考日期
+		minBirth = today.AddDate(-*constraint.MaxAge-1, 0, 1)
+	}
+	if constraint.MinAge != nil {
+		maxBirth = today.AddDate(-*constraint.MinAge, 0, 0)
+	}
+	return minBirth, maxBirth
+}
+
+// generateIDCard 生成18位身份证号：行政区划代码、出生日期、顺序码（奇数为男性，偶数为女性）和校验码
+func (g *Generator) generateIDCard(constraint *FieldConstraint) string {
+	region := idCardRegionCodes[g.rng.Intn(len(idCardRegionCodes))]
+
+	minBirth, maxBirth := idCardBirthRange(constraint, g.ReferenceDate)
+	birth := minBirth
+	if days := int(maxBirth.Sub(minBirth).Hours() / 24); days > 0 {
+		birth = minBirth.AddDate(0, 0, g.rng.Intn(days+1))
+	}
+
+	genderDigit := g.rng.Intn(10)
+	switch constraint.Gender {
+	case "male":
+		genderDigit = genderDigit/2*2 + 1
+	case "female":
+		genderDigit = genderDigit / 2 * 2
+	}
+
+	body := fmt.Sprintf("%s%s%02d%d", region, birth.Format("20060102"), g.rng.Intn(100), genderDigit)
+	return body + string(idCardCheckDigit(body))
+}
+
+// generateBankCard 生成以BIN前缀开头、带有Luhn校验位的银行卡号
+func (g *Generator) generateBankCard(constraint *FieldConstraint) string {
+	prefixes := defaultBankCardPrefixes
+	if len(constraint.BinPrefixes) > 0 {
+		prefixes = constraint.BinPrefixes
+	}
+	length := defaultBankCardLength
+	if constraint.Length != nil {
+		length = *constraint.Length
+	}
+
+	var builder strings.Builder
+	builder.WriteString(prefixes[g.rng.Intn(len(prefixes))])
+	for builder.Len() < length-1 {
+		builder.WriteByte(byte('0' + g.rng.Intn(10)))
+	}
+	body := builder.String()
+	return body + string(luhnCheckDigit(body))
+}
+
+// builtinCardNumbers 返回约束可以直接使用的内置身份证号或银行卡号数据集
+// 兼容旧配置：配置了 builtin_data.id_cards 或 bank_cards，且约束没有设置号码的生成参数时，从数据集中选择号码；
+// 数据集中的号码按原样使用，不保证校验位正确
+func (g *Generator) builtinCardNumbers(constraint *FieldConstraint) []string {
+	if g.Constraints == nil {
+		return nil
+	}
+	switch constraint.Type {
+	case "id_card":
+		if constraint.MinAge == nil && constraint.MaxAge == nil && constraint.Gender == "" {
+			return g.Constraints.BuiltinData.IDCards
+		}
+	case "bank_card":
+		if len(constraint.BinPrefixes) == 0 && constraint.Length == nil {
+			return g.Constraints.BuiltinData.BankCards
+		}
+	}
+	return nil
+}
+
+// generateChecksumMismatch 生成校验位错误的身份证号或银行卡号，其余部分保持有效
+// 原值是有效的号码时只修改其校验位，否则先按约束生成一个有效号码
+func (g *Generator) generateChecksumMismatch(constraint *FieldConstraint, originalValue any) string {
+	valid := fmt.Sprint(originalValue)
+	if CheckConstrainedValue(constraint, valid) != nil {
+		// 直接按约束生成，不使用内置数据集中校验位可能错误的号码
+		if constraint.Type == "id_card" {
+			valid = g.generateIDCard(constraint)
+		} else {
+			valid = g.generateBankCard(constraint)
+		}
+	}
+
+	body, check := valid[:len(valid)-1], strings.ToUpper(valid[len(valid)-1:])
+	candidates := "0123456789"
+	if constraint.Type == "id_card" {
+		candidates = "0123456789X"
+	}
+	candidates = strings.Replace(candidates, check, "", 1)
+	return body + string(candidates[g.rng.Intn(len(candidates))])
+}
+
+// checkIDCardValue 校验身份证号的格式、出生日期、年龄范围、性别和校验码
+func checkIDCardValue(constraint *FieldConstraint, value any) error {
+	if err := checkPattern(value, idCardPattern, "不是18位身份证号"); err != nil {
+		return err
+	}
+	idCard := strings.ToUpper(fmt.Sprint(value))
+
+	birth, err := time.Parse("20060102", idCard[6:14])
+	if err != nil {
+		return fmt.Errorf("出生日期 %s 无效", idCard[6:14])
+	}
+	if constraint.MinAge != nil || constraint.MaxAge != nil {
+		// 校验时按当天计算年龄
+		minBirth, maxBirth := idCardBirthRange(constraint, Today())
+		if birth.Before(minBirth) || birth.After(maxBirth) {
+			return fmt.Errorf("出生日期 %s 不在年龄范围内", idCard[6:14])
+		}
+	}
+
+	male := (idCard[16]-'0')%2 == 1
+	if (constraint.Gender == "male" && !male) || (constraint.Gender == "female" && male) {
+		return fmt.Errorf("性别不是 %s", constraint.Gender)
+	}
+
+	if check := idCardCheckDigit(idCard); idCard[17] != check {
+		return fmt.Errorf("校验码错误，应为 %c", check)
+	}
+	return nil
+}
+
+// checkBankCardValue 校验银行卡号的长度、BIN前缀和Luhn校验位
+func checkBankCardValue(constraint *FieldConstraint, value any) error {
+	if err := checkPattern(value, bankCardPattern, "不是12-19位银行卡号"); err != nil {
+		return err
+	}
+	card := fmt.Sprint(value)
+
+	if constraint.Length != nil && len(card) != *constraint.Length {
+		return fmt.Errorf("长度不是 %d 位", *constraint.Length)
+	}
+	if len(constraint.BinPrefixes) > 0 {
+		matched := false
+		for _, prefix := range constraint.BinPrefixes {
+			if strings.HasPrefix(card, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("不以BIN前缀 %v 开头", constraint.BinPrefixes)
+		}
+	}
+
+	if check := luhnCheckDigit(card[:len(card)-1]); card[len(card)-1] != check {
+		return fmt.Errorf("Luhn校验位错误，应为 %c", check)
+	}
+	return nil
+}
+
+// validateIDCardConstraint 验证身份证号约束的年龄范围和性别
+func validateIDCardConstraint(fieldName string, constraint FieldConstraint) []ValidationError {
+	var errors []ValidationError
+
+	if constraint.MinAge != nil && (*constraint.MinAge < 0 || *constraint.MinAge > 100) {
+		errors = append(errors, ValidationError{
+			Field:   fieldName,
+			Message: fmt.Sprintf("最小年龄 %d 无效，应在 0-100 之间", *constraint.MinAge),
+		})
+	}
+	if constraint.MaxAge != nil && (*constraint.MaxAge < 0 || *constraint.MaxAge > 100) {
+		errors = append(errors, ValidationError{
+			Field:   fieldName,
+			Message: fmt.Sprintf("最大年龄 %d 无效，应在 0-100 之间", *constraint.MaxAge),
+		})
+	}
+	if constraint.MinAge != nil && constraint.MaxAge != nil && *constraint.MinAge > *constraint.MaxAge {
+		errors = append(errors, ValidationError{
+			Field:   fieldName,
+			Message: fmt.Sprintf("最小年龄 (%d) 不能大于最大年龄 (%d)", *constraint.MinAge, *constraint.MaxAge),
+		})
+	}
+	if constraint.Gender != "" && constraint.Gender != "male" && constraint.Gender != "female" {
+		errors = append(errors, ValidationError{
+			Field:   fieldName,
+			Message: fmt.Sprintf("无效的性别 '%s'，支持: male, female", constraint.Gender),
+		})
+	}
+
+	return errors
+}
+
+// validateBankCardConstraint 验证银行卡号约束的长度和BIN前缀
+func validateBankCardConstraint(fieldName string, constraint FieldConstraint) []ValidationError {
+	var errors []ValidationError
+
+	length := defaultBankCardLength
+	if constraint.Length != nil {
+		length = *constraint.Length
+		if length < minBankCardLength || length > maxBankCardLength {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("银行卡号长度 %d 无效，应为 %d-%d 位", length, minBankCardLength, maxBankCardLength),
+			})
+		}
+	}
+
+	for i, prefix := range constraint.BinPrefixes {
+		if _, err := strconv.ParseUint(prefix, 10, 64); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("第 %d 个BIN前缀 '%s' 必须为数字", i+1, prefix),
+			})
+		} else if len(prefix) >= length {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("第 %d 个BIN前缀 '%s' 的长度必须小于银行卡号长度 %d", i+1, prefix, length),
+			})
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCardCheckDigits 测试身份证号校验码和银行卡号Luhn校验位的计算
+func TestCardCheckDigits(t *testing.T) {
+	length := 19
+	tests := []struct {
+		name       string
+		constraint FieldConstraint
+		value      string
+		wantErr    string
+	}{
+		{name: "有效身份证号", constraint: FieldConstraint{Type: "id_card"}, value: "11010519491231002X"},
+		{name: "小写x校验码", constraint: FieldConstraint{Type: "id_card"}, value: "11010519491231002x"},
+		{name: "有效身份证号2", constraint: FieldConstraint{Type: "id_card"}, value: "440305198709120410"},
+		{name: "身份证号校验码错误", constraint: FieldConstraint{Type: "id_card"}, value: "110105194912310021", wantErr: "校验码错误"},
+		{name: "出生日期无效", constraint: FieldConstraint{Type: "id_card"}, value: "110105194913310020", wantErr: "出生日期"},
+		{name: "性别不符", constraint: FieldConstraint{Type: "id_card", Gender: "male"}, value: "11010519491231002X", wantErr: "性别"},
+		{name: "有效银行卡号", constraint: FieldConstraint{Type: "bank_card"}, value: "6222021234567894"},
+		{name: "Luhn示例卡号", constraint: FieldConstraint{Type: "bank_card"}, value: "4111111111111111"},
+		{name: "银行卡号校验位错误", constraint: FieldConstraint{Type: "bank_card"}, value: "6222021234567890", wantErr: "Luhn"},
+		{name: "BIN前缀不符", constraint: FieldConstraint{Type: "bank_card", BinPrefixes: []string{"622848"}}, value: "6222021234567894", wantErr: "BIN前缀"},
+		{name: "长度不符", constraint: FieldConstraint{Type: "bank_card", Length: &length}, value: "6222021234567894", wantErr: "长度"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckConstrainedValue(&tt.constraint, tt.value)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("不应返回错误: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("期望包含 %q 的错误，实际: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestGenerateCards 测试生成的身份证号和银行卡号满足约束且不重复使用少量固定号码
+func TestGenerateCards(t *testing.T) {
+	minAge, maxAge, length := 18, 25, 19
+	tests := []struct {
+		name       string
+		constraint FieldConstraint
+	}{
+		{name: "默认身份证号", constraint: FieldConstraint{Type: "id_card"}},
+		{name: "年龄和性别", constraint: FieldConstraint{Type: "id_card", MinAge: &minAge, MaxAge: &maxAge, Gender: "female"}},
+		{name: "默认银行卡号", constraint: FieldConstraint{Type: "bank_card"}},
+		{name: "BIN前缀和长度", constraint: FieldConstraint{Type: "bank_card", BinPrefixes: []string{"62", "621483"}, Length: &length}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(nil, DefaultVariationRate, 11)
+			seen := make(map[string]bool)
+			for i := 0; i < 200; i++ {
+				value := g.GenerateConstrainedValue(&tt.constraint, "").(string)
+				if err := CheckConstrainedValue(&tt.constraint, value); err != nil {
+					t.Fatalf("生成的值 %s 不满足约束: %v", value, err)
+				}
+				seen[value] = true
+			}
+			if len(seen) < 190 {
+				t.Errorf("200个号码中只有 %d 个不同", len(seen))
+			}
+		})
+	}
+
+	// 年龄范围按当前日期计算
+	age := 30
+	constraint := &FieldConstraint{Type: "id_card", MinAge: &age, MaxAge: &age}
+	value := NewGenerator(nil, DefaultVariationRate, 1).GenerateConstrainedValue(constraint, "").(string)
+	birth, _ := time.Parse("20060102", value[6:14])
+	if years := time.Now().Year() - birth.Year(); years != age && years != age+1 {
+		t.Errorf("身份证号 %s 的出生年份与年龄不符", value)
+	}
+}
+
+// TestIDCardReferenceDate 测试设置年龄范围时，相同的种子和参考日期生成相同的身份证号
+func TestIDCardReferenceDate(t *testing.T) {
+	minAge := 18
+	constraint := &FieldConstraint{Type: "id_card", MinAge: &minAge}
+	reference := time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)
+	generate := func(reference time.Time) []string {
+		g := NewGenerator(nil, DefaultVariationRate, 42)
+		g.ReferenceDate = reference
+		values := make([]string, 20)
+		for i := range values {
+			values[i] = g.GenerateConstrainedValue(constraint, "").(string)
+		}
+		return values
+	}
+
+	first := generate(reference)
+	if second := generate(reference); strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Errorf("相同的种子和参考日期应生成相同的身份证号:\n%v\n%v", first, second)
+	}
+	if other := generate(reference.AddDate(1, 0, 0)); strings.Join(first, ",") == strings.Join(other, ",") {
+		t.Error("参考日期不同时生成的身份证号不应完全相同")
+	}
+	latest := reference.AddDate(-minAge, 0, 0)
+	for _, value := range first {
+		birth, err := time.Parse("20060102", value[6:14])
+		if err != nil || birth.After(latest) {
+			t.Errorf("身份证号 %s 的出生日期晚于参考日期的 %d 周岁", value, minAge)
+		}
+	}
+}
+
+// TestChecksumMutation 测试反例模式生成校验位错误的号码且只修改校验位
+func TestChecksumMutation(t *testing.T) {
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"id_card":   {Type: "id_card"},
+		"bank_card": {Type: "bank_card", BinPrefixes: []string{"622848"}},
+	}}, DefaultVariationRate, 5)
+	data := map[string]any{"id_card": "11010519491231002X", "bank_card": "123", "name": "张三"}
+	g.Schema.KeyOrder = []string{"id_card", "bank_card", "name"}
+
+	var checksumCases []LabeledTestCase
+	for _, testCase := range g.GenerateNegativeTestCases(data, 100, false) {
+		if strings.HasPrefix(testCase.Label, MutationChecksum+":") {
+			checksumCases = append(checksumCases, testCase)
+		}
+	}
+	if len(checksumCases) != 2 || checksumCases[0].Description != "字段 id_card 校验位错误" {
+		t.Fatalf("校验位错误的反例错误: %+v", checksumCases)
+	}
+
+	idCard := checksumCases[0].Data["id_card"].(string)
+	if !strings.HasPrefix(idCard, "11010519491231002") || strings.HasSuffix(idCard, "X") {
+		t.Errorf("身份证号的反例应只修改校验码: %s", idCard)
+	}
+	// 原值无效时先生成有效号码，再修改校验位
+	bankCard := checksumCases[1].Data["bank_card"].(string)
+	err := CheckConstrainedValue(g.FindFieldConstraint("bank_card"), bankCard)
+	if len(bankCard) != 16 || !strings.HasPrefix(bankCard, "622848") || err == nil || !strings.Contains(err.Error(), "Luhn") {
+		t.Errorf("银行卡号的反例应只有校验位错误: %s %v", bankCard, err)
+	}
+}
+
+// TestBuiltinCardNumbers 测试配置了内置号码数据集时，未设置生成参数的约束从数据集中选择号码
+func TestBuiltinCardNumbers(t *testing.T) {
+	config := &ConstraintConfig{BuiltinData: BuiltinData{
+		IDCards:   []string{"110101199001011234"},
+		BankCards: []string{"6222021234567890"},
+	}}
+	g := NewGenerator(config, DefaultVariationRate, 1)
+	minAge, length := 18, 19
+
+	tests := []struct {
+		name       string
+		constraint FieldConstraint
+		want       string
+	}{
+		{name: "身份证号使用数据集", constraint: FieldConstraint{Type: "id_card"}, want: "110101199001011234"},
+		{name: "银行卡号使用数据集", constraint: FieldConstraint{Type: "bank_card"}, want: "6222021234567890"},
+		{name: "设置年龄时生成身份证号", constraint: FieldConstraint{Type: "id_card", MinAge: &minAge}},
+		{name: "设置长度时生成银行卡号", constraint: FieldConstraint{Type: "bank_card", Length: &length}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := g.GenerateConstrainedValue(&tt.constraint, "").(string)
+			if tt.want != "" && value != tt.want {
+				t.Errorf("期望从数据集中选择 %s，实际: %s", tt.want, value)
+			}
+			if tt.want == "" {
+				if err := CheckConstrainedValue(&tt.constraint, value); err != nil {
+					t.Errorf("生成的号码 %s 不满足约束: %v", value, err)
+				}
+			}
+		})
+	}
+
+	invalid := validateBuiltinData(BuiltinData{IDCards: []string{"11010119900101123"}, BankCards: []string{"62220212345678a0"}})
+	fields := make(map[string]bool)
+	for _, err := range invalid {
+		fields[err.Field] = true
+	}
+	if !fields["builtin_data.id_cards"] || !fields["builtin_data.bank_cards"] {
+		t.Errorf("格式无效的号码应报告错误: %v", invalid)
+	}
+}
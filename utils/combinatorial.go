@@ -0,0 +1,298 @@
+// Package utils 提供组合测试（pairwise / n-wise）用例生成
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StrategyPairwise 两两组合策略，等价于 2-wise
+const StrategyPairwise = "pairwise"
+
+// CombinationLabel 组合用例的标签前缀
+const CombinationLabel = "combination"
+
+// coveringSeedCandidates 构造覆盖数组时每行最多尝试的起点数量
+const coveringSeedCandidates = 32
+
+// CoveringParameter 表示参与组合的字段及其候选值
+type CoveringParameter struct {
+	Field  string // 字段路径
+	Values []any  // 候选值
+}
+
+// CombinatorialResult 表示组合用例的生成结果
+type CombinatorialResult struct {
+	TestCases     []LabeledTestCase   // 生成的用例
+	Parameters    []CoveringParameter // 参与组合的字段
+	Strength      int                 // 组合强度（2 表示两两组合）
+	TotalTuples   int                 // 需要覆盖的取值组合总数
+	CoveredTuples int                 // 已覆盖的取值组合数
+}
+
+// Coverage 返回组合覆盖率（0-1）
+func (r CombinatorialResult) Coverage() float64 {
+	if r.TotalTuples == 0 {
+		return 1
+	}
+	return float64(r.CoveredTuples) / float64(r.TotalTuples)
+}
+
+// ParseStrategyStrength 解析组合策略，pairwise 返回2，N-wise 返回N
+func ParseStrategyStrength(strategy string) (int, error) {
+	strategy = strings.ToLower(strings.TrimSpace(strategy))
+	if strategy == StrategyPairwise {
+		return 2, nil
+	}
+	if prefix, ok := strings.CutSuffix(strategy, "-wise"); ok {
+		if strength, err := strconv.Atoi(prefix); err == nil && strength >= 2 {
+			return strength, nil
+		}
+	}
+	return 0, fmt.Errorf("不支持的组合策略 '%s'，支持: pairwise 或 N-wise（N≥2，如 3-wise）", strategy)
+}
+
+// CombinatorialParameters 使用默认生成器的约束配置收集报文中可参与组合的字段及候选值
+func CombinatorialParameters(data map[string]any) []CoveringParameter {
+	return defaultGenerator.CombinatorialParameters(data)
+}
+
+// CombinatorialParameters 收集报文中可参与组合的字段及候选值
+// 候选值依次来自约束的 values 列表、约束的有效边界值，布尔字段使用 true 和 false
+// 依赖其他字段的字段（expr、after、depends_on）由生成用例时重新计算，不作为组合参数
+func (g *Generator) CombinatorialParameters(data map[string]any) []CoveringParameter {
+	var parameters []CoveringParameter
+	for _, field := range collectMutationFields(data, g.mutationKeyOrder(data), "") {
+		switch field.value.(type) {
+		case map[string]any, []any:
+			continue
+		}
+
+		// 依赖其他字段的字段在每个用例中重新计算，不参与组合
+		constraint := g.FindFieldConstraint(field.path)
+		if isDependentConstraint(constraint) {
+			continue
+		}
+
+		var values []any
+		if constraint != nil && len(constraint.Values) > 0 {
+			for _, value := range constraint.Values {
+				values = append(values, preserveValueType(normalizeConstraintValue(value), field.value))
+			}
+		} else if points := BoundaryValues(constraint, field.value); len(points) > 0 {
+			for _, point := range points {
+				if point.Valid {
+					values = append(values, point.Value)
+				}
+			}
+		} else if b, ok := field.value.(bool); ok {
+			values = []any{b, !b}
+		}
+
+		if len(values) > 1 {
+			parameters = append(parameters, CoveringParameter{Field: field.path, Values: values})
+		}
+	}
+	return parameters
+}
+
+// GenerateCombinatorialTestCases 使用默认生成器的约束配置生成组合用例
+func GenerateCombinatorialTestCases(data map[string]any, strength, maxCases int) (CombinatorialResult, error) {
+	return defaultGenerator.GenerateCombinatorialTestCases(data, strength, maxCases)
+}
+
+// GenerateCombinatorialTestCases 生成覆盖所有 strength 个字段取值组合的用例，其余字段保持正例中的值
+// maxCases 大于0时限制用例数量，此时覆盖率可能低于100%
+func (g *Generator) GenerateCombinatorialTestCases(data map[string]any, strength, maxCases int) (CombinatorialResult, error) {
+	parameters := g.CombinatorialParameters(data)
+	if len(parameters) == 0 {
+		return CombinatorialResult{}, fmt.Errorf("报文中没有可组合的字段，请在约束中配置 values 候选值或 integer、float、date、datetime 范围")
+	}
+	if strength > len(parameters) {
+		strength = len(parameters)
+	}
+
+	sizes := make([]int, len(parameters))
+	for i, parameter := range parameters {
+		sizes[i] = len(parameter.Values)
+	}
+	rows, total, covered := BuildCoveringArray(sizes, strength, maxCases)
+
+	result := CombinatorialResult{Parameters: parameters, Strength: strength, TotalTuples: total, CoveredTuples: covered}
+	for i, row := range rows {
+		testCase := deepCopyValue(data).(map[string]any)
+		for j, parameter := range parameters {
+			value := parameter.Values[row[j]]
+			testCase = updateField(testCase, parameter.Field, func(any) (any, bool) { return value, true })
+		}
+		g.applyDependentConstraints(testCase)
+		mutation := Mutation{Field: fmt.Sprintf("%d-wise#%d", strength, i+1), Operator: CombinationLabel}
+		result.TestCases = append(result.TestCases, LabeledTestCase{
+			Data:        testCase,
+			Type:        CaseTypePositive,
+			Label:       mutation.Label(),
+			Description: mutation.Description(),
+		})
+	}
+	return result, nil
+}
+
+// coveringState 保存构造覆盖数组过程中的未覆盖取值组合
+type coveringState struct {
+	sizes     []int
+	combos    [][]int  // 所有参数组合
+	uncovered [][]bool // 每个参数组合下尚未覆盖的取值组合
+	pending   [][]int  // 每个参数的每个取值所在的未覆盖取值组合数
+	remaining int      // 未覆盖的取值组合总数
+}
+
+// BuildCoveringArray 使用贪心算法构造覆盖数组，sizes为每个参数的取值个数
+// 返回每行各参数的取值下标、需要覆盖的组合总数和已覆盖的组合数，相同输入的结果相同
+func BuildCoveringArray(sizes []int, strength, maxRows int) ([][]int, int, int) {
+	state := &coveringState{sizes: sizes, combos: parameterCombinations(len(sizes), strength)}
+	state.uncovered = make([][]bool, len(state.combos))
+	state.pending = make([][]int, len(sizes))
+	for p, size := range sizes {
+		state.pending[p] = make([]int, size)
+	}
+	for i, combo := range state.combos {
+		count := 1
+		for _, p := range combo {
+			count *= sizes[p]
+		}
+		state.uncovered[i] = make([]bool, count)
+		for j := range state.uncovered[i] {
+			state.uncovered[i][j] = true
+		}
+		for _, p := range combo {
+			for v := range state.pending[p] {
+				state.pending[p][v] += count / sizes[p]
+			}
+		}
+		state.remaining += count
+	}
+
+	total := state.remaining
+	var rows [][]int
+	for state.remaining > 0 && (maxRows <= 0 || len(rows) < maxRows) {
+		// 以不同参数组合中第一个未覆盖的取值组合为起点构造候选行，选择新覆盖组合最多的一行
+		var best []int
+		bestGain, seeds := 0, 0
+		for i, combo := range state.combos {
+			index := firstTrue(state.uncovered[i])
+			if index < 0 {
+				continue
+			}
+			row := state.completeRow(combo, index)
+			if gain := state.gain(row, -1); gain > bestGain {
+				best, bestGain = row, gain
+			}
+			if seeds++; seeds == coveringSeedCandidates {
+				break
+			}
+		}
+		state.cover(best)
+		rows = append(rows, best)
+	}
+	return rows, total, total - state.remaining
+}
+
+// completeRow 以参数组合combo的第index个取值组合为起点，依次为其余参数选择新覆盖组合最多的取值
+// 新覆盖组合数相同时选择未覆盖组合更多的取值
+func (s *coveringState) completeRow(combo []int, index int) []int {
+	row := make([]int, len(s.sizes))
+	for i := range row {
+		row[i] = -1
+	}
+	for k := len(combo) - 1; k >= 0; k-- {
+		row[combo[k]] = index % s.sizes[combo[k]]
+		index /= s.sizes[combo[k]]
+	}
+
+	for p := range row {
+		if row[p] >= 0 {
+			continue
+		}
+		best, bestGain := 0, -1
+		for v := 0; v < s.sizes[p]; v++ {
+			row[p] = v
+			gain := s.gain(row, p)
+			if gain > bestGain || (gain == bestGain && s.pending[p][v] > s.pending[p][best]) {
+				best, bestGain = v, gain
+			}
+		}
+		row[p] = best
+	}
+	return row
+}
+
+// gain 统计行新覆盖的取值组合数，required不为-1时只统计包含该参数的组合
+func (s *coveringState) gain(row []int, required int) int {
+	gain := 0
+	for i, combo := range s.combos {
+		if index, ok := tupleIndex(combo, row, s.sizes, required); ok && s.uncovered[i][index] {
+			gain++
+		}
+	}
+	return gain
+}
+
+// cover 将行覆盖的取值组合标记为已覆盖
+func (s *coveringState) cover(row []int) {
+	for i, combo := range s.combos {
+		if index, ok := tupleIndex(combo, row, s.sizes, -1); ok && s.uncovered[i][index] {
+			s.uncovered[i][index] = false
+			s.remaining--
+			for _, p := range combo {
+				s.pending[p][row[p]]--
+			}
+		}
+	}
+}
+
+// parameterCombinations 按字典序返回从n个参数中选取k个的所有组合
+func parameterCombinations(n, k int) [][]int {
+	var result [][]int
+	combo := make([]int, 0, k)
+	var walk func(start int)
+	walk = func(start int) {
+		if len(combo) == k {
+			result = append(result, append([]int(nil), combo...))
+			return
+		}
+		for i := start; i < n; i++ {
+			combo = append(combo, i)
+			walk(i + 1)
+			combo = combo[:len(combo)-1]
+		}
+	}
+	walk(0)
+	return result
+}
+
+// tupleIndex 计算行在参数组合上的取值下标
+// required不为-1时只统计包含该参数的组合；组合中有参数尚未赋值时返回false
+func tupleIndex(combo, row, sizes []int, required int) (int, bool) {
+	index, contains := 0, required < 0
+	for _, p := range combo {
+		if row[p] < 0 {
+			return 0, false
+		}
+		if p == required {
+			contains = true
+		}
+		index = index*sizes[p] + row[p]
+	}
+	return index, contains
+}
+
+// firstTrue 返回第一个为true的下标，不存在时返回-1
+func firstTrue(values []bool) int {
+	for i, value := range values {
+		if value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestParseStrategyStrength 测试解析组合策略
+func TestParseStrategyStrength(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     int
+		wantErr  bool
+	}{
+		{"pairwise", 2, false},
+		{"3-wise", 3, false},
+		{"  4-WISE ", 4, false},
+		{"1-wise", 0, true},
+		{"random", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			got, err := ParseStrategyStrength(tt.strategy)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseStrategyStrength(%q) = %d, %v", tt.strategy, got, err)
+			}
+		})
+	}
+}
+
+// TestBuildCoveringArray 测试覆盖数组覆盖全部取值组合且用例数远少于全组合
+func TestBuildCoveringArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    []int
+		strength int
+		maxRows  int
+	}{
+		{name: "12个二值字段两两组合", sizes: []int{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}, strength: 2, maxRows: 10},
+		{name: "4个三值字段两两组合", sizes: []int{3, 3, 3, 3}, strength: 2, maxRows: 10},
+		{name: "混合取值个数三三组合", sizes: []int{2, 3, 2, 4, 2}, strength: 3, maxRows: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, total, covered := BuildCoveringArray(tt.sizes, tt.strength, 0)
+			if covered != total {
+				t.Fatalf("覆盖率应为100%%: %d/%d", covered, total)
+			}
+			if len(rows) > tt.maxRows {
+				t.Errorf("用例数量过多: %d > %d", len(rows), tt.maxRows)
+			}
+
+			// 逐一检查每个取值组合都出现在某一行中
+			seen := make(map[string]bool)
+			for _, combo := range parameterCombinations(len(tt.sizes), tt.strength) {
+				for _, row := range rows {
+					key := fmt.Sprint(combo)
+					for _, p := range combo {
+						key += fmt.Sprintf(",%d", row[p])
+					}
+					seen[key] = true
+				}
+			}
+			if len(seen) != total {
+				t.Errorf("实际覆盖的取值组合数 %d 与总数 %d 不一致", len(seen), total)
+			}
+
+			again, _, _ := BuildCoveringArray(tt.sizes, tt.strength, 0)
+			if fmt.Sprint(again) != fmt.Sprint(rows) {
+				t.Error("相同输入多次生成的覆盖数组应相同")
+			}
+		})
+	}
+}
+
+// TestGenerateCombinatorialTestCases 测试根据约束候选值和布尔字段生成组合用例
+func TestGenerateCombinatorialTestCases(t *testing.T) {
+	minAge, maxAge := 18.0, 60.0
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"status": {Type: "integer", Values: []any{int64(0), int64(1), int64(9)}},
+		"age":    {Type: "integer", Min: &minAge, Max: &maxAge},
+	}}, DefaultVariationRate, 1)
+	g.Schema.KeyOrder = []string{"name", "status", "vip", "user"}
+
+	data := map[string]any{"name": "张三", "status": 1, "vip": false, "user": map[string]any{"age": 30}}
+	result, err := g.GenerateCombinatorialTestCases(data, 2, 0)
+	if err != nil {
+		t.Fatalf("生成组合用例失败: %v", err)
+	}
+
+	var fields []string
+	for _, parameter := range result.Parameters {
+		fields = append(fields, fmt.Sprintf("%s:%d", parameter.Field, len(parameter.Values)))
+	}
+	if fmt.Sprint(fields) != "[status:3 vip:2 user.age:4]" {
+		t.Errorf("组合字段错误: %v", fields)
+	}
+	if result.Coverage() != 1 || result.TotalTuples != 3*2+3*4+2*4 {
+		t.Errorf("覆盖率错误: %d/%d", result.CoveredTuples, result.TotalTuples)
+	}
+	first := result.TestCases[0]
+	if first.Label != "combination:2-wise#1" || first.Type != CaseTypePositive || first.Data["name"] != "张三" {
+		t.Errorf("组合用例错误: %+v", first)
+	}
+	if caseType, description := ParseCaseLabel(first.Label); caseType != CaseTypePositive || description != "组合用例 2-wise#1" {
+		t.Errorf("解析组合标签错误: %s %s", caseType, description)
+	}
+	if data["status"] != 1 || data["user"].(map[string]any)["age"] != 30 {
+		t.Error("生成组合用例不应修改正例")
+	}
+
+	limited, err := g.GenerateCombinatorialTestCases(data, 2, 3)
+	if err != nil || len(limited.TestCases) != 3 || limited.Coverage() >= 1 {
+		t.Errorf("限制用例数量后覆盖率应低于100%%: %d 条, %d/%d", len(limited.TestCases), limited.CoveredTuples, limited.TotalTuples)
+	}
+
+	if _, err := g.GenerateCombinatorialTestCases(map[string]any{"name": "张三"}, 2, 0); err == nil {
+		t.Error("没有可组合字段时应返回错误")
+	}
+}
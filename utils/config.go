@@ -4,15 +4,53 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
 // LLMConfig LLM相关配置
 type LLMConfig struct {
-	URL        string `toml:"url"`         // LLM API Base URL
-	APIKey     string `toml:"api_key"`     // LLM API Key
-	UserPrompt string `toml:"user_prompt"` // 自定义提示词
+	Provider          string   `toml:"provider"`            // LLM提供方（dify 或 openai，默认dify）
+	URL               string   `toml:"url"`                 // LLM API Base URL
+	APIKey            string   `toml:"api_key"`             // LLM API Key
+	AnalysisURL       string   `toml:"analysis_url"`        // 失败分析使用的API Base URL（可选，默认与url相同）
+	AnalysisAPIKey    string   `toml:"analysis_api_key"`    // 失败分析使用的API Key（可选，默认与api_key相同）
+	UserPrompt        string   `toml:"user_prompt"`         // 自定义提示词
+	PromptTemplate    string   `toml:"prompt_template"`     // 提示词模板名称（如 boundary、security）
+	PromptTemplateDir string   `toml:"prompt_template_dir"` // 自定义提示词模板目录（同名模板覆盖内置模板）
+	Model             string   `toml:"model"`               // 模型名称（openai提供方必填）
+	Temperature       *float64 `toml:"temperature"`         // 采样温度（可选）
+	MaxTokens         int      `toml:"max_tokens"`          // 最大生成token数（可选）
+	Stream            *bool    `toml:"stream"`              // 是否使用流式响应（默认true）
+	BatchSize         int      `toml:"batch_size"`          // 分批生成时每批的用例数量（默认50）
+	BatchConcurrency  int      `toml:"batch_concurrency"`   // 分批生成时的并发批次数（默认1）
+	Repair            bool     `toml:"repair"`              // 请求LLM重新生成结构损坏的用例
+	CheckConstraints  bool     `toml:"check_constraints"`   // 使用[constraints]中的约束校验生成的用例
+	Timeout           int      `toml:"timeout"`             // 单次LLM请求的整体超时时间（秒，默认300）
+	IdleTimeout       int      `toml:"idle_timeout"`        // 流式响应的空闲超时时间（秒，默认60，仅dify）
+	Retries           *int     `toml:"retries"`             // 失败后的重试次数（默认2，仅dify）
+	RetryBackoff      int      `toml:"retry_backoff"`       // 首次重试前的等待时间（秒，默认2，之后每次翻倍，仅dify）
+	Cache             *bool    `toml:"cache"`               // 是否使用LLM响应缓存（默认true）
+	CacheDir          string   `toml:"cache_dir"`           // LLM响应缓存目录（默认为用户缓存目录下的 atc/llm）
+	PromptPrice       float64  `toml:"prompt_price"`        // 每百万输入token的价格（用于估算费用，Dify返回费用时不使用）
+	CompletionPrice   float64  `toml:"completion_price"`    // 每百万输出token的价格
+	Currency          string   `toml:"currency"`            // 价格的货币单位（默认USD）
+	UsageLog          string   `toml:"usage_log"`           // 生成日志路径（默认为用户缓存目录下的 atc/generations.jsonl）
+	Team              string   `toml:"team"`                // 团队标识，写入生成日志用于按团队统计费用
+}
+
+// AnalysisConfig 返回失败分析使用的LLM配置
+// 配置了 analysis_url 或 analysis_api_key 时替换生成用例使用的地址和密钥，
+// 便于Dify提供方将失败分析发送到单独的应用，而不是生成测试用例的Chatflow
+func (c LLMConfig) AnalysisConfig() LLMConfig {
+	if c.AnalysisURL != "" {
+		c.URL = c.AnalysisURL
+	}
+	if c.AnalysisAPIKey != "" {
+		c.APIKey = c.AnalysisAPIKey
+	}
+	return c
 }
 
 // RequestConfig 请求相关配置
@@ -29,6 +67,44 @@ type RequestConfig struct {
 	Headers           []string `toml:"headers"`             // 自定义HTTP头
 	Query             []string `toml:"query"`               // GET请求的URL查询参数
 	IgnoreTLSErrors   bool     `toml:"ignore_tls_errors"`   // 忽略TLS证书验证错误
+	FollowRedirects   *bool    `toml:"follow_redirects"`    // 是否跟随重定向（默认跟随）
+	MaxRedirects      int      `toml:"max_redirects"`       // 最大重定向次数（默认10）
+	SameHostRedirects bool     `toml:"same_host_redirects"` // 只跟随同一主机内的重定向
+	MaxBodySize       string   `toml:"max_body_size"`       // 最大响应体大小（如 "10MB"，默认10MB）
+	BinaryBody        string   `toml:"binary_body"`         // 二进制响应体保存方式（hash 或 base64，默认hash）
+	SaveBodiesDir     string   `toml:"save_bodies_dir"`     // 完整响应体保存目录（每个用例一个子目录）
+	MaxSavedBodySize  string   `toml:"max_saved_body_size"` // 保存和统计响应体的最大大小（如 "100MB"，默认为max_body_size的10倍）
+	Analyze           bool     `toml:"analyze"`             // 请求完成后使用LLM分析失败用例
+}
+
+// ResponseBodyOptions 根据请求配置构建响应体读取选项
+func (c RequestConfig) ResponseBodyOptions() (ResponseBodyOptions, error) {
+	maxSize, err := ParseByteSize(c.MaxBodySize)
+	if err != nil {
+		return ResponseBodyOptions{}, fmt.Errorf("request.max_body_size 配置错误: %w", err)
+	}
+	maxSavedSize, err := ParseByteSize(c.MaxSavedBodySize)
+	if err != nil {
+		return ResponseBodyOptions{}, fmt.Errorf("request.max_saved_body_size 配置错误: %w", err)
+	}
+	if c.BinaryBody != "" && c.BinaryBody != BinaryBodyHash && c.BinaryBody != BinaryBodyBase64 {
+		return ResponseBodyOptions{}, fmt.Errorf("request.binary_body 配置错误: 仅支持 %s 或 %s", BinaryBodyHash, BinaryBodyBase64)
+	}
+	return ResponseBodyOptions{
+		MaxSize:      maxSize,
+		BinaryMode:   c.BinaryBody,
+		SaveDir:      c.SaveBodiesDir,
+		MaxSavedSize: maxSavedSize,
+	}, nil
+}
+
+// RedirectPolicy 根据请求配置构建重定向策略
+func (c RequestConfig) RedirectPolicy() RedirectPolicy {
+	return RedirectPolicy{
+		Disabled:     c.FollowRedirects != nil && !*c.FollowRedirects,
+		MaxRedirects: c.MaxRedirects,
+		SameHostOnly: c.SameHostRedirects,
+	}
 }
 
 // TestCaseConfig 用例设置
@@ -38,6 +114,11 @@ type TestCaseConfig struct {
 	PositiveExample string  `toml:"positive_example"` // 正例报文（支持多行字符串）
 	Type            string  `toml:"type"`             // 正例报文类型（xml或json）
 	VariationRate   float64 `toml:"variation_rate"`   // 随机化因子，控制数据变化程度（0.0-1.0，默认0.5）
+	Mode            string  `toml:"mode"`             // 生成模式（random、negative、mixed或boundary，默认random）
+	NegativeRatio   float64 `toml:"negative_ratio"`   // 混合模式下反例的占比（0.0-1.0，默认0.3）
+	Strategy        string  `toml:"strategy"`         // 组合策略（pairwise或N-wise，为空时不使用）
+	Seed            int64   `toml:"seed"`             // 随机数种子，相同的种子和输入生成相同的用例（0表示随机）
+	ReferenceDate   string  `toml:"reference_date"`   // 计算身份证年龄范围的参考日期（格式 2006-01-02，为空表示当天）
 }
 
 // ConstraintsConfig 约束系统配置
@@ -88,17 +169,25 @@ func LoadConfig(configFile string) (*Config, error) {
 		if constraintsMap, ok := constraintsNode.(map[string]any); ok {
 			config.Constraints.Constraints = make(map[string]FieldConstraint)
 
+			// 展开嵌套的约束表，如 [constraints.user.name] 对应约束名称 user.name
+			flattened := make(map[string]any)
 			for key, value := range constraintsMap {
 				if key == "enable" || key == "builtin_data" {
 					// 跳过已经解析的字段
 					continue
 				}
+				flattenConstraintTables(key, value, flattened)
+			}
+
+			for key, value := range flattened {
 
 				// 解析字段约束
 				constraintBytes, _ := toml.Marshal(map[string]any{key: value})
 				var temp map[string]FieldConstraint
 				if toml.Unmarshal(constraintBytes, &temp) == nil {
 					if constraint, exists := temp[key]; exists {
+						// TOML中的日期时间候选值统一按字符串使用
+						constraint.Values = normalizeConstraintValues(constraint.Values)
 						config.Constraints.Constraints[key] = constraint
 					}
 				}
@@ -109,45 +198,78 @@ func LoadConfig(configFile string) (*Config, error) {
 	return &config, nil
 }
 
-// LoadConfigWithConstraints 从指定文件加载配置并设置约束系统
+// flattenConstraintTables 展开嵌套的约束表，结果写入 result
+// 没有 type 字段且所有值都是表的节点视为路径前缀，子表的名称与前缀拼接为路径选择器
+func flattenConstraintTables(name string, value any, result map[string]any) {
+	if table, ok := value.(map[string]any); ok && len(table) > 0 {
+		if _, hasType := table["type"]; !hasType {
+			nested := true
+			for _, child := range table {
+				if _, ok := child.(map[string]any); !ok {
+					nested = false
+					break
+				}
+			}
+			if nested {
+				for key, child := range table {
+					if strings.HasPrefix(key, "[") {
+						// 数组下标直接拼接，如 items 和 [*] 拼接为 items[*]
+						flattenConstraintTables(name+key, child, result)
+					} else {
+						flattenConstraintTables(name+"."+key, child, result)
+					}
+				}
+				return
+			}
+		}
+	}
+	result[name] = value
+}
+
+// LoadConfigWithConstraints 从指定文件加载配置并设置默认生成器的约束配置
 func LoadConfigWithConstraints(configFile string) (*Config, error) {
 	config, err := LoadConfig(configFile)
 	if err != nil {
 		return nil, err
 	}
 
-	// 检查约束系统是否启用
-	constraintsEnabled := IsConstraintsEnabled(config)
+	constraintConfig, err := config.ConstraintConfig()
+	if err != nil {
+		return nil, err
+	}
+	SetConstraintConfig(constraintConfig)
 
-	// 如果约束系统启用且配置文件中包含约束配置，设置全局约束配置
-	if constraintsEnabled && (len(config.Constraints.Constraints) > 0 || len(config.Constraints.BuiltinData.FirstNames) > 0 || len(config.BuiltinData.FirstNames) > 0) {
-		// 合并约束配置（优先使用constraints节点下的配置，向后兼容builtin_data）
-		constraints := config.Constraints.Constraints
-		builtinData := config.Constraints.BuiltinData
+	return config, nil
+}
 
-		// 向后兼容：如果constraints节点下没有builtin_data，使用根节点下的
-		if len(builtinData.FirstNames) == 0 && len(config.BuiltinData.FirstNames) > 0 {
-			builtinData = config.BuiltinData
-		}
+// ConstraintConfig 返回配置文件中启用的约束配置，约束系统未启用或没有约束配置时返回nil
+func (c *Config) ConstraintConfig() (*ConstraintConfig, error) {
+	// 检查约束系统是否启用
+	if !IsConstraintsEnabled(c) {
+		return nil, nil
+	}
+	if len(c.Constraints.Constraints) == 0 && len(c.Constraints.BuiltinData.FirstNames) == 0 && len(c.BuiltinData.FirstNames) == 0 {
+		return nil, nil
+	}
 
-		constraintConfig := &ConstraintConfig{
-			Constraints: constraints,
-			BuiltinData: builtinData,
-		}
+	// 合并约束配置（优先使用constraints节点下的配置，向后兼容builtin_data）
+	builtinData := c.Constraints.BuiltinData
 
-		// 验证约束配置
-		if err := ValidateConstraintConfig(constraintConfig); err != nil {
-			return nil, fmt.Errorf("约束配置验证失败: %w", err)
-		}
+	// 向后兼容：如果constraints节点下没有builtin_data，使用根节点下的
+	if len(builtinData.FirstNames) == 0 && len(c.BuiltinData.FirstNames) > 0 {
+		builtinData = c.BuiltinData
+	}
 
-		// 设置全局约束配置
-		globalConstraintConfig = constraintConfig
-	} else {
-		// 约束系统未启用，清空全局约束配置
-		globalConstraintConfig = nil
+	constraintConfig := &ConstraintConfig{
+		Constraints: c.Constraints.Constraints,
+		BuiltinData: builtinData,
 	}
 
-	return config, nil
+	// 验证约束配置
+	if err := ValidateConstraintConfig(constraintConfig); err != nil {
+		return nil, fmt.Errorf("约束配置验证失败: %w", err)
+	}
+	return constraintConfig, nil
 }
 
 // IsConstraintsEnabled 检查约束系统是否启用
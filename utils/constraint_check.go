@@ -0,0 +1,356 @@
+// Package utils 提供约束定义的序列化和生成结果的约束校验
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// constraintSpec 序列化给LLM的字段约束，只包含已设置的属性
+type constraintSpec struct {
+	Field        string         `json:"field"`
+	Type         string         `json:"type"`
+	Format       string         `json:"format,omitempty"`
+	MinDate      string         `json:"min_date,omitempty"`
+	MaxDate      string         `json:"max_date,omitempty"`
+	MinDatetime  string         `json:"min_datetime,omitempty"`
+	MaxDatetime  string         `json:"max_datetime,omitempty"`
+	Timezone     string         `json:"timezone,omitempty"`
+	Min          *float64       `json:"min,omitempty"`
+	Max          *float64       `json:"max,omitempty"`
+	Precision    *int           `json:"precision,omitempty"`
+	KeepOriginal *bool          `json:"keep_original,omitempty"`
+	Values       []any          `json:"values,omitempty"`
+	Weights      []float64      `json:"weights,omitempty"`
+	Pattern      string         `json:"pattern,omitempty"`
+	MinAge       *int           `json:"min_age,omitempty"`
+	MaxAge       *int           `json:"max_age,omitempty"`
+	Gender       string         `json:"gender,omitempty"`
+	BinPrefixes  []string       `json:"bin_prefixes,omitempty"`
+	Length       *int           `json:"length,omitempty"`
+	Start        *int           `json:"start,omitempty"`
+	Step         *int           `json:"step,omitempty"`
+	Unique       bool           `json:"unique,omitempty"`
+	Expr         string         `json:"expr,omitempty"`
+	After        string         `json:"after,omitempty"`
+	DependsOn    string         `json:"depends_on,omitempty"`
+	Mapping      map[string]any `json:"mapping,omitempty"`
+	Description  string         `json:"description,omitempty"`
+}
+
+// SerializeConstraints 将字段约束序列化为按字段名排序的JSON数组，作为LLM的结构化输入
+func SerializeConstraints(constraints map[string]FieldConstraint) string {
+	if len(constraints) == 0 {
+		return ""
+	}
+
+	names := sortedKeys(constraints)
+	specs := make([]constraintSpec, 0, len(names))
+	for _, name := range names {
+		c := constraints[name]
+		specs = append(specs, constraintSpec{
+			Field:        name,
+			Type:         c.Type,
+			Format:       c.Format,
+			MinDate:      c.MinDate,
+			MaxDate:      c.MaxDate,
+			MinDatetime:  c.MinDatetime,
+			MaxDatetime:  c.MaxDatetime,
+			Timezone:     c.Timezone,
+			Min:          c.Min,
+			Max:          c.Max,
+			Precision:    c.Precision,
+			KeepOriginal: c.KeepOriginal,
+			Values:       normalizeConstraintValues(c.Values),
+			Weights:      c.Weights,
+			Pattern:      c.Pattern,
+			MinAge:       c.MinAge,
+			MaxAge:       c.MaxAge,
+			Gender:       c.Gender,
+			BinPrefixes:  c.BinPrefixes,
+			Length:       c.Length,
+			Start:        c.Start,
+			Step:         c.Step,
+			Unique:       c.Unique,
+			Expr:         c.Expr,
+			After:        c.After,
+			DependsOn:    c.DependsOn,
+			Mapping:      c.Mapping,
+			Description:  c.Description,
+		})
+	}
+
+	data, _ := json.Marshal(specs)
+	return string(data)
+}
+
+// ConstraintViolation 表示生成的用例中违反约束的字段
+type ConstraintViolation struct {
+	Field   string // 字段路径
+	Value   any    // 字段值
+	Message string // 违反的约束说明
+}
+
+// ConstraintCheckResult 表示单个用例的约束校验结果
+type ConstraintCheckResult struct {
+	Index      int                   // 用例序号（从1开始）
+	Violations []ConstraintViolation // 违反约束的字段
+	Err        error                 // 报文解析错误
+}
+
+// 常见约束类型的格式校验规则
+var (
+	phonePattern       = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	emailPattern       = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	idCardPattern      = regexp.MustCompile(`^\d{17}[\dXx]$`)
+	bankCardPattern    = regexp.MustCompile(`^\d{12,19}$`)
+	chineseNamePattern = regexp.MustCompile(`^\p{Han}{2,4}$`)
+)
+
+// constraintDateLayout 最小、最大日期以及日期约束默认使用的格式
+const constraintDateLayout = "20060102"
+
+// CheckCasesConstraints 使用约束配置校验生成的用例，返回每个用例的校验结果
+func CheckCasesConstraints(testCases []string, format string, constraints map[string]FieldConstraint) []ConstraintCheckResult {
+	results := make([]ConstraintCheckResult, len(testCases))
+	for i, testCase := range testCases {
+		violations, err := CheckCaseConstraints(testCase, format, constraints)
+		results[i] = ConstraintCheckResult{Index: i + 1, Violations: violations, Err: err}
+	}
+	return results
+}
+
+// CheckCaseConstraints 使用约束配置校验单个用例中的字段值和字段之间的依赖约束
+// 字段查找规则与本地生成用例时一致（完整路径、小写、去掉路径前缀的简单字段名）
+func CheckCaseConstraints(payload, format string, constraints map[string]FieldConstraint) ([]ConstraintViolation, error) {
+	data, err := payloadToMap(payload, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []ConstraintViolation
+	for _, key := range sortedKeys(data) {
+		violations = append(violations, checkValueConstraints(data[key], key, constraints)...)
+	}
+	dependencyViolations, err := checkDependentConstraints(data, constraints)
+	if err != nil {
+		return nil, err
+	}
+	return append(violations, dependencyViolations...), nil
+}
+
+// CheckConstrainedValue 检查字段值是否满足约束，满足时返回nil
+func CheckConstrainedValue(constraint *FieldConstraint, value any) error {
+	if constraint == nil || constraint.Type == "keep_original" || (constraint.KeepOriginal != nil && *constraint.KeepOriginal) {
+		return nil
+	}
+
+	switch constraint.Type {
+	case "date":
+		return checkDateValue(constraint, value)
+	case "datetime":
+		return checkDatetimeValue(constraint, value)
+	case "integer":
+		number, err := constraintNumber(value)
+		if err != nil {
+			return err
+		}
+		if number != math.Trunc(number) {
+			return fmt.Errorf("不是整数")
+		}
+		return checkNumberRange(constraint, number)
+	case "float":
+		number, err := constraintNumber(value)
+		if err != nil {
+			return err
+		}
+		if constraint.Precision != nil {
+			multiplier := math.Pow(10, float64(*constraint.Precision))
+			if math.Abs(number*multiplier-math.Round(number*multiplier)) > 1e-6 {
+				return fmt.Errorf("小数位数超过 %d 位", *constraint.Precision)
+			}
+		}
+		return checkNumberRange(constraint, number)
+	case "phone":
+		return checkPattern(value, phonePattern, "不是有效的手机号")
+	case "email":
+		return checkPattern(value, emailPattern, "不是有效的邮箱地址")
+	case "id_card":
+		return checkIDCardValue(constraint, value)
+	case "bank_card":
+		return checkBankCardValue(constraint, value)
+	case "chinese_name":
+		return checkPattern(value, chineseNamePattern, "不是2-4个汉字的中文姓名")
+	case "pattern":
+		return checkPatternValue(constraint, value)
+	case "sequence":
+		return checkSequenceValue(constraint, value)
+	case "enum":
+		for _, candidate := range constraint.Values {
+			if enumValueEqual(candidate, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("不在候选值 %v 中", constraint.Values)
+	case "chinese_address":
+		if str, ok := value.(string); !ok || strings.TrimSpace(str) == "" {
+			return fmt.Errorf("地址不能为空")
+		}
+	}
+	return nil
+}
+
+// checkValueConstraints 递归校验字段值，嵌套字段名的构造方式与 generateVariationWithConstraints 一致
+func checkValueConstraints(value any, fieldName string, constraints map[string]FieldConstraint) []ConstraintViolation {
+	switch v := value.(type) {
+	case map[string]any:
+		constraint := lookupFieldConstraint(constraints, fieldName)
+		if constraint != nil && constraint.Type != "keep_original" && (constraint.KeepOriginal == nil || !*constraint.KeepOriginal) {
+			return checkFieldConstraint(constraint, value, fieldName)
+		}
+		var violations []ConstraintViolation
+		for _, key := range sortedKeys(v) {
+			violations = append(violations, checkValueConstraints(v[key], fieldName+"."+key, constraints)...)
+		}
+		return violations
+	case []any:
+		var violations []ConstraintViolation
+		for i, item := range v {
+			violations = append(violations, checkValueConstraints(item, fmt.Sprintf("%s[%d]", fieldName, i), constraints)...)
+		}
+		return violations
+	default:
+		return checkFieldConstraint(lookupFieldConstraint(constraints, fieldName), value, fieldName)
+	}
+}
+
+// checkFieldConstraint 校验单个字段，违反约束时返回一条记录
+func checkFieldConstraint(constraint *FieldConstraint, value any, fieldName string) []ConstraintViolation {
+	if err := CheckConstrainedValue(constraint, value); err != nil {
+		return []ConstraintViolation{{Field: fieldName, Value: value, Message: fmt.Sprintf("%s约束: %v", constraint.Type, err)}}
+	}
+	return nil
+}
+
+// enumValueEqual 判断字段值是否等于枚举候选值，数值与数字字符串按数值比较
+func enumValueEqual(candidate, value any) bool {
+	if fmt.Sprint(normalizeConstraintValue(candidate)) == fmt.Sprint(value) {
+		return true
+	}
+	candidateNumber, err := constraintNumber(candidate)
+	if err != nil {
+		return false
+	}
+	valueNumber, err := constraintNumber(value)
+	return err == nil && candidateNumber == valueNumber
+}
+
+// checkDateValue 校验日期值的格式和范围
+func checkDateValue(constraint *FieldConstraint, value any) error {
+	format := constraint.Format
+	if format == "" {
+		format = constraintDateLayout
+	}
+	date, err := time.Parse(format, fmt.Sprint(value))
+	if err != nil {
+		return fmt.Errorf("不符合日期格式 %s", format)
+	}
+	// 比较时统一转换为最小、最大日期使用的 YYYYMMDD 精度
+	date, _ = time.Parse(constraintDateLayout, date.Format(constraintDateLayout))
+	if minDate, err := time.Parse(constraintDateLayout, constraint.MinDate); err == nil && date.Before(minDate) {
+		return fmt.Errorf("早于最小日期 %s", constraint.MinDate)
+	}
+	if maxDate, err := time.Parse(constraintDateLayout, constraint.MaxDate); err == nil && date.After(maxDate) {
+		return fmt.Errorf("晚于最大日期 %s", constraint.MaxDate)
+	}
+	return nil
+}
+
+// checkDatetimeValue 校验RFC 3339日期时间值的格式和范围
+func checkDatetimeValue(constraint *FieldConstraint, value any) error {
+	datetime, err := time.Parse(time.RFC3339, fmt.Sprint(value))
+	if err != nil {
+		return fmt.Errorf("不是RFC 3339格式的日期时间")
+	}
+	if minDatetime, err := time.Parse(time.RFC3339, constraint.MinDatetime); err == nil && datetime.Before(minDatetime) {
+		return fmt.Errorf("早于最小日期时间 %s", constraint.MinDatetime)
+	}
+	if maxDatetime, err := time.Parse(time.RFC3339, constraint.MaxDatetime); err == nil && datetime.After(maxDatetime) {
+		return fmt.Errorf("晚于最大日期时间 %s", constraint.MaxDatetime)
+	}
+	return nil
+}
+
+// checkNumberRange 校验数值是否在最小值和最大值之间
+func checkNumberRange(constraint *FieldConstraint, number float64) error {
+	if constraint.Min != nil && number < *constraint.Min {
+		return fmt.Errorf("小于最小值 %v", *constraint.Min)
+	}
+	if constraint.Max != nil && number > *constraint.Max {
+		return fmt.Errorf("大于最大值 %v", *constraint.Max)
+	}
+	return nil
+}
+
+// checkPattern 校验字符串值是否匹配格式
+func checkPattern(value any, pattern *regexp.Regexp, message string) error {
+	str, ok := value.(string)
+	if !ok {
+		// XML中的纯数字内容会被解析为数值，按文本形式校验
+		str = fmt.Sprint(value)
+	}
+	if !pattern.MatchString(str) {
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
+
+// constraintNumber 将字段值转换为数值，数字字符串同样视为数值
+func constraintNumber(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("不是数值")
+		}
+		return number, nil
+	default:
+		return 0, fmt.Errorf("不是数值")
+	}
+}
+
+// payloadToMap 将报文解析为字段映射，XML报文返回根元素内的内容
+func payloadToMap(payload, format string) (map[string]any, error) {
+	if strings.EqualFold(format, "xml") {
+		// 非UTF-8编码的声明改为UTF-8，与 ParseXML 的处理方式一致
+		result, err := XMLToMap(xmlEncodingRegex.ReplaceAllString(payload, `encoding="UTF-8"`))
+		if err != nil {
+			return nil, fmt.Errorf("解析XML失败: %v", err)
+		}
+		for _, rootValue := range result {
+			if rootMap, ok := rootValue.(map[string]any); ok {
+				return rootMap, nil
+			}
+		}
+		return result, nil
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal([]byte(payload), &result); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %v", err)
+	}
+	return result, nil
+}
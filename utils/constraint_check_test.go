@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestSerializeConstraints 测试约束序列化为LLM输入
+func TestSerializeConstraints(t *testing.T) {
+	minAge, maxAge := 18.0, 60.0
+	constraints := map[string]FieldConstraint{
+		"phone":    {Type: "phone", Description: "手机号"},
+		"age":      {Type: "integer", Min: &minAge, Max: &maxAge},
+		"birthday": {Type: "date", Format: "2006-01-02", MinDate: "19900101", MaxDate: "20001231"},
+	}
+
+	serialized := SerializeConstraints(constraints)
+	var specs []map[string]any
+	if err := json.Unmarshal([]byte(serialized), &specs); err != nil {
+		t.Fatalf("序列化结果不是JSON数组: %v", err)
+	}
+	if len(specs) != 3 || specs[0]["field"] != "age" || specs[1]["field"] != "birthday" || specs[2]["field"] != "phone" {
+		t.Fatalf("序列化结果应按字段名排序: %s", serialized)
+	}
+	if specs[0]["min"] != 18.0 || specs[1]["min_date"] != "19900101" || specs[2]["description"] != "手机号" {
+		t.Errorf("序列化结果缺少约束属性: %s", serialized)
+	}
+	if _, ok := specs[2]["min"]; ok {
+		t.Errorf("未设置的属性不应输出: %s", serialized)
+	}
+
+	inputs := generationInputs(GenerationRequest{Format: "json", Num: 1, Constraints: constraints})
+	if inputs["constraints"] != serialized {
+		t.Errorf("生成请求输入参数应包含约束: %v", inputs)
+	}
+	if message := buildUserMessage(GenerationRequest{Format: "json", Num: 1, Constraints: constraints}); !strings.Contains(message, serialized) {
+		t.Errorf("用户消息应包含约束: %s", message)
+	}
+	if _, ok := generationInputs(GenerationRequest{Format: "json", Num: 1})["constraints"]; ok {
+		t.Error("未配置约束时不应添加constraints输入")
+	}
+}
+
+// TestCheckCaseConstraints 测试使用约束校验生成的用例
+func TestCheckCaseConstraints(t *testing.T) {
+	minAge, maxAge := 18.0, 60.0
+	minPrice, maxPrice := 0.0, 1000.0
+	precision := 2
+	keep := true
+	constraints := map[string]FieldConstraint{
+		"age":      {Type: "integer", Min: &minAge, Max: &maxAge},
+		"price":    {Type: "float", Min: &minPrice, Max: &maxPrice, Precision: &precision},
+		"birthday": {Type: "date", Format: "2006-01-02", MinDate: "19900101", MaxDate: "20001231"},
+		"phone":    {Type: "phone"},
+		"email":    {Type: "email"},
+		"name":     {Type: "chinese_name"},
+		"meta":     {Type: "keep_original", KeepOriginal: &keep},
+	}
+
+	tests := []struct {
+		name       string
+		format     string
+		payload    string
+		wantFields []string
+	}{
+		{name: "JSON满足约束", format: "json", payload: `{"age":30,"price":9.99,"birthday":"1995-06-01","phone":"13800138000","email":"a@b.com","name":"张三","meta":{"note":"任意"}}`},
+		{name: "JSON超出范围", format: "json", payload: `{"age":17,"price":1000.5,"birthday":"2001-01-01"}`, wantFields: []string{"age", "birthday", "price"}},
+		{name: "JSON格式错误", format: "json", payload: `{"age":"abc","price":1.234,"birthday":"19950601","phone":"123","email":"abc","name":"Tom"}`, wantFields: []string{"age", "birthday", "email", "name", "phone", "price"}},
+		{name: "JSON嵌套字段和数组", format: "json", payload: `{"user":{"age":70},"items":[{"price":1},{"price":-1}]}`, wantFields: []string{"items[1].price", "user.age"}},
+		{name: "XML满足约束", format: "xml", payload: `<?xml version="1.0" encoding="GBK"?><req><age>20</age><phone>13800138000</phone></req>`},
+		{name: "XML违反约束", format: "xml", payload: `<req><user><age>99</age></user><phone>abc</phone></req>`, wantFields: []string{"phone", "user.age"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := CheckCaseConstraints(tt.payload, tt.format, constraints)
+			if err != nil {
+				t.Fatalf("校验失败: %v", err)
+			}
+			var fields []string
+			for _, violation := range violations {
+				fields = append(fields, violation.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("违反约束的字段错误: 期望 %v，实际 %v（%v）", tt.wantFields, fields, violations)
+			}
+		})
+	}
+
+	results := CheckCasesConstraints([]string{`{"age":30}`, `{"age":`}, "json", constraints)
+	if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+		t.Errorf("批量校验结果错误: %+v", results)
+	}
+}
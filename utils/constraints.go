@@ -3,7 +3,6 @@ package utils
 
 import (
 	"fmt"
-	"math/rand"
 	"os"
 	"strconv"
 	"strings"
@@ -14,18 +13,33 @@ import (
 
 // FieldConstraint 字段约束配置
 type FieldConstraint struct {
-	Type         string   `toml:"type"`          // 约束类型
-	Format       string   `toml:"format"`        // 格式（用于日期等）
-	MinDate      string   `toml:"min_date"`      // 最小日期
-	MaxDate      string   `toml:"max_date"`      // 最大日期
-	MinDatetime  string   `toml:"min_datetime"`  // 最小日期时间（RFC 3339 Extended格式）
-	MaxDatetime  string   `toml:"max_datetime"`  // 最大日期时间（RFC 3339 Extended格式）
-	Timezone     string   `toml:"timezone"`      // 时区（如：+08:00, UTC, Asia/Shanghai）
-	Min          *float64 `toml:"min"`           // 最小值
-	Max          *float64 `toml:"max"`           // 最大值
-	Precision    *int     `toml:"precision"`     // 精度（小数位数）
-	KeepOriginal *bool    `toml:"keep_original"` // 是否保持原值不变
-	Description  string   `toml:"description"`   // 描述
+	Type         string         `toml:"type"`          // 约束类型
+	Format       string         `toml:"format"`        // 格式（用于日期、序列等）
+	MinDate      string         `toml:"min_date"`      // 最小日期
+	MaxDate      string         `toml:"max_date"`      // 最大日期
+	MinDatetime  string         `toml:"min_datetime"`  // 最小日期时间（RFC 3339 Extended格式）
+	MaxDatetime  string         `toml:"max_datetime"`  // 最大日期时间（RFC 3339 Extended格式）
+	Timezone     string         `toml:"timezone"`      // 时区（如：+08:00, UTC, Asia/Shanghai）
+	Min          *float64       `toml:"min"`           // 最小值
+	Max          *float64       `toml:"max"`           // 最大值
+	Precision    *int           `toml:"precision"`     // 精度（小数位数）
+	KeepOriginal *bool          `toml:"keep_original"` // 是否保持原值不变
+	Values       []any          `toml:"values"`        // 候选值（enum类型的取值集合，组合测试的候选值）
+	Weights      []float64      `toml:"weights"`       // enum类型各候选值的权重（可选，默认等概率）
+	Pattern      string         `toml:"pattern"`       // 正则表达式（用于pattern类型）
+	MinAge       *int           `toml:"min_age"`       // 最小年龄（用于id_card类型）
+	MaxAge       *int           `toml:"max_age"`       // 最大年龄（用于id_card类型）
+	Gender       string         `toml:"gender"`        // 性别（用于id_card类型，male 或 female）
+	BinPrefixes  []string       `toml:"bin_prefixes"`  // BIN前缀（用于bank_card类型）
+	Length       *int           `toml:"length"`        // 号码长度（用于bank_card类型，默认16位）
+	Start        *int           `toml:"start"`         // 起始值（用于sequence类型，默认1）
+	Step         *int           `toml:"step"`          // 步长（用于sequence类型，默认1）
+	Unique       bool           `toml:"unique"`        // 同一次生成中不重复（适用于任意类型）
+	Expr         string         `toml:"expr"`          // 由其他字段计算的表达式（用于integer、float类型，如 price * quantity）
+	After        string         `toml:"after"`         // 不早于该字段的日期（用于date、datetime类型）
+	DependsOn    string         `toml:"depends_on"`    // 查找取值时依赖的字段（用于lookup类型）
+	Mapping      map[string]any `toml:"mapping"`       // 依赖字段的值到本字段取值的映射，映射值为列表时从中选择（用于lookup类型）
+	Description  string         `toml:"description"`   // 描述
 }
 
 // BuiltinData 内置数据集
@@ -34,9 +48,9 @@ type BuiltinData struct {
 	LastNames    []string `toml:"last_names"`    // 名字
 	Addresses    []string `toml:"addresses"`     // 地址
 	EmailDomains []string `toml:"email_domains"` // 邮箱域名
-	BankCards    []string `toml:"bank_cards"`    // 银行卡号
+	BankCards    []string `toml:"bank_cards"`    // 银行卡号（可选，配置后未设置BIN前缀和长度的bank_card约束从中选择）
 	PhoneNumbers []string `toml:"phone_numbers"` // 手机号
-	IDCards      []string `toml:"id_cards"`      // 身份证号
+	IDCards      []string `toml:"id_cards"`      // 身份证号（可选，配置后未设置年龄和性别的id_card约束从中选择）
 }
 
 // ConstraintConfig 约束配置
@@ -45,9 +59,6 @@ type ConstraintConfig struct {
 	BuiltinData BuiltinData                `toml:"builtin_data"` // 内置数据
 }
 
-// 全局约束配置
-var globalConstraintConfig *ConstraintConfig
-
 // ValidationError 验证错误信息
 type ValidationError struct {
 	Field   string // 字段名
@@ -89,6 +100,9 @@ func ValidateConstraintConfig(config *ConstraintConfig) error {
 		}
 	}
 
+	// 验证依赖约束之间没有循环依赖
+	errors = append(errors, validateConstraintDependencies(config.Constraints)...)
+
 	// 验证内置数据
 	if builtinErrors := validateBuiltinData(config.BuiltinData); len(builtinErrors) > 0 {
 		errors = append(errors, builtinErrors...)
@@ -105,7 +119,7 @@ func validateFieldConstraint(fieldName string, constraint FieldConstraint) []Val
 	var errors []ValidationError
 
 	// 验证约束类型
-	validTypes := []string{"date", "datetime", "chinese_name", "phone", "email", "chinese_address", "id_card", "bank_card", "integer", "float", "keep_original"}
+	validTypes := []string{"date", "datetime", "chinese_name", "phone", "email", "chinese_address", "id_card", "bank_card", "integer", "float", "pattern", "enum", "sequence", "lookup", "keep_original"}
 	if constraint.Type == "" {
 		errors = append(errors, ValidationError{
 			Field:   fieldName,
@@ -127,6 +141,9 @@ func validateFieldConstraint(fieldName string, constraint FieldConstraint) []Val
 		}
 	}
 
+	// 验证约束名称中的路径选择器
+	errors = append(errors, validateConstraintSelector(fieldName)...)
+
 	// 根据类型进行特定验证
 	switch constraint.Type {
 	case "date":
@@ -137,6 +154,42 @@ func validateFieldConstraint(fieldName string, constraint FieldConstraint) []Val
 		errors = append(errors, validateIntegerConstraint(fieldName, constraint)...)
 	case "float":
 		errors = append(errors, validateFloatConstraint(fieldName, constraint)...)
+	case "enum":
+		errors = append(errors, validateEnumConstraint(fieldName, constraint)...)
+	case "id_card":
+		errors = append(errors, validateIDCardConstraint(fieldName, constraint)...)
+	case "bank_card":
+		errors = append(errors, validateBankCardConstraint(fieldName, constraint)...)
+	case "sequence":
+		errors = append(errors, validateSequenceConstraint(fieldName, constraint)...)
+	case "pattern":
+		if _, err := parseConstraintPattern(constraint.Pattern); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// 验证依赖其他字段的表达式和日期先后
+	errors = append(errors, validateDependentConstraint(fieldName, constraint)...)
+
+	// 保持原值的字段无法保证不重复
+	if constraint.Unique && (constraint.Type == "keep_original" || (constraint.KeepOriginal != nil && *constraint.KeepOriginal)) {
+		errors = append(errors, ValidationError{
+			Field:   fieldName,
+			Message: "保持原值的字段不能设置 unique",
+		})
+	}
+
+	// 验证候选值满足约束
+	for _, value := range constraint.Values {
+		if err := CheckConstrainedValue(&constraint, normalizeConstraintValue(value)); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("候选值 '%v' 不满足约束: %v", value, err),
+			})
+		}
 	}
 
 	return errors
@@ -307,6 +360,57 @@ func validateFloatConstraint(fieldName string, constraint FieldConstraint) []Val
 	return errors
 }
 
+// validateEnumConstraint 验证枚举约束
+func validateEnumConstraint(fieldName string, constraint FieldConstraint) []ValidationError {
+	var errors []ValidationError
+
+	// 验证候选值
+	if len(constraint.Values) == 0 {
+		errors = append(errors, ValidationError{
+			Field:   fieldName,
+			Message: "枚举类型必须设置 'values' 候选值",
+		})
+	}
+	for _, value := range constraint.Values {
+		switch normalizeConstraintValue(value).(type) {
+		case string, int64, float64, bool:
+		default:
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("候选值 '%v' 不是字符串、数值、布尔值或日期时间", value),
+			})
+		}
+	}
+
+	// 验证权重
+	if len(constraint.Weights) > 0 {
+		if len(constraint.Weights) != len(constraint.Values) {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("权重个数 %d 与候选值个数 %d 不一致", len(constraint.Weights), len(constraint.Values)),
+			})
+		}
+		total := 0.0
+		for _, weight := range constraint.Weights {
+			if weight < 0 {
+				errors = append(errors, ValidationError{
+					Field:   fieldName,
+					Message: fmt.Sprintf("权重 %v 不能为负数", weight),
+				})
+			}
+			total += weight
+		}
+		if total <= 0 {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "权重之和必须大于0",
+			})
+		}
+	}
+
+	return errors
+}
+
 // validateBuiltinData 验证内置数据
 func validateBuiltinData(data BuiltinData) []ValidationError {
 	var errors []ValidationError
@@ -384,40 +488,6 @@ func validateBuiltinData(data BuiltinData) []ValidationError {
 		}
 	}
 
-	// 验证银行卡号数据
-	if len(data.BankCards) == 0 {
-		errors = append(errors, ValidationError{
-			Field:   "builtin_data.bank_cards",
-			Message: "银行卡号列表不能为空",
-		})
-	} else {
-		for i, card := range data.BankCards {
-			cardTrimmed := strings.TrimSpace(card)
-			if cardTrimmed == "" {
-				errors = append(errors, ValidationError{
-					Field:   "builtin_data.bank_cards",
-					Message: fmt.Sprintf("第 %d 个银行卡号不能为空", i+1),
-				})
-			} else if len(cardTrimmed) < 15 || len(cardTrimmed) > 19 {
-				errors = append(errors, ValidationError{
-					Field:   "builtin_data.bank_cards",
-					Message: fmt.Sprintf("第 %d 个银行卡号 '%s' 长度无效，应为15-19位数字", i+1, cardTrimmed),
-				})
-			} else {
-				// 验证是否为纯数字
-				for _, char := range cardTrimmed {
-					if char < '0' || char > '9' {
-						errors = append(errors, ValidationError{
-							Field:   "builtin_data.bank_cards",
-							Message: fmt.Sprintf("第 %d 个银行卡号 '%s' 包含非数字字符", i+1, cardTrimmed),
-						})
-						break
-					}
-				}
-			}
-		}
-	}
-
 	// 验证手机号数据
 	if len(data.PhoneNumbers) == 0 {
 		errors = append(errors, ValidationError{
@@ -452,54 +522,46 @@ func validateBuiltinData(data BuiltinData) []ValidationError {
 		}
 	}
 
-	// 验证身份证号数据
-	if len(data.IDCards) == 0 {
-		errors = append(errors, ValidationError{
-			Field:   "builtin_data.id_cards",
-			Message: "身份证号列表不能为空",
-		})
-	} else {
-		for i, idCard := range data.IDCards {
-			idCardTrimmed := strings.TrimSpace(idCard)
-			if idCardTrimmed == "" {
-				errors = append(errors, ValidationError{
-					Field:   "builtin_data.id_cards",
-					Message: fmt.Sprintf("第 %d 个身份证号不能为空", i+1),
-				})
-			} else if len(idCardTrimmed) != 18 {
-				errors = append(errors, ValidationError{
-					Field:   "builtin_data.id_cards",
-					Message: fmt.Sprintf("第 %d 个身份证号 '%s' 长度无效，应为18位", i+1, idCardTrimmed),
-				})
-			} else {
-				// 验证前17位是否为数字，最后一位可以是数字或X
-				for j, char := range idCardTrimmed {
-					if j < 17 {
-						if char < '0' || char > '9' {
-							errors = append(errors, ValidationError{
-								Field:   "builtin_data.id_cards",
-								Message: fmt.Sprintf("第 %d 个身份证号 '%s' 前17位必须为数字", i+1, idCardTrimmed),
-							})
-							break
-						}
-					} else {
-						if char != 'X' && (char < '0' || char > '9') {
-							errors = append(errors, ValidationError{
-								Field:   "builtin_data.id_cards",
-								Message: fmt.Sprintf("第 %d 个身份证号 '%s' 最后一位必须为数字或X", i+1, idCardTrimmed),
-							})
-							break
-						}
-					}
-				}
-			}
+	// 验证银行卡号数据（可选，未配置时按约束生成）
+	for i, card := range data.BankCards {
+		cardTrimmed := strings.TrimSpace(card)
+		if cardTrimmed == "" {
+			errors = append(errors, ValidationError{
+				Field:   "builtin_data.bank_cards",
+				Message: fmt.Sprintf("第 %d 个银行卡号不能为空", i+1),
+			})
+		} else if len(cardTrimmed) < minBankCardLength || len(cardTrimmed) > maxBankCardLength {
+			errors = append(errors, ValidationError{
+				Field:   "builtin_data.bank_cards",
+				Message: fmt.Sprintf("第 %d 个银行卡号 '%s' 长度无效，应为%d-%d位数字", i+1, cardTrimmed, minBankCardLength, maxBankCardLength),
+			})
+		} else if strings.Trim(cardTrimmed, "0123456789") != "" {
+			errors = append(errors, ValidationError{
+				Field:   "builtin_data.bank_cards",
+				Message: fmt.Sprintf("第 %d 个银行卡号 '%s' 包含非数字字符", i+1, cardTrimmed),
+			})
 		}
 	}
 
+	// 验证身份证号数据（可选，未配置时按约束生成）
+	for i, idCard := range data.IDCards {
+		idCardTrimmed := strings.TrimSpace(idCard)
+		if idCardTrimmed == "" {
+			errors = append(errors, ValidationError{
+				Field:   "builtin_data.id_cards",
+				Message: fmt.Sprintf("第 %d 个身份证号不能为空", i+1),
+			})
+		} else if !idCardPattern.MatchString(idCardTrimmed) {
+			errors = append(errors, ValidationError{
+				Field:   "builtin_data.id_cards",
+				Message: fmt.Sprintf("第 %d 个身份证号 '%s' 格式无效，应为17位数字加1位数字或X", i+1, idCardTrimmed),
+			})
+		}
+	}
 	return errors
 }
 
-// LoadConstraintConfig 从TOML文件加载约束配置
+// LoadConstraintConfig 从TOML文件加载约束配置并设置为默认生成器的约束配置
 func LoadConstraintConfig(filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -578,7 +640,7 @@ func LoadConstraintConfig(filePath string) error {
 		return fmt.Errorf("约束配置验证失败: %w", err)
 	}
 
-	globalConstraintConfig = config
+	SetConstraintConfig(config)
 	return nil
 }
 
@@ -588,43 +650,66 @@ func LoadDefaultConstraints() error {
 	return LoadConstraintConfig("constraints.toml")
 }
 
-// FindFieldConstraint 根据字段名查找约束配置
+// FindFieldConstraint 根据字段名在默认生成器的约束配置中查找约束
 func FindFieldConstraint(fieldName string) *FieldConstraint {
-	if globalConstraintConfig == nil {
-		return nil
+	return defaultGenerator.FindFieldConstraint(fieldName)
+}
+
+// FindFieldConstraint 根据字段名查找约束配置
+func (g *Generator) FindFieldConstraint(fieldName string) *FieldConstraint {
+	_, constraint := g.findFieldConstraintEntry(fieldName)
+	return constraint
+}
+
+// findFieldConstraintEntry 根据字段名查找约束配置，同时返回匹配到的约束名称
+func (g *Generator) findFieldConstraintEntry(fieldName string) (string, *FieldConstraint) {
+	if g.Constraints == nil {
+		return "", nil
 	}
+	return lookupFieldConstraintEntry(g.Constraints.Constraints, fieldName)
+}
+
+// lookupFieldConstraint 在约束映射中查找字段约束
+// 依次尝试完整字段名、小写字段名、匹配完整路径的路径选择器（最具体的优先）、去掉路径前缀的简单字段名及其小写形式
+func lookupFieldConstraint(constraints map[string]FieldConstraint, fieldName string) *FieldConstraint {
+	_, constraint := lookupFieldConstraintEntry(constraints, fieldName)
+	return constraint
+}
 
-	// 直接匹配字段名
-	if constraint, exists := globalConstraintConfig.Constraints[fieldName]; exists {
-		return &constraint
+// lookupFieldConstraintEntry 在约束映射中查找字段约束，同时返回匹配到的约束名称
+func lookupFieldConstraintEntry(constraints map[string]FieldConstraint, fieldName string) (string, *FieldConstraint) {
+	// 直接匹配字段名，然后尝试小写匹配
+	for _, name := range []string{fieldName, strings.ToLower(fieldName)} {
+		if constraint, exists := constraints[name]; exists {
+			return name, &constraint
+		}
 	}
 
-	// 尝试小写匹配
-	lowerFieldName := strings.ToLower(fieldName)
-	if constraint, exists := globalConstraintConfig.Constraints[lowerFieldName]; exists {
-		return &constraint
+	// 尝试匹配完整路径的路径选择器，如 items[*].price、*.name
+	if name, constraint := lookupPathSelector(constraints, fieldName); constraint != nil {
+		return name, constraint
 	}
 
-	// 尝试匹配简单字段名（去掉路径前缀）
+	// 尝试匹配简单字段名（去掉路径前缀）及其小写形式
 	if strings.Contains(fieldName, ".") {
 		parts := strings.Split(fieldName, ".")
 		simpleFieldName := parts[len(parts)-1] // 取最后一部分
-		if constraint, exists := globalConstraintConfig.Constraints[simpleFieldName]; exists {
-			return &constraint
-		}
-
-		// 尝试简单字段名的小写匹配
-		lowerSimpleFieldName := strings.ToLower(simpleFieldName)
-		if constraint, exists := globalConstraintConfig.Constraints[lowerSimpleFieldName]; exists {
-			return &constraint
+		for _, name := range []string{simpleFieldName, strings.ToLower(simpleFieldName)} {
+			if constraint, exists := constraints[name]; exists {
+				return name, &constraint
+			}
 		}
 	}
+	return "", nil
+}
 
-	return nil
+// GenerateConstrainedValue 使用默认生成器根据约束生成值
+func GenerateConstrainedValue(constraint *FieldConstraint, originalValue any) any {
+	return defaultGenerator.GenerateConstrainedValue(constraint, originalValue)
 }
 
 // GenerateConstrainedValue 根据约束生成值
-func GenerateConstrainedValue(constraint *FieldConstraint, originalValue any) any {
+func (g *Generator) GenerateConstrainedValue(constraint *FieldConstraint, originalValue any) any {
 	if constraint == nil {
 		return originalValue
 	}
@@ -638,32 +723,45 @@ func GenerateConstrainedValue(constraint *FieldConstraint, originalValue any) an
 	case "keep_original":
 		return originalValue
 	case "date":
-		return generateDateValue(constraint)
+		return g.generateDateValue(constraint)
 	case "datetime":
-		return generateDatetimeValue(constraint)
+		return g.generateDatetimeValue(constraint)
 	case "chinese_name":
-		return generateChineseName()
+		return g.generateChineseName()
 	case "phone":
-		return generatePhoneNumber()
+		return g.generatePhoneNumber()
 	case "email":
-		return generateEmail()
+		return g.generateEmail()
 	case "chinese_address":
-		return generateChineseAddress()
+		return g.generateChineseAddress()
 	case "id_card":
-		return generateIDCard()
+		if idCards := g.builtinCardNumbers(constraint); len(idCards) > 0 {
+			return idCards[g.rng.Intn(len(idCards))]
+		}
+		return g.generateIDCard(constraint)
 	case "bank_card":
-		return generateBankCard()
+		if bankCards := g.builtinCardNumbers(constraint); len(bankCards) > 0 {
+			return bankCards[g.rng.Intn(len(bankCards))]
+		}
+		return g.generateBankCard(constraint)
 	case "integer":
-		return generateIntegerValue(constraint)
+		return g.generateIntegerValue(constraint)
 	case "float":
-		return generateFloatValue(constraint)
+		return g.generateFloatValue(constraint)
+	case "pattern":
+		return g.generatePatternValue(constraint, originalValue)
+	case "enum":
+		return g.generateEnumValue(constraint, originalValue)
+	case "sequence":
+		// 未指定字段名时所有序列共用一个计数器
+		return g.nextSequenceValue("", constraint, originalValue)
 	default:
 		return originalValue
 	}
 }
 
 // generateDateValue 生成日期值
-func generateDateValue(constraint *FieldConstraint) string {
+func (g *Generator) generateDateValue(constraint *FieldConstraint) string {
 	format := constraint.Format
 	if format == "" {
 		format = "20060102" // 默认格式
@@ -687,14 +785,14 @@ func generateDateValue(constraint *FieldConstraint) string {
 
 	// 生成随机日期
 	duration := maxDate.Sub(minDate)
-	randomDuration := time.Duration(rand.Int63n(int64(duration)))
+	randomDuration := time.Duration(g.rng.Int63n(int64(duration)))
 	randomDate := minDate.Add(randomDuration)
 
 	return randomDate.Format(format)
 }
 
 // generateDatetimeValue 生成RFC 3339 Extended格式的日期时间值
-func generateDatetimeValue(constraint *FieldConstraint) string {
+func (g *Generator) generateDatetimeValue(constraint *FieldConstraint) string {
 	// 设置默认日期时间范围（包含时分秒）
 	minDatetime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 	maxDatetime := time.Date(2030, 12, 31, 23, 59, 59, 999000000, time.UTC)
@@ -720,71 +818,41 @@ func generateDatetimeValue(constraint *FieldConstraint) string {
 
 	// 如果最大日期时间小于或等于最小日期时间，返回最小日期时间
 	if maxDatetime.Before(minDatetime) || maxDatetime.Equal(minDatetime) {
-		// 处理时区
-		var targetTimezone *time.Location
-		if constraint.Timezone != "" {
-			if constraint.Timezone == "UTC" {
-				targetTimezone = time.UTC
-			} else if strings.HasPrefix(constraint.Timezone, "+") || strings.HasPrefix(constraint.Timezone, "-") {
-				// 解析偏移量格式（如：+08:00, -05:00）
-				if offset, err := parseTimezoneOffset(constraint.Timezone); err == nil {
-					targetTimezone = time.FixedZone("Custom", offset)
-				} else {
-					targetTimezone = time.UTC // 默认使用UTC
-				}
-			} else {
-				// IANA时区名称
-				if loc, err := time.LoadLocation(constraint.Timezone); err == nil {
-					targetTimezone = loc
-				} else {
-					targetTimezone = time.UTC // 默认使用UTC
-				}
-			}
-		} else {
-			// 默认使用UTC
-			targetTimezone = time.UTC
-		}
 		// 转换到目标时区并返回
-		return minDatetime.In(targetTimezone).Format("2006-01-02T15:04:05.000Z07:00")
+		return minDatetime.In(constraintLocation(constraint)).Format("2006-01-02T15:04:05.000Z07:00")
 	}
 
 	// 生成随机日期时间（精确到毫秒）
 	diff := maxDatetime.UnixNano() - minDatetime.UnixNano()
-	randomNanos := rand.Int63n(diff + 1)
+	randomNanos := g.rng.Int63n(diff + 1)
 	randomDatetime := time.Unix(0, minDatetime.UnixNano()+randomNanos)
 
-	// 处理时区
-	var targetTimezone *time.Location
-	if constraint.Timezone != "" {
-		if constraint.Timezone == "UTC" {
-			targetTimezone = time.UTC
-		} else if strings.HasPrefix(constraint.Timezone, "+") || strings.HasPrefix(constraint.Timezone, "-") {
-			// 解析偏移量格式（如：+08:00, -05:00）
-			if offset, err := parseTimezoneOffset(constraint.Timezone); err == nil {
-				targetTimezone = time.FixedZone("Custom", offset)
-			} else {
-				targetTimezone = time.UTC // 默认使用UTC
-			}
-		} else {
-			// IANA时区名称
-			if loc, err := time.LoadLocation(constraint.Timezone); err == nil {
-				targetTimezone = loc
-			} else {
-				targetTimezone = time.UTC // 默认使用UTC
-			}
-		}
-	} else {
-		// 默认使用UTC
-		targetTimezone = time.UTC
-	}
-
 	// 转换到目标时区
-	randomDatetime = randomDatetime.In(targetTimezone)
+	randomDatetime = randomDatetime.In(constraintLocation(constraint))
 
 	// 返回RFC 3339 Extended格式
 	return randomDatetime.Format("2006-01-02T15:04:05.000Z07:00")
 }
 
+// constraintLocation 返回约束指定的时区，未指定或无法解析时使用UTC
+func constraintLocation(constraint *FieldConstraint) *time.Location {
+	switch {
+	case constraint.Timezone == "" || constraint.Timezone == "UTC":
+		return time.UTC
+	case strings.HasPrefix(constraint.Timezone, "+") || strings.HasPrefix(constraint.Timezone, "-"):
+		// 解析偏移量格式（如：+08:00, -05:00）
+		if offset, err := parseTimezoneOffset(constraint.Timezone); err == nil {
+			return time.FixedZone("Custom", offset)
+		}
+	default:
+		// IANA时区名称
+		if loc, err := time.LoadLocation(constraint.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
 // ValidateTimezone 验证时区格式
 func ValidateTimezone(timezone string) error {
 	if timezone == "" {
@@ -957,60 +1025,56 @@ func parseTimezoneOffset(offset string) (int, error) {
 }
 
 // generateChineseName 生成中文姓名
-func generateChineseName() string {
-	if globalConstraintConfig == nil || len(globalConstraintConfig.BuiltinData.FirstNames) == 0 {
+func (g *Generator) generateChineseName() string {
+	if g.Constraints == nil || len(g.Constraints.BuiltinData.FirstNames) == 0 {
 		// 默认姓名
 		defaultFirstNames := []string{"张", "王", "李", "赵", "刘"}
 		defaultLastNames := []string{"伟", "芳", "娜", "敏", "静"}
-		firstName := defaultFirstNames[rand.Intn(len(defaultFirstNames))]
-		lastName := defaultLastNames[rand.Intn(len(defaultLastNames))]
+		firstName := defaultFirstNames[g.rng.Intn(len(defaultFirstNames))]
+		lastName := defaultLastNames[g.rng.Intn(len(defaultLastNames))]
 		return firstName + lastName
 	}
 
-	firstName := globalConstraintConfig.BuiltinData.FirstNames[rand.Intn(len(globalConstraintConfig.BuiltinData.FirstNames))]
-	lastName := globalConstraintConfig.BuiltinData.LastNames[rand.Intn(len(globalConstraintConfig.BuiltinData.LastNames))]
+	firstName := g.Constraints.BuiltinData.FirstNames[g.rng.Intn(len(g.Constraints.BuiltinData.FirstNames))]
+	lastName := g.Constraints.BuiltinData.LastNames[g.rng.Intn(len(g.Constraints.BuiltinData.LastNames))]
 	return firstName + lastName
 }
 
 // generatePhoneNumber 生成手机号
-func generatePhoneNumber() string {
-	// 默认手机号数据集
-	defaultPhoneNumbers := []string{
-		"13800138000", "13900139000", "15000150000", "15100151000", "15200152000",
-		"15300153000", "15500155000", "15600156000", "15700157000", "15800158000",
-		"15900159000", "17000170000", "17100171000", "17200172000", "17300173000",
-		"17500175000", "17600176000", "17700177000", "17800178000", "17900179000",
-		"18000180000", "18100181000", "18200182000", "18300183000", "18500185000",
-		"18600186000", "18700187000", "18800188000", "18900189000", "19000190000",
+// 配置了内置手机号数据集时从中选择，否则使用常见号段加8位随机数字
+func (g *Generator) generatePhoneNumber() string {
+	if g.Constraints != nil && len(g.Constraints.BuiltinData.PhoneNumbers) > 0 {
+		phoneNumbers := g.Constraints.BuiltinData.PhoneNumbers
+		return phoneNumbers[g.rng.Intn(len(phoneNumbers))]
 	}
 
-	phoneNumbers := defaultPhoneNumbers
-	if globalConstraintConfig != nil && len(globalConstraintConfig.BuiltinData.PhoneNumbers) > 0 {
-		phoneNumbers = globalConstraintConfig.BuiltinData.PhoneNumbers
+	// 默认号段
+	prefixes := []string{
+		"138", "139", "150", "151", "152", "153", "155", "156", "157", "158",
+		"159", "170", "171", "172", "173", "175", "176", "177", "178", "179",
+		"180", "181", "182", "183", "185", "186", "187", "188", "189", "190",
 	}
-
-	// 随机选择一个手机号
-	return phoneNumbers[rand.Intn(len(phoneNumbers))]
+	return fmt.Sprintf("%s%08d", prefixes[g.rng.Intn(len(prefixes))], g.rng.Intn(100000000))
 }
 
 // generateEmail 生成邮箱地址
-func generateEmail() string {
+func (g *Generator) generateEmail() string {
 	domains := []string{"qq.com", "163.com", "126.com", "gmail.com", "sina.com"}
-	if globalConstraintConfig != nil && len(globalConstraintConfig.BuiltinData.EmailDomains) > 0 {
-		domains = globalConstraintConfig.BuiltinData.EmailDomains
+	if g.Constraints != nil && len(g.Constraints.BuiltinData.EmailDomains) > 0 {
+		domains = g.Constraints.BuiltinData.EmailDomains
 	}
 
 	// 生成用户名部分
 	usernames := []string{"user", "demo", "test", "admin", "guest"}
-	username := usernames[rand.Intn(len(usernames))]
-	number := rand.Intn(1000)
-	domain := domains[rand.Intn(len(domains))]
+	username := usernames[g.rng.Intn(len(usernames))]
+	number := g.rng.Intn(1000)
+	domain := domains[g.rng.Intn(len(domains))]
 
 	return fmt.Sprintf("%s%d@%s", username, number, domain)
 }
 
 // generateChineseAddress 生成中文地址
-func generateChineseAddress() string {
+func (g *Generator) generateChineseAddress() string {
 	defaultAddresses := []string{
 		"北京市朝阳区建国门外大街1号",
 		"上海市浦东新区陆家嘴环路1000号",
@@ -1020,38 +1084,15 @@ func generateChineseAddress() string {
 	}
 
 	addresses := defaultAddresses
-	if globalConstraintConfig != nil && len(globalConstraintConfig.BuiltinData.Addresses) > 0 {
-		addresses = globalConstraintConfig.BuiltinData.Addresses
-	}
-
-	return addresses[rand.Intn(len(addresses))]
-}
-
-// generateIDCard 生成身份证号
-func generateIDCard() string {
-	// 默认身份证号数据集
-	defaultIDCards := []string{
-		"110101199001011234", "110101199002021235", "110101199003031236", "110101199004041237", "110101199005051238",
-		"310101199101011239", "310101199102021240", "310101199103031241", "310101199104041242", "310101199105051243",
-		"440101199201011244", "440101199202021245", "440101199203031246", "440101199204041247", "440101199205051248",
-		"500101199301011249", "500101199302021250", "500101199303031251", "500101199304041252", "500101199305051253",
-		"510101199401011254", "510101199402021255", "510101199403031256", "510101199404041257", "510101199405051258",
-		"320101199501011259", "320101199502021260", "320101199503031261", "320101199504041262", "320101199505051263",
-		"330101199601011264", "330101199602021265", "330101199603031266", "330101199604041267", "330101199605051268",
-		"420101199701011269", "420101199702021270", "420101199703031271", "420101199704041272", "420101199705051273",
-	}
-
-	idCards := defaultIDCards
-	if globalConstraintConfig != nil && len(globalConstraintConfig.BuiltinData.IDCards) > 0 {
-		idCards = globalConstraintConfig.BuiltinData.IDCards
+	if g.Constraints != nil && len(g.Constraints.BuiltinData.Addresses) > 0 {
+		addresses = g.Constraints.BuiltinData.Addresses
 	}
 
-	// 随机选择一个身份证号
-	return idCards[rand.Intn(len(idCards))]
+	return addresses[g.rng.Intn(len(addresses))]
 }
 
 // generateIntegerValue 生成整数值
-func generateIntegerValue(constraint *FieldConstraint) int {
+func (g *Generator) generateIntegerValue(constraint *FieldConstraint) int {
 	min := 1
 	max := 100
 
@@ -1073,11 +1114,11 @@ func generateIntegerValue(constraint *FieldConstraint) int {
 	}
 
 	// 生成min到max之间的随机整数（包含边界）
-	return min + rand.Intn(max-min+1)
+	return min + g.rng.Intn(max-min+1)
 }
 
 // generateFloatValue 生成浮点数值
-func generateFloatValue(constraint *FieldConstraint) float64 {
+func (g *Generator) generateFloatValue(constraint *FieldConstraint) float64 {
 	min := 0.01
 	max := 999.99
 	precision := 2
@@ -1103,11 +1144,100 @@ func generateFloatValue(constraint *FieldConstraint) float64 {
 	}
 
 	// 生成随机浮点数
-	value := min + rand.Float64()*(max-min)
+	value := min + g.rng.Float64()*(max-min)
 
 	return applyFloatPrecision(value, precision)
 }
 
+// generateEnumValue 按权重从候选值中选择一个值，并保持正例字段的类型（数值或字符串）
+func (g *Generator) generateEnumValue(constraint *FieldConstraint, originalValue any) any {
+	if len(constraint.Values) == 0 {
+		return originalValue
+	}
+
+	value := constraint.Values[g.weightedIndex(len(constraint.Values), constraint.Weights)]
+	return preserveValueType(normalizeConstraintValue(value), originalValue)
+}
+
+// weightedIndex 按权重随机选择下标，权重个数不一致或权重之和不大于0时等概率选择
+func (g *Generator) weightedIndex(n int, weights []float64) int {
+	total := 0.0
+	if len(weights) == n {
+		for _, weight := range weights {
+			total += max(weight, 0)
+		}
+	}
+	if total <= 0 {
+		return g.rng.Intn(n)
+	}
+
+	target := g.rng.Float64() * total
+	for i, weight := range weights {
+		target -= max(weight, 0)
+		if target < 0 {
+			return i
+		}
+	}
+	return n - 1
+}
+
+// preserveValueType 将候选值转换为与正例字段相同的类型
+// 正例为字符串时输出字符串，正例为数值时将数字字符串转换为数值，TOML整数转换为int
+func preserveValueType(value, original any) any {
+	switch original.(type) {
+	case string:
+		switch v := value.(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Sprint(v)
+		}
+	case int, int64, float64:
+		if str, ok := value.(string); ok {
+			if intVal, err := strconv.Atoi(str); err == nil {
+				return intVal
+			}
+			if floatVal, err := strconv.ParseFloat(str, 64); err == nil {
+				return floatVal
+			}
+		}
+	}
+	if intVal, ok := value.(int64); ok {
+		return int(intVal)
+	}
+	return value
+}
+
+// normalizeConstraintValue 将候选值中的TOML日期时间转换为字符串，其他值原样返回
+// 带时区的日期时间使用RFC 3339格式，本地日期、时间和日期时间保持TOML中的写法（如 2024-01-02）
+func normalizeConstraintValue(value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case toml.LocalDate:
+		return v.String()
+	case toml.LocalDateTime:
+		return v.String()
+	case toml.LocalTime:
+		return v.String()
+	}
+	return value
+}
+
+// normalizeConstraintValues 返回将日期时间转换为字符串后的候选值列表
+func normalizeConstraintValues(values []any) []any {
+	if values == nil {
+		return nil
+	}
+	normalized := make([]any, len(values))
+	for i, value := range values {
+		normalized[i] = normalizeConstraintValue(value)
+	}
+	return normalized
+}
+
 // applyFloatPrecision 应用浮点数精度
 func applyFloatPrecision(value float64, precision int) float64 {
 	// 应用精度
@@ -1119,38 +1249,3 @@ func applyFloatPrecision(value float64, precision int) float64 {
 	// 四舍五入到指定精度
 	return float64(int(value*multiplier+0.5)) / multiplier
 }
-
-// generateBankCard 生成银行卡号
-func generateBankCard() string {
-	// 默认银行卡号数据集
-	defaultBankCards := []string{
-		"6222021234567890", // 工商银行
-		"6227001234567896", // 建设银行
-		"6228481234567893", // 农业银行
-		"6217851234567899", // 中国银行
-		"6225881234567892", // 交通银行
-		"6221551234567897", // 招商银行
-		"6222601234567891", // 兴业银行
-		"6225211234567898", // 浦发银行
-		"6225811234567895", // 邮储银行
-		"6217771234567894", // 广发银行
-		"6226661234567890", // 民生银行
-		"6223181234567896", // 平安银行
-		"6223231234567893", // 中信银行
-		"6226001234567899", // 华夏银行
-		"6222621234567892", // 光大银行
-	}
-
-	bankCards := defaultBankCards
-	if globalConstraintConfig != nil && len(globalConstraintConfig.BuiltinData.BankCards) > 0 {
-		bankCards = globalConstraintConfig.BuiltinData.BankCards
-	}
-
-	// 随机选择一个银行卡号
-	return bankCards[rand.Intn(len(bankCards))]
-}
-
-// init 初始化随机数种子
-func init() {
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-}
@@ -2,7 +2,12 @@ package utils
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
 )
 
 // TestValidateTimezone 测试时区验证功能
@@ -263,3 +268,127 @@ func TestBankCardConstraintValidation(t *testing.T) {
 		t.Errorf("有效的银行卡约束验证失败: %v", errors)
 	}
 }
+
+// TestEnumConstraintValidation 测试枚举约束的候选值和权重验证
+func TestEnumConstraintValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint FieldConstraint
+		errCount   int
+	}{
+		{name: "字符串候选值", constraint: FieldConstraint{Type: "enum", Values: []any{"CNY", "USD"}}},
+		{name: "带权重的整数候选值", constraint: FieldConstraint{Type: "enum", Values: []any{int64(0), int64(1)}, Weights: []float64{9, 1}}},
+		{name: "日期时间候选值", constraint: FieldConstraint{Type: "enum", Values: []any{
+			toml.LocalDate{Year: 2024, Month: 1, Day: 2},
+			toml.LocalDateTime{LocalDate: toml.LocalDate{Year: 2024, Month: 1, Day: 2}, LocalTime: toml.LocalTime{Hour: 8}},
+			toml.LocalTime{Hour: 8, Minute: 30},
+			time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC),
+		}}},
+		{name: "缺少候选值", constraint: FieldConstraint{Type: "enum"}, errCount: 1},
+		{name: "候选值不是标量", constraint: FieldConstraint{Type: "enum", Values: []any{[]any{"a"}}}, errCount: 1},
+		{name: "权重个数不一致", constraint: FieldConstraint{Type: "enum", Values: []any{"a", "b"}, Weights: []float64{1}}, errCount: 1},
+		{name: "负权重且权重之和为0", constraint: FieldConstraint{Type: "enum", Values: []any{"a", "b"}, Weights: []float64{-1, 1}}, errCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := validateFieldConstraint("status", tt.constraint); len(errs) != tt.errCount {
+				t.Errorf("期望 %d 个错误，实际: %v", tt.errCount, errs)
+			}
+		})
+	}
+}
+
+// TestGenerateEnumValue 测试枚举值按权重选择并保持正例字段的类型
+func TestGenerateEnumValue(t *testing.T) {
+	g := NewGenerator(nil, DefaultVariationRate, 1)
+
+	weighted := &FieldConstraint{Type: "enum", Values: []any{"WEB", "APP", "H5"}, Weights: []float64{0, 3, 1}}
+	counts := make(map[any]int)
+	for i := 0; i < 1000; i++ {
+		value := g.GenerateConstrainedValue(weighted, "WEB")
+		if err := CheckConstrainedValue(weighted, value); err != nil {
+			t.Fatalf("生成的值 %v 不满足约束: %v", value, err)
+		}
+		counts[value]++
+	}
+	if counts["WEB"] != 0 || counts["APP"] < 650 || counts["H5"] < 150 {
+		t.Errorf("按权重选择的分布错误: %v", counts)
+	}
+
+	tests := []struct {
+		name     string
+		values   []any
+		original any
+		want     any
+	}{
+		{name: "TOML整数输出为JSON整数", values: []any{int64(2)}, original: 1, want: 2},
+		{name: "正例为字符串时输出字符串", values: []any{int64(2)}, original: "1", want: "2"},
+		{name: "正例为数值时数字字符串输出数值", values: []any{"2"}, original: 1, want: 2},
+		{name: "浮点数", values: []any{1.5}, original: "0.5", want: "1.5"},
+		{name: "布尔值", values: []any{true}, original: false, want: true},
+		{name: "本地日期输出字符串", values: []any{toml.LocalDate{Year: 2024, Month: 1, Day: 2}}, original: "2023-12-31", want: "2024-01-02"},
+		{name: "本地时间输出字符串", values: []any{toml.LocalTime{Hour: 8, Minute: 30}}, original: "09:00:00", want: "08:30:00"},
+		{name: "带时区的日期时间输出RFC3339", values: []any{time.Date(2024, 1, 2, 8, 0, 0, 0, time.FixedZone("", 8*3600))}, original: "", want: "2024-01-02T08:00:00+08:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint := &FieldConstraint{Type: "enum", Values: tt.values}
+			if got := g.GenerateConstrainedValue(constraint, tt.original); got != tt.want {
+				t.Errorf("期望 %#v，实际 %#v", tt.want, got)
+			}
+		})
+	}
+
+	enum := &FieldConstraint{Type: "enum", Values: []any{int64(0), int64(1)}}
+	if CheckConstrainedValue(enum, "1") != nil || CheckConstrainedValue(enum, 1.0) != nil || CheckConstrainedValue(enum, 2) == nil {
+		t.Error("枚举校验应按数值比较且拒绝不在候选值中的值")
+	}
+}
+
+// TestLoadEnumDateValues 测试配置文件中TOML日期时间类型的候选值按字符串加载和校验
+func TestLoadEnumDateValues(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	content := `[constraints.biz_date]
+type = "enum"
+values = [2024-01-02, 2024-01-03]
+
+[constraints.cutoff]
+type = "enum"
+values = [08:30:00, 2024-01-02T08:30:00, 2024-01-02T08:30:00+08:00]
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	for name, constraint := range config.Constraints.Constraints {
+		if errs := validateFieldConstraint(name, constraint); len(errs) > 0 {
+			t.Fatalf("日期时间候选值应通过验证: %v", errs)
+		}
+	}
+
+	want := map[string][]any{
+		"biz_date": {"2024-01-02", "2024-01-03"},
+		"cutoff":   {"08:30:00", "2024-01-02T08:30:00", "2024-01-02T08:30:00+08:00"},
+	}
+	for name, values := range want {
+		got := config.Constraints.Constraints[name].Values
+		if len(got) != len(values) {
+			t.Fatalf("约束 %s 的候选值 %#v，期望 %#v", name, got, values)
+		}
+		for i := range values {
+			if got[i] != values[i] {
+				t.Errorf("约束 %s 的候选值 %#v，期望 %#v", name, got, values)
+			}
+		}
+	}
+
+	constraint := config.Constraints.Constraints["biz_date"]
+	if CheckConstrainedValue(&constraint, "2024-01-03") != nil || CheckConstrainedValue(&constraint, "2024-01-04") == nil {
+		t.Error("日期候选值应按字符串校验")
+	}
+}
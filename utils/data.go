@@ -7,38 +7,30 @@ import (
 	"encoding/xml"
 	"fmt"
 	"math"
-	"math/rand"
 	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 )
 
-// 保存原始字段顺序和类型信息
-var originalKeyOrder []string
-var originalValueTypes map[string]string
-var originalRootElementName string
-var originalHasXMLDeclaration bool
-var originalXMLDeclaration string
-
-func init() {
-	// 初始化随机数生成器
-	rand.New(rand.NewSource(time.Now().UnixNano()))
+// ParseXML 使用默认生成器解析XML字符串为map[string]any
+func ParseXML(xmlStr string) (map[string]any, error) {
+	return defaultGenerator.ParseXML(xmlStr)
 }
 
-// ParseXML 解析XML字符串为map[string]any
-func ParseXML(xmlStr string) (map[string]any, error) {
+// ParseXML 解析XML字符串为map[string]any，并记录字段顺序、根元素名称和XML声明
+func (g *Generator) ParseXML(xmlStr string) (map[string]any, error) {
 	// 检测原始XML是否包含XML声明
-	originalHasXMLDeclaration = strings.Contains(xmlStr, "<?xml")
+	var schema Schema
+	schema.HasXMLDeclaration = strings.Contains(xmlStr, "<?xml")
 
 	// 保存原始的XML声明
-	if originalHasXMLDeclaration {
+	if schema.HasXMLDeclaration {
 		xmlDeclRegex := regexp.MustCompile(`<\?xml[^>]*\?>`)
 		matches := xmlDeclRegex.FindString(xmlStr)
 		if matches != "" {
-			originalXMLDeclaration = matches
+			schema.XMLDeclaration = matches
 		}
 	}
 
@@ -67,13 +59,13 @@ func ParseXML(xmlStr string) (map[string]any, error) {
 	rootRegex := regexp.MustCompile(`<\?xml[^>]*>\s*<([^\s>/]+)[^>]*>`)
 	matches := rootRegex.FindStringSubmatch(xmlStr)
 	if len(matches) > 1 {
-		originalRootElementName = matches[1]
+		schema.RootElementName = matches[1]
 	} else {
 		// 如果没有XML声明，直接查找第一个元素
 		simpleRegex := regexp.MustCompile(`<([^\s>/!?]+)[^>]*>`)
 		simpleMatches := simpleRegex.FindStringSubmatch(xmlStr)
 		if len(simpleMatches) > 1 && simpleMatches[1] != "?xml" {
-			originalRootElementName = simpleMatches[1]
+			schema.RootElementName = simpleMatches[1]
 		}
 	}
 
@@ -81,15 +73,12 @@ func ParseXML(xmlStr string) (map[string]any, error) {
 	// 由于XML解析过程中字段顺序可能已经丢失，我们尝试从原始XML字符串中提取
 	keys := extractXMLKeys(xmlStr)
 	if len(keys) > 0 {
-		originalKeyOrder = keys
+		schema.KeyOrder = keys
 	} else {
 		// 如果无法从原始字符串提取，则使用解析后的结果的键
-		keys = make([]string, 0, len(result))
-		for key := range result {
-			keys = append(keys, key)
-		}
-		originalKeyOrder = keys
+		schema.KeyOrder = sortedKeys(result)
 	}
+	g.Schema = schema
 
 	// 如果结果中有根元素，提取其内容作为实际数据
 	for _, rootValue := range result {
@@ -537,8 +526,13 @@ func convertNumbers(data map[string]any) map[string]any {
 	return result
 }
 
-// ParseJSON 解析JSON字符串并保留字段顺序
+// ParseJSON 使用默认生成器解析JSON字符串并保留字段顺序
 func ParseJSON(jsonStr string) (map[string]any, error) {
+	return defaultGenerator.ParseJSON(jsonStr)
+}
+
+// ParseJSON 解析JSON字符串，并记录字段顺序
+func (g *Generator) ParseJSON(jsonStr string) (map[string]any, error) {
 	// 创建一个空接口来存储解析结果
 	var result map[string]any
 
@@ -555,11 +549,7 @@ func ParseJSON(jsonStr string) (map[string]any, error) {
 	// 记录字段顺序
 	// 由于Go的标准json包不保留字段顺序，我们需要手动提取顺序
 	// 通过简单的字符串处理来提取字段顺序
-	keys := extractJSONKeys(jsonStr)
-	if len(keys) > 0 {
-		// 确保字段顺序正确
-		originalKeyOrder = keys
-	}
+	g.Schema = Schema{KeyOrder: extractJSONKeys(jsonStr)}
 
 	return result, nil
 }
@@ -632,20 +622,26 @@ func extractJSONKeys(jsonStr string) []string {
 	return keys
 }
 
-// GenerateTestCasesWithVariationRate 生成测试用例（支持自定义随机化因子）
+// GenerateTestCasesWithVariationRate 使用默认生成器生成测试用例（支持自定义随机化因子）
 func GenerateTestCasesWithVariationRate(data map[string]any, count int, variationRate float64, useConstraints bool) []map[string]any {
+	return defaultGenerator.generateTestCases(data, count, variationRate, useConstraints)
+}
+
+// GenerateTestCases 按生成器的随机化因子生成随机变化的测试用例，配置了约束时按约束生成字段值
+func (g *Generator) GenerateTestCases(data map[string]any, count int) []map[string]any {
+	return g.generateTestCases(data, count, g.VariationRate, g.Constraints != nil)
+}
+
+// generateTestCases 生成测试用例（支持自定义随机化因子）
+func (g *Generator) generateTestCases(data map[string]any, count int, variationRate float64, useConstraints bool) []map[string]any {
 	testCases := make([]map[string]any, count)
 
 	// 使用已经保存的原始字段顺序
-	keys := originalKeyOrder
+	keys := g.Schema.KeyOrder
 	if len(keys) == 0 {
-		// 如果没有保存的顺序，则从数据中提取（无序）
-		keys = make([]string, 0, len(data))
-		for key := range data {
-			keys = append(keys, key)
-		}
-		// 更新全局变量
-		originalKeyOrder = keys
+		// 如果没有保存的顺序，则按字段名排序
+		keys = sortedKeys(data)
+		g.Schema.KeyOrder = keys
 	}
 
 	// 记录原始数据类型
@@ -698,23 +694,27 @@ func GenerateTestCasesWithVariationRate(data map[string]any, count int, variatio
 		for _, key := range keys {
 			if useConstraints {
 				// 使用带约束的变化生成
-				testCase[key] = generateVariationWithConstraints(data[key], key, variationRate)
+				testCase[key] = g.generateVariationWithConstraints(data[key], key, variationRate)
 			} else {
 				// 不使用约束，使用原始变化逻辑
-				testCase[key] = generateVariation(data[key], variationRate)
+				testCase[key] = g.generateVariation(data[key], variationRate)
 			}
 		}
+		if useConstraints && g.Constraints != nil {
+			// 所有字段生成后，按依赖顺序计算依赖其他字段的字段值
+			g.applyDependentConstraints(testCase)
+		}
 		testCases[i] = testCase
 	}
 
-	// 保存类型信息到全局变量（字段顺序已在ParseJSON中设置）
-	originalValueTypes = types
+	// 保存类型信息（字段顺序已在ParseJSON中设置）
+	g.Schema.ValueTypes = types
 
 	return testCases
 }
 
 // generateVariation 根据原始值生成变化值
-func generateVariation(value any, variationRate float64) any {
+func (g *Generator) generateVariation(value any, variationRate float64) any {
 	// 根据值的类型进行不同处理
 	switch v := value.(type) {
 	case int, int8, int16, int32, int64:
@@ -725,7 +725,7 @@ func generateVariation(value any, variationRate float64) any {
 			variation = 1 // 至少有1的变化
 		}
 		// 生成随机变化值，确保结果仍然是整数
-		newVal := intVal + rand.Int63n(2*variation+1) - variation
+		newVal := intVal + g.rng.Int63n(2*variation+1) - variation
 
 		// 根据原始类型返回相应的整数类型
 		switch v.(type) {
@@ -747,7 +747,7 @@ func generateVariation(value any, variationRate float64) any {
 		// 浮点数类型，上下浮动指定比例，保持原始精度
 		floatVal := reflect.ValueOf(v).Float()
 		variation := floatVal * variationRate
-		newVal := floatVal + (rand.Float64()*2-1)*variation
+		newVal := floatVal + (g.rng.Float64()*2-1)*variation
 
 		// 保持原始浮点数的精度
 		origStr := fmt.Sprintf("%v", v)
@@ -786,20 +786,20 @@ func generateVariation(value any, variationRate float64) any {
 			parts := strings.Split(v, ",")
 			for i := range parts {
 				// 随机修改数组中的一些元素
-				if rand.Float64() < 0.5 {
-					parts[i] = randomizeString(parts[i])
+				if g.rng.Float64() < 0.5 {
+					parts[i] = g.randomizeString(parts[i])
 				}
 			}
 			return strings.Join(parts, ",")
 		} else if intVal, err := strconv.ParseInt(v, 10, 64); err == nil {
 			// 是整数字符串
 			variation := int64(float64(intVal) * variationRate)
-			newVal := intVal + rand.Int63n(2*variation+1) - variation
+			newVal := intVal + g.rng.Int63n(2*variation+1) - variation
 			return strconv.FormatInt(newVal, 10)
 		} else if floatVal, err := strconv.ParseFloat(v, 64); err == nil {
 			// 是浮点数字符串
 			variation := floatVal * variationRate
-			newVal := floatVal + (rand.Float64()*2-1)*variation
+			newVal := floatVal + (g.rng.Float64()*2-1)*variation
 
 			// 保持原始浮点数字符串的精度
 			decimalPlaces := 0
@@ -811,12 +811,12 @@ func generateVariation(value any, variationRate float64) any {
 			return strconv.FormatFloat(newVal, 'f', decimalPlaces, 64)
 		} else {
 			// 普通字符串，随机修改部分字符
-			return randomizeString(v)
+			return g.randomizeString(v)
 		}
 
 	case bool:
 		// 布尔值，有一定概率翻转
-		if rand.Float64() < 0.5 {
+		if g.rng.Float64() < 0.5 {
 			return !v
 		}
 		return v
@@ -825,15 +825,15 @@ func generateVariation(value any, variationRate float64) any {
 		// 数组，递归处理每个元素
 		result := make([]any, len(v))
 		for i, item := range v {
-			result[i] = generateVariation(item, variationRate)
+			result[i] = g.generateVariation(item, variationRate)
 		}
 		return result
 
 	case map[string]any:
-		// 对象，递归处理每个属性
+		// 对象，按字段名顺序递归处理每个属性，保证相同种子生成相同结果
 		result := make(map[string]any)
-		for key, item := range v {
-			result[key] = generateVariation(item, variationRate)
+		for _, key := range sortedKeys(v) {
+			result[key] = g.generateVariation(v[key], variationRate)
 		}
 		return result
 
@@ -844,26 +844,27 @@ func generateVariation(value any, variationRate float64) any {
 }
 
 // generateVariationWithConstraints 根据约束生成变化值
-func generateVariationWithConstraints(value any, fieldName string, variationRate float64) any {
+func (g *Generator) generateVariationWithConstraints(value any, fieldName string, variationRate float64) any {
 	// 处理嵌套结构
 	switch v := value.(type) {
 	case map[string]any:
 		// 对象，先检查是否有针对整个对象的约束
-		if constraint := FindFieldConstraint(fieldName); constraint != nil {
+		if name, constraint := g.findFieldConstraintEntry(fieldName); constraint != nil {
 			// 如果是keep_original约束，需要特殊处理：保持原值但允许子字段覆盖
 			if constraint.Type == "keep_original" || (constraint.KeepOriginal != nil && *constraint.KeepOriginal) {
 				// 递归处理每个属性，子字段的约束优先
 				result := make(map[string]any)
-				for key, item := range v {
+				for _, key := range sortedKeys(v) {
+					item := v[key]
 					// 构建嵌套字段名
 					nestedFieldName := fieldName + "." + key
 					// 检查子字段是否有单独的约束
-					if childConstraint := FindFieldConstraint(nestedFieldName); childConstraint != nil {
+					if childConstraint := g.FindFieldConstraint(nestedFieldName); childConstraint != nil {
 						// 子字段有约束，使用子字段约束
-						result[key] = generateVariationWithConstraints(item, nestedFieldName, variationRate)
-					} else if childConstraint := FindFieldConstraint(key); childConstraint != nil {
+						result[key] = g.generateVariationWithConstraints(item, nestedFieldName, variationRate)
+					} else if childConstraint := g.FindFieldConstraint(key); childConstraint != nil {
 						// 检查是否有直接字段名的约束
-						result[key] = generateVariationWithConstraints(item, key, variationRate)
+						result[key] = g.generateVariationWithConstraints(item, key, variationRate)
 					} else {
 						// 子字段没有约束，保持原值
 						result[key] = item
@@ -872,15 +873,16 @@ func generateVariationWithConstraints(value any, fieldName string, variationRate
 				return result
 			} else {
 				// 其他类型的约束，直接应用
-				return GenerateConstrainedValue(constraint, value)
+				return g.generateFieldValue(name, constraint, value)
 			}
 		} else {
 			// 没有针对整个对象的约束，递归处理每个属性
 			result := make(map[string]any)
-			for key, item := range v {
+			for _, key := range sortedKeys(v) {
+				item := v[key]
 				// 构建嵌套字段名
 				nestedFieldName := fieldName + "." + key
-				result[key] = generateVariationWithConstraints(item, nestedFieldName, variationRate)
+				result[key] = g.generateVariationWithConstraints(item, nestedFieldName, variationRate)
 			}
 			return result
 		}
@@ -890,23 +892,23 @@ func generateVariationWithConstraints(value any, fieldName string, variationRate
 		for i, item := range v {
 			// 构建数组元素字段名
 			arrayFieldName := fmt.Sprintf("%s[%d]", fieldName, i)
-			result[i] = generateVariationWithConstraints(item, arrayFieldName, variationRate)
+			result[i] = g.generateVariationWithConstraints(item, arrayFieldName, variationRate)
 		}
 		return result
 	default:
 		// 基本类型，尝试查找字段约束
-		if constraint := FindFieldConstraint(fieldName); constraint != nil {
+		if name, constraint := g.findFieldConstraintEntry(fieldName); constraint != nil {
 			// 使用约束生成值
-			return GenerateConstrainedValue(constraint, value)
+			return g.generateFieldValue(name, constraint, value)
 		}
 		// 没有约束，使用原始变化逻辑
-		return generateVariation(value, variationRate)
+		return g.generateVariation(value, variationRate)
 	}
 }
 
 // randomizeString 随机修改字符串
 // 字符串长度可进行10%范围内的变化，内容50%的字符随机变更
-func randomizeString(s string) string {
+func (g *Generator) randomizeString(s string) string {
 	// 如果字符串很短，至少保证最小长度为1
 	originalLen := len(s)
 	if originalLen == 0 {
@@ -924,7 +926,7 @@ func randomizeString(s string) string {
 	}
 
 	// 随机确定新长度
-	newLen := minLen + rand.Intn(maxLen-minLen+1)
+	newLen := minLen + g.rng.Intn(maxLen-minLen+1)
 
 	// 将原字符串转换为字符数组
 	runes := []rune(s)
@@ -939,8 +941,8 @@ func randomizeString(s string) string {
 		if newLen > originalLen {
 			// 需要扩展字符串，在随机位置插入随机字符
 			for i := 0; i < newLen-originalLen; i++ {
-				insertPos := rand.Intn(len(runes) + 1)
-				newChar := rune(charset[rand.Intn(len(charset))])
+				insertPos := g.rng.Intn(len(runes) + 1)
+				newChar := rune(charset[g.rng.Intn(len(charset))])
 				// 在指定位置插入字符
 				runes = append(runes[:insertPos], append([]rune{newChar}, runes[insertPos:]...)...)
 			}
@@ -948,7 +950,7 @@ func randomizeString(s string) string {
 			// 需要缩短字符串，随机删除字符
 			for i := 0; i < originalLen-newLen; i++ {
 				if len(runes) > 1 {
-					deletePos := rand.Intn(len(runes))
+					deletePos := g.rng.Intn(len(runes))
 					runes = append(runes[:deletePos], runes[deletePos+1:]...)
 				}
 			}
@@ -969,21 +971,26 @@ func randomizeString(s string) string {
 
 	// 随机打乱位置数组
 	for i := len(positions) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+		j := g.rng.Intn(i + 1)
 		positions[i], positions[j] = positions[j], positions[i]
 	}
 
 	// 变更前changeCount个位置的字符
 	for i := 0; i < changeCount && i < len(positions); i++ {
 		pos := positions[i]
-		runes[pos] = rune(charset[rand.Intn(len(charset))])
+		runes[pos] = rune(charset[g.rng.Intn(len(charset))])
 	}
 
 	return string(runes)
 }
 
-// ConvertToXMLRows 将测试用例转换为XML行格式（每行一个完整的XML）
+// ConvertToXMLRows 使用默认生成器将测试用例转换为XML行格式（每行一个完整的XML）
 func ConvertToXMLRows(testCases []map[string]any) [][]string {
+	return defaultGenerator.ConvertToXMLRows(testCases)
+}
+
+// ConvertToXMLRows 按解析时记录的根元素和XML声明将测试用例转换为XML行格式
+func (g *Generator) ConvertToXMLRows(testCases []map[string]any) [][]string {
 	if len(testCases) == 0 {
 		return [][]string{}
 	}
@@ -996,7 +1003,7 @@ func ConvertToXMLRows(testCases []map[string]any) [][]string {
 	// 填充数据行，每行包含一个完整的XML
 	for _, testCase := range testCases {
 		// 将测试用例转换为XML字符串
-		xmlData, err := convertMapToXML(testCase)
+		xmlData, err := g.convertMapToXML(testCase)
 		if err != nil {
 			// 如果转换失败，使用JSON作为备选
 			jsonData, _ := json.Marshal(testCase)
@@ -1011,14 +1018,14 @@ func ConvertToXMLRows(testCases []map[string]any) [][]string {
 }
 
 // convertMapToXML 将map转换为XML字符串
-func convertMapToXML(data map[string]any) (string, error) {
+func (g *Generator) convertMapToXML(data map[string]any) (string, error) {
 	var xmlBuilder strings.Builder
 
 	// 只有当原始XML包含XML声明时才添加XML声明
-	if originalHasXMLDeclaration {
-		if originalXMLDeclaration != "" {
+	if g.Schema.HasXMLDeclaration {
+		if g.Schema.XMLDeclaration != "" {
 			// 使用保存的原始XML声明
-			xmlBuilder.WriteString(originalXMLDeclaration + "\n")
+			xmlBuilder.WriteString(g.Schema.XMLDeclaration + "\n")
 		} else {
 			// 如果没有保存的声明，使用默认的UTF-8声明
 			xmlBuilder.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
@@ -1026,13 +1033,13 @@ func convertMapToXML(data map[string]any) (string, error) {
 	}
 
 	// 使用保存的原始根元素名称，如果没有则使用默认的root
-	rootElement := originalRootElementName
+	rootElement := g.Schema.RootElementName
 	if rootElement == "" {
 		rootElement = "root"
 	}
 
 	// 构建XML内容
-	xmlContent := buildXMLContent(data, "")
+	xmlContent := g.buildXMLContent(data, "")
 
 	// 如果内容为空，使用自闭合标签
 	if strings.TrimSpace(xmlContent) == "" {
@@ -1045,18 +1052,15 @@ func convertMapToXML(data map[string]any) (string, error) {
 }
 
 // buildXMLContent 递归构建XML内容
-func buildXMLContent(data map[string]any, indent string) string {
+func (g *Generator) buildXMLContent(data map[string]any, indent string) string {
 	var xmlBuilder strings.Builder
 
-	// 对于嵌套结构，不使用全局的originalKeyOrder，而是使用当前map的键
-	keys := make([]string, 0, len(data))
-	for key := range data {
-		keys = append(keys, key)
-	}
+	// 对于嵌套结构，不使用保存的字段顺序，而是按字段名排序使用当前map的键
+	keys := sortedKeys(data)
 
 	// 如果是根级别且有保存的顺序，则使用保存的顺序
-	if indent == "" && len(originalKeyOrder) > 0 {
-		keys = originalKeyOrder
+	if indent == "" && len(g.Schema.KeyOrder) > 0 {
+		keys = g.Schema.KeyOrder
 	}
 
 	hasContent := false
@@ -1102,7 +1106,7 @@ func buildXMLContent(data map[string]any, indent string) string {
 			xmlBuilder.WriteString(fmt.Sprintf("<%s>%t</%s>", cleanKey, v, cleanKey))
 		case map[string]any:
 			// 嵌套对象，递归处理
-			nestedContent := buildXMLContent(v, indent+"  ")
+			nestedContent := g.buildXMLContent(v, indent+"  ")
 			if strings.TrimSpace(nestedContent) == "" {
 				// 空的嵌套对象，使用自闭合标签
 				xmlBuilder.WriteString(fmt.Sprintf("<%s />", cleanKey))
@@ -1134,7 +1138,7 @@ func buildXMLContent(data map[string]any, indent string) string {
 					xmlBuilder.WriteString(fmt.Sprintf("<%s>%s</%s>", cleanKey, itemStr, cleanKey))
 				case map[string]any:
 					// 嵌套对象，递归处理
-					nestedContent := buildXMLContent(iv, indent+"  ")
+					nestedContent := g.buildXMLContent(iv, indent+"  ")
 					if strings.TrimSpace(nestedContent) == "" {
 						// 空的嵌套对象，使用自闭合标签
 						xmlBuilder.WriteString(fmt.Sprintf("<%s />", cleanKey))
@@ -1170,18 +1174,15 @@ func escapeXMLValue(value string) string {
 
 // customJSONMarshal 自定义JSON序列化，保持大数值的原始格式
 // 避免将长数值转换为科学计数法
-func customJSONMarshal(data map[string]any) (string, error) {
+func (g *Generator) customJSONMarshal(data map[string]any) (string, error) {
 	var result strings.Builder
 	result.WriteString("{")
 
 	// 使用保存的原始字段顺序来序列化JSON
-	keys := originalKeyOrder
+	keys := g.Schema.KeyOrder
 	if len(keys) == 0 {
-		// 如果没有保存的顺序，则使用map的键（无序）
-		keys = make([]string, 0, len(data))
-		for key := range data {
-			keys = append(keys, key)
-		}
+		// 如果没有保存的顺序，则按字段名排序
+		keys = sortedKeys(data)
 	}
 
 	first := true
@@ -1341,9 +1342,14 @@ func customJSONMarshal(data map[string]any) (string, error) {
 	return result.String(), nil
 }
 
-// ConvertToJSONRows 将测试用例转换为单列JSON格式的CSV
+// ConvertToJSONRows 使用默认生成器将测试用例转换为单列JSON格式的CSV
 // 每行包含一个完整的JSON字符串
 func ConvertToJSONRows(testCases []map[string]any) [][]string {
+	return defaultGenerator.ConvertToJSONRows(testCases)
+}
+
+// ConvertToJSONRows 按解析时记录的字段顺序将测试用例转换为单列JSON格式的CSV
+func (g *Generator) ConvertToJSONRows(testCases []map[string]any) [][]string {
 	if len(testCases) == 0 {
 		return [][]string{}
 	}
@@ -1356,7 +1362,7 @@ func ConvertToJSONRows(testCases []map[string]any) [][]string {
 	// 填充数据行
 	for _, testCase := range testCases {
 		// 使用自定义JSON序列化来保持数值格式
-		jsonStr, err := customJSONMarshal(testCase)
+		jsonStr, err := g.customJSONMarshal(testCase)
 		if err != nil {
 			// 如果转换失败，使用空JSON对象
 			jsonStr = "{}"
@@ -1369,14 +1375,19 @@ func ConvertToJSONRows(testCases []map[string]any) [][]string {
 	return result
 }
 
-// ConvertToCSV 将测试用例转换为CSV格式
+// ConvertToCSV 使用默认生成器将测试用例转换为CSV格式
 func ConvertToCSV(testCases []map[string]any) [][]string {
+	return defaultGenerator.ConvertToCSV(testCases)
+}
+
+// ConvertToCSV 按解析时记录的字段顺序和类型将测试用例转换为CSV格式
+func (g *Generator) ConvertToCSV(testCases []map[string]any) [][]string {
 	if len(testCases) == 0 {
 		return [][]string{}
 	}
 
 	// 使用保存的原始字段顺序
-	keys := originalKeyOrder
+	keys := g.Schema.KeyOrder
 	if len(keys) == 0 {
 		// 如果没有保存的顺序，则使用第一个测试用例的键
 		keys = make([]string, 0, len(testCases[0]))
@@ -1398,8 +1409,8 @@ func ConvertToCSV(testCases []map[string]any) [][]string {
 			value := testCase[key]
 
 			// 根据原始数据类型处理值
-			if originalValueTypes != nil {
-				if typeInfo, ok := originalValueTypes[key]; ok {
+			if g.Schema.ValueTypes != nil {
+				if typeInfo, ok := g.Schema.ValueTypes[key]; ok {
 					// 根据类型信息处理值
 					if strings.HasPrefix(typeInfo, "int") {
 						// 整数类型，确保输出为整数
@@ -0,0 +1,535 @@
+// Package utils 提供字段之间的依赖约束（expr 表达式、after 日期先后和 depends_on 查找映射）的求值、校验和循环检测
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldSlot 字段在报文中的位置，用于读取和替换字段值
+type fieldSlot struct {
+	object map[string]any // 字段所在的对象，字段是数组元素时为nil
+	array  []any          // 字段所在的数组
+	key    string         // 对象中的字段名
+	index  int            // 数组中的下标
+}
+
+// get 返回字段值
+func (s fieldSlot) get() any {
+	if s.object != nil {
+		return s.object[s.key]
+	}
+	return s.array[s.index]
+}
+
+// set 替换字段值
+func (s fieldSlot) set(value any) {
+	if s.object != nil {
+		s.object[s.key] = value
+	} else {
+		s.array[s.index] = value
+	}
+}
+
+// dependentField 报文中带依赖约束的字段
+type dependentField struct {
+	path       string            // 字段路径
+	constraint *FieldConstraint  // 字段约束
+	refs       map[string]string // 约束中的字段引用对应的字段路径
+	missing    []string          // 报文中不存在的字段引用
+}
+
+// isDependentConstraint 判断约束是否依赖其他字段（expr 表达式、after 日期先后或 depends_on 查找映射）
+func isDependentConstraint(constraint *FieldConstraint) bool {
+	return constraint != nil && (constraint.Expr != "" || constraint.After != "" || constraint.DependsOn != "")
+}
+
+// constraintDependencies 返回约束引用的字段，没有依赖时返回nil
+func constraintDependencies(constraint *FieldConstraint) ([]string, error) {
+	switch {
+	case constraint.Expr != "":
+		node, err := parseExpr(constraint.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return exprFields(node), nil
+	case constraint.After != "":
+		return []string{strings.TrimSpace(constraint.After)}, nil
+	case constraint.DependsOn != "":
+		return []string{strings.TrimSpace(constraint.DependsOn)}, nil
+	}
+	return nil, nil
+}
+
+// lookupCandidates 返回映射中被依赖字段的值对应的取值，映射值为列表时返回列表中的全部候选值
+func lookupCandidates(constraint *FieldConstraint, refValue any) ([]any, error) {
+	key := fmt.Sprint(normalizeConstraintValue(refValue))
+	mapped, exists := constraint.Mapping[key]
+	if !exists {
+		return nil, fmt.Errorf("的值 '%s' 在 mapping 中没有对应的取值", key)
+	}
+	if list, ok := mapped.([]any); ok {
+		if len(list) == 0 {
+			return nil, fmt.Errorf("的值 '%s' 在 mapping 中对应的候选值列表为空", key)
+		}
+		return normalizeConstraintValues(list), nil
+	}
+	return []any{normalizeConstraintValue(mapped)}, nil
+}
+
+// collectFieldSlots 收集报文中所有叶子字段的位置，字段路径的构造方式与 generateVariationWithConstraints 一致
+func collectFieldSlots(value any, path string, slot fieldSlot, slots map[string]fieldSlot) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			collectFieldSlots(item, path+"."+key, fieldSlot{object: v, key: key}, slots)
+		}
+	case []any:
+		for i, item := range v {
+			collectFieldSlots(item, fmt.Sprintf("%s[%d]", path, i), fieldSlot{array: v, index: i}, slots)
+		}
+	default:
+		slots[path] = slot
+	}
+}
+
+// resolveFieldReference 将约束中的字段引用解析为报文中的字段路径
+// 优先匹配同一对象中的字段（如 items[0].total 引用 price 时为 items[0].price），其次匹配从顶层开始的完整路径
+func resolveFieldReference(path, ref string, slots map[string]fieldSlot) (string, bool) {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		if _, exists := slots[path[:i]+"."+ref]; exists {
+			return path[:i] + "." + ref, true
+		}
+	}
+	if _, exists := slots[ref]; exists {
+		return ref, true
+	}
+	return "", false
+}
+
+// orderDependentFields 收集报文中带依赖约束的字段，按依赖顺序排序（被依赖的字段在前）
+// 字段之间存在循环依赖时返回错误
+func orderDependentFields(data map[string]any, constraints map[string]FieldConstraint) ([]*dependentField, map[string]fieldSlot, error) {
+	slots := make(map[string]fieldSlot)
+	for key, value := range data {
+		collectFieldSlots(value, key, fieldSlot{object: data, key: key}, slots)
+	}
+
+	paths := make([]string, 0, len(slots))
+	for path := range slots {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	dependents := make(map[string]*dependentField)
+	var dependentPaths []string
+	for _, path := range paths {
+		constraint := lookupFieldConstraint(constraints, path)
+		if constraint == nil || constraint.Type == "keep_original" || (constraint.KeepOriginal != nil && *constraint.KeepOriginal) {
+			continue
+		}
+		refs, err := constraintDependencies(constraint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("字段 %s: %v", path, err)
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		field := &dependentField{path: path, constraint: constraint, refs: make(map[string]string)}
+		for _, ref := range refs {
+			if resolved, ok := resolveFieldReference(path, ref, slots); ok {
+				field.refs[ref] = resolved
+			} else {
+				field.missing = append(field.missing, ref)
+			}
+		}
+		dependents[path] = field
+		dependentPaths = append(dependentPaths, path)
+	}
+
+	// 深度优先遍历，被依赖的字段先加入结果
+	var ordered []*dependentField
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var visit func(path string, chain []string) error
+	visit = func(path string, chain []string) error {
+		field := dependents[path]
+		if field == nil || visited[path] {
+			return nil
+		}
+		if visiting[path] {
+			return fmt.Errorf("字段之间存在循环依赖: %s -> %s", strings.Join(chain, " -> "), path)
+		}
+		visiting[path] = true
+		refPaths := make([]string, 0, len(field.refs))
+		for _, refPath := range field.refs {
+			refPaths = append(refPaths, refPath)
+		}
+		sort.Strings(refPaths)
+		for _, refPath := range refPaths {
+			if err := visit(refPath, append(chain, path)); err != nil {
+				return err
+			}
+		}
+		visiting[path] = false
+		visited[path] = true
+		ordered = append(ordered, field)
+		return nil
+	}
+	for _, path := range dependentPaths {
+		if err := visit(path, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return ordered, slots, nil
+}
+
+// applyDependentConstraints 按依赖顺序重新计算测试用例中依赖其他字段的字段值
+// keep 中的字段保持当前值（如边界值用例中被测试的字段），依赖它们的字段仍会重新计算
+func (g *Generator) applyDependentConstraints(testCase map[string]any, keep ...string) {
+	if g.Constraints == nil {
+		return
+	}
+	fields, slots, err := orderDependentFields(testCase, g.Constraints.Constraints)
+	if err != nil {
+		g.setErr(err)
+		return
+	}
+
+	kept := make(map[string]bool, len(keep))
+	for _, path := range keep {
+		kept[path] = true
+	}
+	for _, field := range fields {
+		if kept[field.path] {
+			continue
+		}
+		if len(field.missing) > 0 {
+			g.setErr(fmt.Errorf("字段 %s 依赖的字段 %s 不存在", field.path, strings.Join(field.missing, ", ")))
+			return
+		}
+		slot := slots[field.path]
+		value, err := g.dependentValue(field, slots, slot.get())
+		if err != nil {
+			g.setErr(fmt.Errorf("字段 %s: %v", field.path, err))
+			return
+		}
+		slot.set(value)
+	}
+}
+
+// dependentValue 根据被依赖字段的当前值生成字段值
+func (g *Generator) dependentValue(field *dependentField, slots map[string]fieldSlot, originalValue any) (any, error) {
+	constraint := field.constraint
+	if constraint.Expr != "" {
+		result, err := evalDependentExpr(field, slots)
+		if err != nil {
+			return nil, err
+		}
+		return exprResultValue(constraint, result, originalValue), nil
+	}
+	if constraint.DependsOn != "" {
+		refPath := field.refs[strings.TrimSpace(constraint.DependsOn)]
+		candidates, err := lookupCandidates(constraint, slots[refPath].get())
+		if err != nil {
+			return nil, fmt.Errorf("依赖的字段 %s %v", refPath, err)
+		}
+		return preserveValueType(candidates[g.rng.Intn(len(candidates))], originalValue), nil
+	}
+
+	refPath := field.refs[strings.TrimSpace(constraint.After)]
+	after, err := parseDependencyTime(slots[refPath].get(), g.FindFieldConstraint(refPath))
+	if err != nil {
+		return nil, fmt.Errorf("依赖的字段 %s %v", refPath, err)
+	}
+
+	// 将最小日期（时间）提高到被依赖字段的值后在范围内重新生成
+	bounded := *constraint
+	if constraint.Type == "datetime" {
+		if minDatetime, err := time.Parse(time.RFC3339, constraint.MinDatetime); err != nil || after.After(minDatetime) {
+			bounded.MinDatetime = after.Format(time.RFC3339Nano)
+		}
+		return g.generateDatetimeValue(&bounded), nil
+	}
+	if afterDate := after.Format(constraintDateLayout); afterDate > constraint.MinDate {
+		bounded.MinDate = afterDate
+	}
+	return g.generateDateValue(&bounded), nil
+}
+
+// evalDependentExpr 使用被依赖字段的值计算表达式
+func evalDependentExpr(field *dependentField, slots map[string]fieldSlot) (float64, error) {
+	node, err := parseExpr(field.constraint.Expr)
+	if err != nil {
+		return 0, err
+	}
+	return node.eval(func(ref string) (float64, error) {
+		value := slots[field.refs[ref]].get()
+		number, err := constraintNumber(value)
+		if err != nil {
+			return 0, fmt.Errorf("依赖的字段 %s 的值 '%v' 不是数值", field.refs[ref], value)
+		}
+		return number, nil
+	})
+}
+
+// exprResultValue 按约束类型输出表达式的计算结果并保持正例字段的类型
+// integer 四舍五入为整数，float 按精度（默认2位）保留小数
+func exprResultValue(constraint *FieldConstraint, result float64, originalValue any) any {
+	if constraint.Type == "integer" {
+		return preserveValueType(int(math.Round(result)), originalValue)
+	}
+
+	precision := 2
+	if constraint.Precision != nil {
+		precision = *constraint.Precision
+	}
+	multiplier := math.Pow(10, float64(precision))
+	value := math.Round(result*multiplier) / multiplier
+	if _, ok := originalValue.(string); ok {
+		return strconv.FormatFloat(value, 'f', precision, 64)
+	}
+	return value
+}
+
+// parseDependencyTime 解析被依赖的日期（时间）字段，优先使用该字段约束的格式
+func parseDependencyTime(value any, constraint *FieldConstraint) (time.Time, error) {
+	str := fmt.Sprint(value)
+	if constraint != nil && constraint.Type == "date" && constraint.Format != "" {
+		if parsed, err := time.Parse(constraint.Format, str); err == nil {
+			return parsed, nil
+		}
+	}
+	if layout := detectDateLayout(str); layout != "" {
+		parsed, _ := time.Parse(layout, str)
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("的值 '%s' 不是日期", str)
+}
+
+// checkDependentConstraints 校验用例中的依赖约束：expr 字段等于表达式的计算结果，after 字段不早于被依赖的字段，
+// depends_on 字段等于被依赖字段的值在 mapping 中对应的取值
+func checkDependentConstraints(data map[string]any, constraints map[string]FieldConstraint) ([]ConstraintViolation, error) {
+	fields, slots, err := orderDependentFields(data, constraints)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []ConstraintViolation
+	for _, field := range fields {
+		value := slots[field.path].get()
+		if len(field.missing) > 0 {
+			violations = append(violations, ConstraintViolation{Field: field.path, Value: value, Message: fmt.Sprintf("依赖的字段 %s 不存在", strings.Join(field.missing, ", "))})
+			continue
+		}
+		if err := checkDependentValue(field, slots, value, constraints); err != nil {
+			violations = append(violations, ConstraintViolation{Field: field.path, Value: value, Message: err.Error()})
+		}
+	}
+	return violations, nil
+}
+
+// checkDependentValue 校验单个字段是否满足依赖约束
+func checkDependentValue(field *dependentField, slots map[string]fieldSlot, value any, constraints map[string]FieldConstraint) error {
+	constraint := field.constraint
+	if constraint.Expr != "" {
+		result, err := evalDependentExpr(field, slots)
+		if err != nil {
+			return fmt.Errorf("expr约束: %v", err)
+		}
+		expected := exprResultValue(constraint, result, value)
+		expectedNumber, _ := constraintNumber(expected)
+		if number, err := constraintNumber(value); err != nil || math.Abs(number-expectedNumber) > 1e-6 {
+			return fmt.Errorf("expr约束: 不等于 %s 的计算结果 %v", constraint.Expr, expected)
+		}
+		return nil
+	}
+	if constraint.DependsOn != "" {
+		refPath := field.refs[strings.TrimSpace(constraint.DependsOn)]
+		refValue := slots[refPath].get()
+		candidates, err := lookupCandidates(constraint, refValue)
+		if err != nil {
+			return fmt.Errorf("depends_on约束: 依赖的字段 %s %v", refPath, err)
+		}
+		for _, candidate := range candidates {
+			if enumValueEqual(candidate, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("depends_on约束: 不是字段 %s 的值 %v 对应的取值 %v", refPath, refValue, candidates)
+	}
+
+	refPath := field.refs[strings.TrimSpace(constraint.After)]
+	after, err := parseDependencyTime(slots[refPath].get(), lookupFieldConstraint(constraints, refPath))
+	if err != nil {
+		return fmt.Errorf("after约束: 依赖的字段 %s %v", refPath, err)
+	}
+	current, err := parseDependencyTime(value, constraint)
+	if err != nil {
+		return fmt.Errorf("after约束: 字段%v", err)
+	}
+	if current.Before(after) {
+		return fmt.Errorf("after约束: 早于字段 %s 的值 %v", refPath, slots[refPath].get())
+	}
+	return nil
+}
+
+// validateConstraintDependencies 验证依赖约束引用的约束之间没有循环依赖
+func validateConstraintDependencies(constraints map[string]FieldConstraint) []ValidationError {
+	names := make([]string, 0, len(constraints))
+	edges := make(map[string][]string)
+	for name, constraint := range constraints {
+		names = append(names, name)
+		refs, err := constraintDependencies(&constraint)
+		if err != nil {
+			continue // 表达式语法错误已在字段约束验证中报告
+		}
+		for _, ref := range refs {
+			if target, targetConstraint := lookupFieldConstraintEntry(constraints, ref); targetConstraint != nil {
+				edges[name] = append(edges[name], target)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var errors []ValidationError
+
+	// 被依赖的字段是枚举时，每个候选值都需要在 mapping 中有对应的取值
+	for _, name := range names {
+		constraint := constraints[name]
+		if constraint.DependsOn == "" || len(constraint.Mapping) == 0 {
+			continue
+		}
+		target, targetConstraint := lookupFieldConstraintEntry(constraints, strings.TrimSpace(constraint.DependsOn))
+		if targetConstraint == nil || targetConstraint.Type != "enum" {
+			continue
+		}
+		for _, value := range targetConstraint.Values {
+			if _, err := lookupCandidates(&constraint, value); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   name,
+					Message: fmt.Sprintf("依赖的约束 %s 的候选值 '%v' 在 mapping 中没有对应的取值", target, normalizeConstraintValue(value)),
+				})
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var visit func(name string, chain []string)
+	visit = func(name string, chain []string) {
+		if visited[name] {
+			return
+		}
+		if visiting[name] {
+			// 只报告环上的约束
+			for i, item := range chain {
+				if item == name {
+					chain = chain[i:]
+					break
+				}
+			}
+			errors = append(errors, ValidationError{
+				Field:   name,
+				Message: fmt.Sprintf("约束之间存在循环依赖: %s -> %s", strings.Join(chain, " -> "), name),
+			})
+			return
+		}
+		visiting[name] = true
+		for _, target := range edges[name] {
+			visit(target, append(chain, name))
+		}
+		visiting[name] = false
+		visited[name] = true
+	}
+	for _, name := range names {
+		visit(name, nil)
+	}
+	return errors
+}
+
+// validateDependentConstraint 验证依赖约束的表达式、查找映射和适用的约束类型
+func validateDependentConstraint(fieldName string, constraint FieldConstraint) []ValidationError {
+	var errors []ValidationError
+
+	if constraint.Expr != "" {
+		if constraint.Type != "integer" && constraint.Type != "float" {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "表达式 'expr' 只能用于 integer 或 float 类型",
+			})
+		}
+		if _, err := parseExpr(constraint.Expr); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: err.Error(),
+			})
+		}
+		if constraint.Unique {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "由表达式计算的字段不能设置 unique",
+			})
+		}
+	}
+
+	if constraint.After != "" {
+		if constraint.Type != "date" && constraint.Type != "datetime" {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "'after' 只能用于 date 或 datetime 类型",
+			})
+		}
+		if constraint.Expr != "" {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "'expr' 和 'after' 不能同时设置",
+			})
+		}
+	}
+
+	if constraint.DependsOn != "" {
+		if constraint.Type != "lookup" {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "'depends_on' 只能用于 lookup 类型",
+			})
+		}
+		if constraint.Expr != "" || constraint.After != "" {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "'depends_on' 不能与 'expr' 或 'after' 同时设置",
+			})
+		}
+	}
+
+	if constraint.Type == "lookup" {
+		if constraint.DependsOn == "" || len(constraint.Mapping) == 0 {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "lookup 类型需要设置 'depends_on' 和非空的 'mapping'",
+			})
+		}
+		for _, key := range sortedKeys(constraint.Mapping) {
+			if list, ok := constraint.Mapping[key].([]any); ok && len(list) == 0 {
+				errors = append(errors, ValidationError{
+					Field:   fieldName,
+					Message: fmt.Sprintf("mapping 中 '%s' 对应的候选值列表不能为空", key),
+				})
+			}
+		}
+		if constraint.Unique {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: "由映射查找的字段不能设置 unique",
+			})
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,362 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseExpr 测试表达式的解析、引用字段和求值
+func TestParseExpr(t *testing.T) {
+	values := map[string]float64{"price": 2.5, "order.quantity": 4, "items[0].fee": 1}
+	lookup := func(field string) (float64, error) { return values[field], nil }
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    float64
+		fields  []string
+		wantErr string
+	}{
+		{name: "乘法", expr: "price * order.quantity", want: 10, fields: []string{"price", "order.quantity"}},
+		{name: "优先级和括号", expr: "(price + items[0].fee) * 2 - order.quantity / 4", want: 6, fields: []string{"price", "items[0].fee", "order.quantity"}},
+		{name: "负号和小数", expr: "-price + .5 * 2", want: -1.5, fields: []string{"price"}},
+		{name: "重复引用", expr: "price * price", want: 6.25, fields: []string{"price"}},
+		{name: "空表达式", expr: " ", wantErr: "不能为空"},
+		{name: "缺少右括号", expr: "(price + 1", wantErr: "缺少右括号"},
+		{name: "不完整", expr: "price *", wantErr: "不完整"},
+		{name: "非法字符", expr: "price % 2", wantErr: "无法解析"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseExpr(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("期望包含 %q 的错误，实际: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if got, _ := node.eval(lookup); got != tt.want {
+				t.Errorf("计算结果 %v，期望 %v", got, tt.want)
+			}
+			if fields := exprFields(node); strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("引用字段 %v，期望 %v", fields, tt.fields)
+			}
+		})
+	}
+
+	if _, err := (exprBinary{op: '/', left: exprNumber(1), right: exprNumber(0)}).eval(lookup); err == nil {
+		t.Error("除数为0时应返回错误")
+	}
+}
+
+// TestGenerateDependentValues 测试按依赖顺序生成表达式字段和日期先后字段
+func TestGenerateDependentValues(t *testing.T) {
+	minPrice, maxPrice, minQuantity, maxQuantity := 1.0, 100.0, 1.0, 10.0
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"price":      {Type: "float", Min: &minPrice, Max: &maxPrice},
+		"quantity":   {Type: "integer", Min: &minQuantity, Max: &maxQuantity},
+		"subtotal":   {Type: "float", Expr: "price * quantity"},
+		"total":      {Type: "float", Expr: "order.subtotal + shipping"},
+		"shipping":   {Type: "integer", Min: &minQuantity, Max: &maxQuantity},
+		"start_date": {Type: "date", Format: "2006-01-02", MinDate: "20240101", MaxDate: "20241231"},
+		"end_date":   {Type: "date", Format: "2006-01-02", MinDate: "20240101", MaxDate: "20241231", After: "start_date"},
+	}}, DefaultVariationRate, 9)
+	data := map[string]any{
+		"order":      map[string]any{"price": 9.9, "quantity": 1, "subtotal": 9.9},
+		"items":      []any{map[string]any{"price": 1.0, "quantity": 2, "subtotal": 2.0}},
+		"shipping":   5,
+		"total":      14.9,
+		"start_date": "2024-03-01",
+		"end_date":   "2024-03-02",
+	}
+
+	for _, testCase := range g.GenerateTestCases(data, 50) {
+		order := testCase["order"].(map[string]any)
+		subtotal := order["price"].(float64) * float64(order["quantity"].(int))
+		if want := roundTo(subtotal, 2); order["subtotal"] != want {
+			t.Fatalf("order.subtotal 计算错误: %v，期望 %v", order, want)
+		}
+
+		// 数组元素中的字段引用同一对象中的字段
+		item := testCase["items"].([]any)[0].(map[string]any)
+		if want := roundTo(item["price"].(float64)*float64(item["quantity"].(int)), 2); item["subtotal"] != want {
+			t.Fatalf("items[0].subtotal 计算错误: %v，期望 %v", item, want)
+		}
+
+		// total 依赖 order.subtotal，按依赖顺序先计算 order.subtotal
+		if want := roundTo(order["subtotal"].(float64)+float64(testCase["shipping"].(int)), 2); testCase["total"] != want {
+			t.Fatalf("total 计算错误: %v，期望 %v", testCase["total"], want)
+		}
+
+		start, _ := time.Parse("2006-01-02", testCase["start_date"].(string))
+		end, _ := time.Parse("2006-01-02", testCase["end_date"].(string))
+		if end.Before(start) {
+			t.Fatalf("end_date %v 早于 start_date %v", testCase["end_date"], testCase["start_date"])
+		}
+	}
+	if g.Err() != nil {
+		t.Fatalf("不应返回错误: %v", g.Err())
+	}
+
+	// 依赖的字段不存在时返回错误
+	g.GenerateTestCases(map[string]any{"total": 1.0}, 1)
+	if err := g.Err(); err == nil || !strings.Contains(err.Error(), "不存在") {
+		t.Errorf("依赖的字段不存在时应返回错误: %v", err)
+	}
+}
+
+// roundTo 四舍五入到指定小数位数
+func roundTo(value float64, precision int) float64 {
+	return exprResultValue(&FieldConstraint{Type: "float", Precision: &precision}, value, 0.0).(float64)
+}
+
+// TestConstraintDependencyValidation 测试依赖约束的配置校验和循环依赖检测
+func TestConstraintDependencyValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints map[string]FieldConstraint
+		wantErr     string
+	}{
+		{name: "无循环", constraints: map[string]FieldConstraint{
+			"a": {Type: "integer", Expr: "b + c"}, "b": {Type: "integer", Expr: "c * 2"}, "c": {Type: "integer"},
+		}},
+		{name: "两个约束循环", constraints: map[string]FieldConstraint{
+			"a": {Type: "integer", Expr: "b + 1"}, "b": {Type: "integer", Expr: "order.a - 1"},
+		}, wantErr: "循环依赖: a -> b -> a"},
+		{name: "自引用", constraints: map[string]FieldConstraint{
+			"end": {Type: "date", After: "end"},
+		}, wantErr: "循环依赖: end -> end"},
+		{name: "表达式语法错误", constraints: map[string]FieldConstraint{
+			"a": {Type: "integer", Expr: "b +"},
+		}, wantErr: "无效的表达式"},
+		{name: "表达式用于非数值类型", constraints: map[string]FieldConstraint{
+			"a": {Type: "date", Expr: "b"},
+		}, wantErr: "只能用于 integer 或 float"},
+		{name: "after用于非日期类型", constraints: map[string]FieldConstraint{
+			"a": {Type: "integer", After: "b"},
+		}, wantErr: "只能用于 date 或 datetime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateConstraintDependencies(tt.constraints)
+			for name, constraint := range tt.constraints {
+				errors = append(errors, validateDependentConstraint(name, constraint)...)
+			}
+			if tt.wantErr == "" && len(errors) > 0 {
+				t.Fatalf("不应返回错误: %v", errors)
+			}
+			if tt.wantErr != "" && (len(errors) == 0 || !strings.Contains(ValidationErrors(errors).Error(), tt.wantErr)) {
+				t.Errorf("期望包含 %q 的错误，实际: %v", tt.wantErr, errors)
+			}
+		})
+	}
+}
+
+// TestCheckDependentConstraints 测试校验用例中的依赖约束
+func TestCheckDependentConstraints(t *testing.T) {
+	constraints := map[string]FieldConstraint{
+		"total":    {Type: "integer", Expr: "price * quantity"},
+		"end_time": {Type: "datetime", After: "start_time"},
+	}
+
+	tests := []struct {
+		name       string
+		payload    string
+		wantFields []string
+	}{
+		{name: "满足依赖约束", payload: `{"price":3,"quantity":4,"total":12,"start_time":"2024-01-01T08:00:00Z","end_time":"2024-01-01T17:00:00+08:00"}`},
+		{name: "计算结果不符", payload: `{"price":3,"quantity":4,"total":13,"start_time":"2024-01-01T08:00:00Z","end_time":"2024-01-01T07:00:00Z"}`, wantFields: []string{"end_time", "total"}},
+		{name: "依赖的字段缺失", payload: `{"price":3,"total":3}`, wantFields: []string{"total"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := CheckCaseConstraints(tt.payload, "json", constraints)
+			if err != nil {
+				t.Fatalf("校验失败: %v", err)
+			}
+			var fields []string
+			for _, violation := range violations {
+				fields = append(fields, violation.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("违反约束的字段 %v，期望 %v: %+v", fields, tt.wantFields, violations)
+			}
+		})
+	}
+}
+
+// TestLookupDependency 测试 depends_on 查找映射的配置加载、校验、生成和用例校验
+func TestLookupDependency(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	content := `[constraints.city]
+type = "enum"
+values = ["广州", "深圳", "杭州"]
+
+[constraints.province]
+type = "lookup"
+depends_on = "city"
+mapping = { "广州" = "广东", "深圳" = "广东", "杭州" = "浙江" }
+
+[constraints.district]
+type = "lookup"
+depends_on = "city"
+mapping = { "广州" = ["天河区", "越秀区"], "深圳" = ["南山区", "福田区"], "杭州" = ["西湖区"] }
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	constraintConfig := &ConstraintConfig{Constraints: config.Constraints.Constraints}
+	errors := validateConstraintDependencies(constraintConfig.Constraints)
+	for name, constraint := range constraintConfig.Constraints {
+		errors = append(errors, validateFieldConstraint(name, constraint)...)
+	}
+	if len(errors) > 0 {
+		t.Fatalf("约束配置应通过验证: %v", errors)
+	}
+
+	provinces := map[string]string{"广州": "广东", "深圳": "广东", "杭州": "浙江"}
+	g := NewGenerator(constraintConfig, DefaultVariationRate, 5)
+	data := map[string]any{"city": "广州", "province": "广东", "district": "天河区"}
+	for _, testCase := range g.GenerateTestCases(data, 30) {
+		city := testCase["city"].(string)
+		if testCase["province"] != provinces[city] {
+			t.Fatalf("province 与 city 不匹配: %v", testCase)
+		}
+		payload, _ := json.Marshal(testCase)
+		if violations, err := CheckCaseConstraints(string(payload), "json", constraintConfig.Constraints); err != nil || len(violations) > 0 {
+			t.Fatalf("生成的用例应满足约束: %v %+v", err, violations)
+		}
+	}
+	if g.Err() != nil {
+		t.Fatalf("不应返回错误: %v", g.Err())
+	}
+
+	// 校验用例
+	checks := []struct {
+		name       string
+		payload    string
+		wantFields []string
+	}{
+		{name: "满足映射", payload: `{"city":"深圳","province":"广东","district":"南山区"}`},
+		{name: "省份不匹配", payload: `{"city":"杭州","province":"广东","district":"西湖区"}`, wantFields: []string{"province"}},
+		{name: "不在候选列表中", payload: `{"city":"广州","province":"广东","district":"西湖区"}`, wantFields: []string{"district"}},
+		{name: "映射中没有的值", payload: `{"city":"北京","province":"北京","district":"朝阳区"}`, wantFields: []string{"city", "district", "province"}},
+	}
+	for _, tt := range checks {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := CheckCaseConstraints(tt.payload, "json", constraintConfig.Constraints)
+			if err != nil {
+				t.Fatalf("校验失败: %v", err)
+			}
+			var fields []string
+			for _, violation := range violations {
+				fields = append(fields, violation.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("违反约束的字段 %v，期望 %v: %+v", fields, tt.wantFields, violations)
+			}
+		})
+	}
+
+	// 配置校验
+	validations := []struct {
+		name        string
+		constraints map[string]FieldConstraint
+		wantErr     string
+	}{
+		{name: "缺少mapping", constraints: map[string]FieldConstraint{
+			"province": {Type: "lookup", DependsOn: "city"},
+		}, wantErr: "非空的 'mapping'"},
+		{name: "depends_on用于其他类型", constraints: map[string]FieldConstraint{
+			"province": {Type: "enum", Values: []any{"广东"}, DependsOn: "city", Mapping: map[string]any{"广州": "广东"}},
+		}, wantErr: "只能用于 lookup"},
+		{name: "枚举候选值没有映射", constraints: map[string]FieldConstraint{
+			"city":     {Type: "enum", Values: []any{"广州", "北京"}},
+			"province": {Type: "lookup", DependsOn: "city", Mapping: map[string]any{"广州": "广东"}},
+		}, wantErr: "候选值 '北京' 在 mapping 中没有对应的取值"},
+		{name: "循环依赖", constraints: map[string]FieldConstraint{
+			"a": {Type: "lookup", DependsOn: "b", Mapping: map[string]any{"1": "2"}},
+			"b": {Type: "lookup", DependsOn: "a", Mapping: map[string]any{"2": "1"}},
+		}, wantErr: "循环依赖"},
+	}
+	for _, tt := range validations {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateConstraintDependencies(tt.constraints)
+			for name, constraint := range tt.constraints {
+				errors = append(errors, validateDependentConstraint(name, constraint)...)
+			}
+			if len(errors) == 0 || !strings.Contains(ValidationErrors(errors).Error(), tt.wantErr) {
+				t.Errorf("期望包含 %q 的错误，实际: %v", tt.wantErr, errors)
+			}
+		})
+	}
+}
+
+// TestBoundaryAndCombinatorialDependentValues 测试边界值用例和组合用例按依赖约束重新计算依赖字段
+func TestBoundaryAndCombinatorialDependentValues(t *testing.T) {
+	minPrice, maxPrice, minQuantity, maxQuantity := 1.0, 100.0, 1.0, 10.0
+	constraints := map[string]FieldConstraint{
+		"price":    {Type: "integer", Min: &minPrice, Max: &maxPrice},
+		"quantity": {Type: "integer", Min: &minQuantity, Max: &maxQuantity},
+		"total":    {Type: "integer", Min: &minPrice, Expr: "price * quantity"},
+	}
+	g := NewGenerator(&ConstraintConfig{Constraints: constraints}, DefaultVariationRate, 1)
+	g.Schema.KeyOrder = []string{"price", "quantity", "total"}
+	data := map[string]any{"price": 10, "quantity": 2, "total": 20}
+
+	checkTotals := func(name string, testCases []LabeledTestCase, skip string) {
+		t.Helper()
+		if len(testCases) == 0 {
+			t.Fatalf("%s: 没有生成用例", name)
+		}
+		for _, testCase := range testCases {
+			if skip != "" && strings.Contains(testCase.Label, skip) {
+				continue
+			}
+			violations, err := checkDependentConstraints(testCase.Data, constraints)
+			if err != nil || len(violations) > 0 {
+				t.Errorf("%s: 用例 %s 的 total 与 price * quantity 不一致: %v %v %+v", name, testCase.Label, testCase.Data, err, violations)
+			}
+		}
+	}
+
+	boundaryCases := g.GenerateBoundaryTestCases(data)
+	checkTotals("边界值", boundaryCases, "total=")
+	keptBoundary := false
+	for _, testCase := range boundaryCases {
+		if testCase.Label == "boundary:total=min" {
+			keptBoundary = testCase.Data["total"] == 1
+		}
+	}
+	if !keptBoundary {
+		t.Error("被测试的依赖字段应保持边界值 total=min")
+	}
+
+	result, err := g.GenerateCombinatorialTestCases(data, 2, 0)
+	if err != nil {
+		t.Fatalf("组合生成失败: %v", err)
+	}
+	for _, parameter := range result.Parameters {
+		if parameter.Field == "total" {
+			t.Errorf("依赖字段不应参与组合: %+v", result.Parameters)
+		}
+	}
+	checkTotals("组合", result.TestCases, "")
+	if err := g.Err(); err != nil {
+		t.Errorf("生成过程不应出错: %v", err)
+	}
+}
@@ -5,16 +5,20 @@ package utils
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -44,29 +48,207 @@ type DifyStreamEvent struct {
 	Message        string `json:"message"`         // error事件的错误消息
 }
 
+// DifyProvider 基于Dify Chatflow API的LLM提供方
+// 提示词由Dify工作流维护，正例报文作为query发送，其余参数通过inputs传递
+type DifyProvider struct {
+	BaseURL      string        // Dify API Base URL
+	APIKey       string        // Dify应用的API Key
+	Timeout      time.Duration // 单次请求的整体超时时间（0表示使用默认值300秒）
+	IdleTimeout  time.Duration // 流式响应的空闲超时时间，超过该时间未收到数据则中断（0表示使用默认值60秒）
+	Retries      int           // 失败后的重试次数
+	RetryBackoff time.Duration // 首次重试前的等待时间，之后每次翻倍（0表示使用默认值2秒）
+
+	usageRecorder
+}
+
+// Dify请求的默认重试参数
+const (
+	defaultDifyRetries      = 2
+	defaultDifyIdleTimeout  = 60 * time.Second
+	defaultDifyRetryBackoff = 2 * time.Second
+)
+
+// difyStreamResult 表示一次流式请求的结果，请求中断时包含已收到的部分文本
+type difyStreamResult struct {
+	Text           string   // 收集到的测试用例文本
+	ConversationID string   // 会话ID，用于在同一会话中继续生成
+	Usage          LLMUsage // message_end或workflow_finished事件中的token用量
+}
+
+// difyMetadata 表示message_end事件元数据中的用量信息
+type difyMetadata struct {
+	Usage struct {
+		PromptTokens     int         `json:"prompt_tokens"`
+		CompletionTokens int         `json:"completion_tokens"`
+		TotalTokens      int         `json:"total_tokens"`
+		TotalPrice       json.Number `json:"total_price"`
+		Currency         string      `json:"currency"`
+	} `json:"usage"`
+}
+
+// retryableError 表示可以重试的错误（网络错误、流式响应中断、限流和服务端错误）
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
 // GenerateTestCasesWithDify 使用Dify Chatflow API生成测试用例
+// 相同的URL、API Key、正例报文和inputs优先使用默认目录中的缓存结果
+//
+// Deprecated: 使用 GenerateTestCases 配合 DifyProvider 和 CachedProvider
 func GenerateTestCasesWithDify(apiKey, baseURL, query string, inputs map[string]any, format, outputFile string, debug bool) error {
+	req := GenerationRequest{
+		PositiveExample: query,
+		Format:          format,
+		ExtraInputs:     make(map[string]any),
+		Debug:           debug,
+	}
+	for key, value := range inputs {
+		switch key {
+		case "post_type":
+			// 报文格式以format参数为准
+		case "test_num":
+			num, err := strconv.Atoi(fmt.Sprint(value))
+			if err != nil {
+				return fmt.Errorf("test_num 必须是整数: %v", value)
+			}
+			req.Num = num
+		case "user_prompt":
+			req.UserPrompt = fmt.Sprint(value)
+		default:
+			req.ExtraInputs[key] = value
+		}
+	}
+
+	provider := &CachedProvider{
+		Provider: &DifyProvider{BaseURL: baseURL, APIKey: apiKey, Retries: defaultDifyRetries},
+		Cache:    NewLLMCache(""),
+		URL:      baseURL,
+		APIKey:   apiKey,
+	}
+	return GenerateTestCases(provider, req, outputFile)
+}
+
+// Name 返回提供方名称
+func (p *DifyProvider) Name() string {
+	return ProviderDify
+}
+
+// Generate 调用Dify Chatflow API生成测试用例文本
+func (p *DifyProvider) Generate(req GenerationRequest) (string, error) {
+	start := time.Now()
+	defer func() { p.record(LLMUsage{Calls: 1, Latency: time.Since(start)}) }()
+	query := req.PositiveExample
+	if req.Task == TaskAnalyze {
+		query = req.UserPrompt
+	}
+	return p.chat(query, generationInputs(req), req.Debug, req.Quiet)
+}
+
+// chat 发送chat-messages请求并返回流式响应中收集到的文本
+// 可重试的错误按指数退避重试；流式响应中断时保留已生成的用例，
+// 并在同一会话中请求继续生成剩余的用例。重试耗尽时返回已保留的用例和 ErrPartialResult
+// 失败分析的响应不是测试用例，中断时直接重新请求，不保留部分结果
+func (p *DifyProvider) chat(query string, inputs map[string]any, debug, quiet bool) (string, error) {
+	format, _ := inputs["post_type"].(string)
+	total, _ := inputs["test_num"].(int)
+	partialCases := func(text string) []string {
+		if inputs["task"] == TaskAnalyze {
+			return nil
+		}
+		return parsePartialTestCases(text, format)
+	}
+
+	backoff := p.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultDifyRetryBackoff
+	}
+
+	var preserved []string // 之前中断的请求中已生成的完整用例
+	conversationID := ""
+	currentQuery := query
+
+	for attempt := 0; ; attempt++ {
+		result, err := p.send(currentQuery, inputs, conversationID, debug, quiet)
+		// 重试前的请求同样消耗token，全部计入用量
+		p.record(result.Usage)
+		if err == nil {
+			if len(preserved) == 0 {
+				return result.Text, nil
+			}
+			return joinTestCases(appendUniqueTestCases(preserved, partialCases(result.Text)), format), nil
+		}
+
+		preserved = appendUniqueTestCases(preserved, partialCases(result.Text))
+
+		var retryErr *retryableError
+		if !errors.As(err, &retryErr) || attempt >= p.Retries {
+			if len(preserved) > 0 {
+				fmt.Printf("\n⚠️  LLM请求失败: %v\n", err)
+				fmt.Printf("⚠️  保留中断前已生成的 %d 个测试用例\n", len(preserved))
+				return joinTestCases(preserved, format), fmt.Errorf("%w（保留了中断前已生成的 %d 个测试用例）: %v", ErrPartialResult, len(preserved), err)
+			}
+			return "", err
+		}
+
+		// 已有部分用例且拿到会话ID时，在同一会话中继续生成剩余用例
+		if result.ConversationID != "" && len(preserved) > 0 {
+			conversationID = result.ConversationID
+			currentQuery = buildContinuationQuery(len(preserved), total)
+		}
+
+		wait := backoff << attempt
+		fmt.Printf("\n⚠️  LLM请求失败: %v\n", err)
+		if len(preserved) > 0 {
+			fmt.Printf("💾 已保留 %d 个测试用例", len(preserved))
+			if conversationID != "" {
+				fmt.Printf("，将在会话 %s 中继续生成", conversationID)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("🔄 %v 后进行第 %d/%d 次重试...\n", wait, attempt+1, p.Retries)
+		time.Sleep(wait)
+	}
+}
+
+// buildContinuationQuery 构建在同一会话中继续生成剩余用例的提问
+func buildContinuationQuery(generated, total int) string {
+	if total > generated {
+		return fmt.Sprintf("上一次回答在生成 %d 个测试用例后中断，请继续生成剩余的 %d 个测试用例，不要重复已生成的用例，输出格式保持不变", generated, total-generated)
+	}
+	return fmt.Sprintf("上一次回答在生成 %d 个测试用例后中断，请继续生成剩余的测试用例，不要重复已生成的用例，输出格式保持不变", generated)
+}
+
+// send 发送一次chat-messages请求，请求中断时返回已收到的部分结果和错误
+func (p *DifyProvider) send(query string, inputs map[string]any, conversationID string, debug, quiet bool) (difyStreamResult, error) {
+	apiKey := p.APIKey
+
 	// 构建请求URL - 使用新的chat-messages端点
-	chatflowURL := fmt.Sprintf("%s/chat-messages", strings.TrimSuffix(baseURL, "/"))
+	chatflowURL := fmt.Sprintf("%s/chat-messages", strings.TrimSuffix(p.BaseURL, "/"))
 
 	// 构建请求体
 	reqBody := DifyChatflowRequest{
-		Query:        query,
-		Inputs:       inputs,
-		ResponseMode: "streaming",
-		User:         generateUserID(),
+		Query:          query,
+		Inputs:         inputs,
+		ResponseMode:   "streaming",
+		User:           generateUserID(),
+		ConversationID: conversationID,
 	}
 
 	// 序列化请求体
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return fmt.Errorf("序列化请求体失败: %v", err)
+		return difyStreamResult{}, fmt.Errorf("序列化请求体失败: %v", err)
 	}
 
-	// 创建HTTP请求
-	req, err := http.NewRequest("POST", chatflowURL, bytes.NewBuffer(jsonData))
+	// 创建HTTP请求，空闲超时通过取消context中断流式响应
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", chatflowURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("创建请求失败: %v", err)
+		return difyStreamResult{}, fmt.Errorf("创建请求失败: %v", err)
 	}
 
 	// 设置请求头
@@ -102,10 +284,14 @@ func GenerateTestCasesWithDify(apiKey, baseURL, query string, inputs map[string]
 	}
 
 	// 发送请求
-	client := &http.Client{Timeout: 300 * time.Second}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = llmRequestTimeout
+	}
+	client := &http.Client{Timeout: timeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("发送请求失败: %v", err)
+		return difyStreamResult{}, &retryableError{fmt.Errorf("发送请求失败: %v", err)}
 	}
 	defer resp.Body.Close()
 
@@ -125,22 +311,51 @@ func GenerateTestCasesWithDify(apiKey, baseURL, query string, inputs map[string]
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API请求失败，状态码: %d，响应: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("API请求失败，状态码: %d，响应: %s", resp.StatusCode, string(body))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			return difyStreamResult{}, &retryableError{err}
+		}
+		return difyStreamResult{}, err
 	}
 
-	// 处理流式响应
-	return processStreamingResponse(resp.Body, format, outputFile, debug)
+	// 处理流式响应，超过空闲超时时间未收到数据则中断
+	idleTimeout := p.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultDifyIdleTimeout
+	}
+	var idle atomic.Bool
+	idleTimer := time.AfterFunc(idleTimeout, func() {
+		idle.Store(true)
+		cancel()
+	})
+	defer idleTimer.Stop()
+
+	result, err := processStreamingResponse(resp.Body, streamOutput(quiet), debug, func() { idleTimer.Reset(idleTimeout) })
+	if err != nil && idle.Load() {
+		err = &retryableError{fmt.Errorf("流式响应超过 %v 未收到数据", idleTimeout)}
+	}
+	return result, err
 }
 
-// processStreamingResponse 处理Dify API的流式响应
-func processStreamingResponse(body io.Reader, format, outputFile string, debug bool) error {
+// processStreamingResponse 处理Dify API的流式响应，返回收集到的测试用例文本
+// 流式文本片段实时写入out，每收到一行数据调用一次onData（可为nil）。
+// 读取中断时返回已收到的message文本，以便调用方保留部分结果
+func processStreamingResponse(body io.Reader, out io.Writer, debug bool, onData func()) (difyStreamResult, error) {
 	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
 	var collectedText strings.Builder
+	var streamedText strings.Builder // message事件的文本，用于中断时保留部分结果
+	var conversationID string
 	var errorMsg string
+	var usage LLMUsage
+	finished := false // 是否收到结束事件
 
-	fmt.Println("📡 开始接收流式数据...")
+	fmt.Fprintln(out, "📡 开始接收流式数据...")
 
 	for scanner.Scan() {
+		if onData != nil {
+			onData()
+		}
 		line := scanner.Text()
 		// 跳过空行和非data行
 		if !strings.HasPrefix(line, "data: ") {
@@ -157,6 +372,9 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 			fmt.Printf("⚠️  解析事件失败: %v\n", err)
 			continue
 		}
+		if event.ConversationID != "" {
+			conversationID = event.ConversationID
+		}
 		// Debug模式：显示原始响应数据
 		if debug {
 			fmt.Printf("\n🔍 [DEBUG] 收到事件: %s\n", event.Event)
@@ -168,7 +386,8 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 		case "message":
 			// LLM返回文本块事件，仅实时输出，不收集文本（避免重复收集）
 			if event.Answer != "" {
-				fmt.Print(event.Answer) // 实时流式输出文本片段
+				fmt.Fprint(out, event.Answer) // 实时流式输出文本片段
+				streamedText.WriteString(event.Answer)
 			}
 			if debug {
 				fmt.Printf("\n🔍 [DEBUG] Message ID: %s, Conversation ID: %s\n", event.MessageID, event.ConversationID)
@@ -185,7 +404,11 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 			}
 		case "message_end":
 			// 消息结束事件，收到此事件则代表流式返回结束
-			fmt.Println("\n\n✅ 消息接收完成!")
+			finished = true
+			fmt.Fprintln(out, "\n\n✅ 消息接收完成!")
+			if metadataUsage, ok := parseDifyUsage(event.Metadata); ok {
+				usage = metadataUsage
+			}
 			if debug {
 				fmt.Printf("🔍 [DEBUG] Message ID: %s, Conversation ID: %s\n", event.MessageID, event.ConversationID)
 				if event.Metadata != nil {
@@ -200,10 +423,12 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 				fmt.Printf("\n🔄 消息内容被替换: %s\n", event.Answer)
 				collectedText.Reset() // 清空之前收集的文本
 				collectedText.WriteString(event.Answer)
+				streamedText.Reset()
+				streamedText.WriteString(event.Answer)
 			}
 		case "workflow_started":
 			// Workflow开始执行
-			fmt.Println("🚀 Workflow开始执行...")
+			fmt.Fprintln(out, "🚀 Workflow开始执行...")
 			if debug {
 				fmt.Printf("🔍 [DEBUG] Workflow Run ID: %s\n", event.WorkflowRunID)
 				if event.Data != nil {
@@ -242,6 +467,7 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 			}
 		case "workflow_finished":
 			// Workflow执行结束
+			finished = true
 			if workflowData, ok := event.Data.(map[string]any); ok {
 				if debug {
 					if dataBytes, err := json.Marshal(workflowData); err == nil {
@@ -249,9 +475,14 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 					}
 				}
 
+				// workflow只提供总token数，message_end中有更详细的用量时以其为准
+				if totalTokens, ok := workflowData["total_tokens"].(float64); ok && usage.TotalTokens == 0 {
+					usage.TotalTokens = int(totalTokens)
+				}
+
 				if status, exists := workflowData["status"]; exists {
 					if status == "succeeded" {
-						fmt.Println("\n🎉 Workflow执行成功!")
+						fmt.Fprintln(out, "\n🎉 Workflow执行成功!")
 					} else {
 						fmt.Printf("\n❌ Workflow执行失败: %s\n", status)
 						if errorField, exists := workflowData["error"]; exists && errorField != nil {
@@ -292,7 +523,7 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 		case "error":
 			// 流式输出过程中出现的异常
 			fmt.Printf("\n❌ 流式响应错误: [%d] %s - %s\n", event.Status, event.Code, event.Message)
-			return fmt.Errorf("API返回错误: [%d] %s - %s", event.Status, event.Code, event.Message)
+			return difyStreamResult{}, fmt.Errorf("API返回错误: [%d] %s - %s", event.Status, event.Code, event.Message)
 		case "ping":
 			// 每10s一次的ping事件，保持连接存活
 			if debug {
@@ -312,29 +543,55 @@ func processStreamingResponse(body io.Reader, format, outputFile string, debug b
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取流式响应失败: %v", err)
+		partial := difyStreamResult{Text: streamedText.String(), ConversationID: conversationID, Usage: usage}
+		return partial, &retryableError{fmt.Errorf("读取流式响应失败: %v", err)}
+	}
+	if !finished {
+		partial := difyStreamResult{Text: streamedText.String(), ConversationID: conversationID, Usage: usage}
+		return partial, &retryableError{errors.New("流式响应在结束前中断")}
 	}
 
 	// 检查是否有错误
 	if errorMsg != "" {
-		return fmt.Errorf("处理过程中出现错误: %s", errorMsg)
+		return difyStreamResult{Usage: usage}, fmt.Errorf("处理过程中出现错误: %s", errorMsg)
 	}
 
-	// 解析生成的测试用例
+	// workflow未输出结果时使用message事件的文本
 	generatedText := collectedText.String()
 	if generatedText == "" {
-		fmt.Println("\n⚠️  当前API未返回测试用例数据，不生成文件")
-		return nil
+		generatedText = streamedText.String()
 	}
+	return difyStreamResult{Text: generatedText, ConversationID: conversationID, Usage: usage}, nil
+}
 
-	fmt.Println("\n📝 正在解析生成的测试用例...")
+// parseDifyUsage 从message_end事件的元数据中解析token用量和费用
+func parseDifyUsage(metadata any) (LLMUsage, bool) {
+	if metadata == nil {
+		return LLMUsage{}, false
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return LLMUsage{}, false
+	}
+	var parsed difyMetadata
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return LLMUsage{}, false
+	}
 
-	// 保存测试用例到CSV文件
-	return saveTestCasesToCSV(generatedText, format, outputFile)
+	usage := LLMUsage{
+		PromptTokens:     parsed.Usage.PromptTokens,
+		CompletionTokens: parsed.Usage.CompletionTokens,
+		TotalTokens:      parsed.Usage.TotalTokens,
+		Currency:         parsed.Usage.Currency,
+	}
+	if price, err := parsed.Usage.TotalPrice.Float64(); err == nil {
+		usage.Cost = price
+	}
+	return usage, usage.TotalTokens > 0 || usage.Cost > 0
 }
 
-// saveTestCasesToCSV 将生成的测试用例保存为CSV文件
-func saveTestCasesToCSV(generatedText, format, outputFile string) error {
+// writeTestCasesCSV 将已解析的测试用例写入CSV文件
+func writeTestCasesCSV(testCases []string, format, outputFile string) error {
 	// 确保输出目录存在
 	dir := filepath.Dir(outputFile)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -362,12 +619,6 @@ func saveTestCasesToCSV(generatedText, format, outputFile string) error {
 		}
 	}
 
-	// 解析生成的测试用例
-	testCases := parseGeneratedTestCases(generatedText, format)
-	if len(testCases) == 0 {
-		return fmt.Errorf("未能解析出有效的测试用例")
-	}
-
 	// 写入测试用例
 	for i, testCase := range testCases {
 		if err := writer.Write([]string{testCase}); err != nil {
@@ -607,6 +858,66 @@ func parseGeneratedTestCases(text, format string) []string {
 	return testCases
 }
 
+// parsePartialTestCases 解析可能在中途被截断的测试用例文本
+// 先按完整文本解析，失败时只提取其中完整的JSON对象或XML报文，丢弃被截断的最后一个
+func parsePartialTestCases(text, format string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if testCases := parseGeneratedTestCases(text, format); len(testCases) > 0 {
+		return testCases
+	}
+
+	// 去掉markdown代码块标记
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "```") {
+			lines = append(lines, line)
+		}
+	}
+	text = strings.Join(lines, "\n")
+
+	var testCases []string
+	if format == "xml" {
+		for _, xmlStr := range splitXMLObjectsByDelimiter(text) {
+			if ValidateXMLFormat(xmlStr) == nil {
+				testCases = append(testCases, xmlStr)
+			}
+		}
+		return testCases
+	}
+
+	for _, jsonStr := range splitConsecutiveJSONObjects(text) {
+		if ValidateJSONFormat(jsonStr) == nil {
+			testCases = append(testCases, jsonStr)
+		}
+	}
+	return testCases
+}
+
+// appendUniqueTestCases 将新用例追加到已有用例之后，跳过重复的用例
+func appendUniqueTestCases(existing, testCases []string) []string {
+	seenTestCases := make(map[string]bool, len(existing))
+	for _, testCase := range existing {
+		seenTestCases[testCase] = true
+	}
+	for _, testCase := range testCases {
+		if !seenTestCases[testCase] {
+			existing = append(existing, testCase)
+			seenTestCases[testCase] = true
+		}
+	}
+	return existing
+}
+
+// joinTestCases 将测试用例拼接为parseGeneratedTestCases可解析的文本
+func joinTestCases(testCases []string, format string) string {
+	if format == "xml" {
+		return strings.Join(testCases, "$$$$")
+	}
+	return "[" + strings.Join(testCases, ",") + "]"
+}
+
 // parseJSONArrayTestCases 解析JSON Array格式的测试用例
 // 输入格式：[{"data_field": value1}, {"data_field": value2}, ...]
 // 输出：每个JSON对象的字符串表示
@@ -1,9 +1,17 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestGenerateUserID 测试基于IP地址的用户ID生成功能
@@ -107,3 +115,158 @@ func TestGenerateUserIDCrossPlatform(t *testing.T) {
 
 	t.Logf("跨平台兼容性测试通过，用户ID: %s (长度: %d)", userID, len(userID))
 }
+
+// TestDifyProviderRetryContinuesConversation 测试流式响应中断后保留已生成的用例并在同一会话中继续生成
+func TestDifyProviderRetryContinuesConversation(t *testing.T) {
+	tests := []struct {
+		name      string
+		interrupt func(w http.ResponseWriter)
+	}{
+		{name: "连接提前关闭", interrupt: func(http.ResponseWriter) {}},
+		{name: "空闲超时", interrupt: func(http.ResponseWriter) { time.Sleep(500 * time.Millisecond) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []DifyChatflowRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req DifyChatflowRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				requests = append(requests, req)
+				w.Header().Set("Content-Type", "text/event-stream")
+
+				if len(requests) == 1 {
+					// 第一次请求：输出两个完整用例和一个被截断的用例后中断
+					fmt.Fprint(w, `data: {"event":"message","conversation_id":"conv-1","answer":"[{\"id\":1},{\"id\":2},{\"id\""}`+"\n\n")
+					w.(http.Flusher).Flush()
+					tt.interrupt(w)
+					return
+				}
+				fmt.Fprint(w, `data: {"event":"message","conversation_id":"conv-1","answer":"[{\"id\":3}]"}`+"\n\n")
+				fmt.Fprint(w, `data: {"event":"message_end","conversation_id":"conv-1"}`+"\n\n")
+			}))
+			defer server.Close()
+
+			provider := &DifyProvider{
+				BaseURL:      server.URL,
+				APIKey:       "app-test",
+				IdleTimeout:  100 * time.Millisecond,
+				Retries:      1,
+				RetryBackoff: time.Millisecond,
+			}
+			text, err := provider.Generate(GenerationRequest{PositiveExample: `{"id":0}`, Format: "json", Num: 3, Quiet: true})
+			if err != nil {
+				t.Fatalf("生成失败: %v", err)
+			}
+
+			if len(requests) != 2 {
+				t.Fatalf("期望请求2次，实际 %d 次", len(requests))
+			}
+			if requests[1].ConversationID != "conv-1" || !strings.Contains(requests[1].Query, "剩余的 1 个") {
+				t.Errorf("重试请求未在同一会话中继续生成: %+v", requests[1])
+			}
+			if got := parseGeneratedTestCases(text, "json"); len(got) != 3 {
+				t.Errorf("期望合并后得到3个用例，实际: %v", got)
+			}
+		})
+	}
+}
+
+// TestDifyProviderPartialResult 测试重试耗尽后返回已保留的用例和 ErrPartialResult
+func TestDifyProviderPartialResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"event":"message","answer":"[{\"id\":1},{\"id\""}`+"\n\n")
+	}))
+	defer server.Close()
+
+	provider := &DifyProvider{BaseURL: server.URL, APIKey: "app-test", Retries: 1, RetryBackoff: time.Millisecond}
+	text, err := provider.Generate(GenerationRequest{PositiveExample: `{"id":0}`, Format: "json", Num: 3, Quiet: true})
+	if !errors.Is(err, ErrPartialResult) {
+		t.Fatalf("期望返回 ErrPartialResult，实际: %v", err)
+	}
+	if got := parseGeneratedTestCases(text, "json"); len(got) != 1 {
+		t.Errorf("期望保留1个用例，实际: %v", got)
+	}
+}
+
+// TestGenerateTestCasesWithDify 测试兼容函数把inputs转换为生成请求并复用缓存
+func TestGenerateTestCasesWithDify(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var body DifyChatflowRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("解析请求体失败: %v", err)
+		}
+		if fmt.Sprint(body.Inputs["test_num"]) != "2" || body.Inputs["scene"] != "登录" {
+			t.Errorf("inputs 转换错误: %v", body.Inputs)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"event":"message","answer":"[{\"id\":1},{\"id\":2}]"}`+"\n\n")
+		fmt.Fprint(w, `data: {"event":"message_end","conversation_id":"conv-1"}`+"\n\n")
+	}))
+	defer server.Close()
+
+	inputs := map[string]any{"post_type": "json", "test_num": 2, "scene": "登录"}
+	for i := 0; i < 2; i++ {
+		outputFile := filepath.Join(t.TempDir(), "cases.csv")
+		if err := GenerateTestCasesWithDify("app-test", server.URL, `{"id":0}`, inputs, "json", outputFile, false); err != nil {
+			t.Fatalf("第 %d 次生成失败: %v", i+1, err)
+		}
+		if _, err := os.Stat(outputFile); err != nil {
+			t.Errorf("第 %d 次生成未写入文件: %v", i+1, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("第二次生成应命中缓存，实际请求 %d 次", calls)
+	}
+}
+
+// TestDifyProviderAnalysisNoPartialResult 测试失败分析中断时不把响应文本当作测试用例保留
+func TestDifyProviderAnalysisNoPartialResult(t *testing.T) {
+	var conversationIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		conversationID, _ := body["conversation_id"].(string)
+		conversationIDs = append(conversationIDs, conversationID)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"event":"message","conversation_id":"c1","answer":"分组1的请求体 [{\"id\":1},{\"id\""}`+"\n\n")
+	}))
+	defer server.Close()
+
+	provider := &DifyProvider{BaseURL: server.URL, APIKey: "app-test", Retries: 1, RetryBackoff: time.Millisecond}
+	text, err := provider.Generate(GenerationRequest{Task: TaskAnalyze, UserPrompt: "分析失败原因", Quiet: true})
+	if err == nil || errors.Is(err, ErrPartialResult) {
+		t.Fatalf("期望返回普通错误，实际: %v", err)
+	}
+	if text != "" {
+		t.Errorf("失败分析不应保留部分结果: %q", text)
+	}
+	if fmt.Sprint(conversationIDs) != "[ ]" {
+		t.Errorf("失败分析重试时不应在原会话中继续生成: %q", conversationIDs)
+	}
+}
+
+// TestDifyProviderNoRetryOnClientError 测试客户端错误不重试
+func TestDifyProviderNoRetryOnClientError(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	provider := &DifyProvider{BaseURL: server.URL, APIKey: "app-test", Retries: 3, RetryBackoff: time.Millisecond}
+	if _, err := provider.Generate(GenerationRequest{Format: "json", Num: 1, Quiet: true}); err == nil {
+		t.Fatal("期望返回错误")
+	}
+	if calls != 1 {
+		t.Errorf("客户端错误不应重试，实际请求 %d 次", calls)
+	}
+}
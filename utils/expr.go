@@ -0,0 +1,215 @@
+// Package utils 提供依赖约束使用的四则运算表达式解析和求值
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprNode 表达式语法树节点
+type exprNode interface {
+	// eval 计算节点的值，lookup 返回字段引用的数值
+	eval(lookup func(field string) (float64, error)) (float64, error)
+}
+
+// exprNumber 数字常量
+type exprNumber float64
+
+// exprField 字段引用，如 price、order.quantity
+type exprField string
+
+// exprNegate 取负
+type exprNegate struct {
+	operand exprNode
+}
+
+// exprBinary 二元运算（+ - * /）
+type exprBinary struct {
+	op          rune
+	left, right exprNode
+}
+
+func (n exprNumber) eval(func(string) (float64, error)) (float64, error) {
+	return float64(n), nil
+}
+
+func (n exprField) eval(lookup func(string) (float64, error)) (float64, error) {
+	return lookup(string(n))
+}
+
+func (n exprNegate) eval(lookup func(string) (float64, error)) (float64, error) {
+	value, err := n.operand.eval(lookup)
+	return -value, err
+}
+
+func (n exprBinary) eval(lookup func(string) (float64, error)) (float64, error) {
+	left, err := n.left.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	right, err := n.right.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	default:
+		if right == 0 {
+			return 0, fmt.Errorf("除数为0")
+		}
+		return left / right, nil
+	}
+}
+
+// exprParser 递归下降解析器，支持数字、字段引用、括号、正负号和四则运算
+type exprParser struct {
+	input []rune
+	pos   int
+}
+
+// parseExpr 解析依赖约束的表达式，如 order.price * order.quantity
+func parseExpr(expr string) (exprNode, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("表达式 'expr' 不能为空")
+	}
+	p := &exprParser{input: []rune(expr)}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, fmt.Errorf("无效的表达式 '%s': %v", expr, err)
+	}
+	if p.skipSpaces(); p.pos < len(p.input) {
+		return nil, fmt.Errorf("无效的表达式 '%s': 第 %d 个字符 '%c' 无法解析", expr, p.pos+1, p.input[p.pos])
+	}
+	return node, nil
+}
+
+// exprFields 返回表达式引用的字段，按出现顺序去重
+func exprFields(node exprNode) []string {
+	var fields []string
+	var walk func(exprNode)
+	walk = func(node exprNode) {
+		switch n := node.(type) {
+		case exprField:
+			for _, field := range fields {
+				if field == string(n) {
+					return
+				}
+			}
+			fields = append(fields, string(n))
+		case exprNegate:
+			walk(n.operand)
+		case exprBinary:
+			walk(n.left)
+			walk(n.right)
+		}
+	}
+	walk(node)
+	return fields
+}
+
+// skipSpaces 跳过空白字符
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// peek 返回下一个非空白字符，已到末尾时返回0
+func (p *exprParser) peek() rune {
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+// parseSum 解析加减运算
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseProduct 解析乘除运算
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFactor 解析数字、字段引用、括号表达式和正负号
+func (p *exprParser) parseFactor() (exprNode, error) {
+	switch r := p.peek(); {
+	case r == 0:
+		return nil, fmt.Errorf("表达式不完整")
+	case r == '-' || r == '+':
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil || r == '+' {
+			return operand, err
+		}
+		return exprNegate{operand: operand}, nil
+	case r == '(':
+		p.pos++
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		p.pos++
+		return node, nil
+	case unicode.IsDigit(r) || r == '.':
+		start := p.pos
+		for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字 '%s'", string(p.input[start:p.pos]))
+		}
+		return exprNumber(value), nil
+	case unicode.IsLetter(r) || r == '_':
+		// 字段引用可以包含路径分隔符和数组下标，如 order.items[0].price
+		start := p.pos
+		for p.pos < len(p.input) && isExprFieldRune(p.input[p.pos]) {
+			p.pos++
+		}
+		return exprField(p.input[start:p.pos]), nil
+	default:
+		return nil, fmt.Errorf("第 %d 个字符 '%c' 无法解析", p.pos+1, r)
+	}
+}
+
+// isExprFieldRune 判断字符是否可以出现在字段引用中
+func isExprFieldRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '[' || r == ']'
+}
@@ -10,12 +10,21 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode/utf8"
 )
 
+// CSVMetadataPrefix CSV文件开头元数据行的前缀，元数据行形如 "# seed=42"
+const CSVMetadataPrefix = "# "
+
 // SaveToCSV 将数据保存为CSV文件
 func SaveToCSV(data [][]string, filePath string) error {
+	return SaveToCSVWithMetadata(data, filePath, nil)
+}
+
+// SaveToCSVWithMetadata 将数据保存为CSV文件，表头之前按键名顺序写入元数据行
+func SaveToCSVWithMetadata(data [][]string, filePath string, metadata map[string]string) error {
 	// 如果未指定文件路径，则使用默认路径
 	if filePath == "" {
 		filePath = "result.csv"
@@ -36,6 +45,18 @@ func SaveToCSV(data [][]string, filePath string) error {
 	}
 	defer file.Close()
 
+	// 写入元数据
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(file, "%s%s=%s\n", CSVMetadataPrefix, key, metadata[key]); err != nil {
+			return fmt.Errorf("写入CSV元数据失败: %v", err)
+		}
+	}
+
 	// 写入CSV
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -55,23 +76,64 @@ func SaveToCSV(data [][]string, filePath string) error {
 	return nil
 }
 
-// ReadCSV 从CSV文件读取数据
+// ReadCSV 从CSV文件读取数据，跳过文件开头的元数据行
 func ReadCSV(filePath string) ([][]string, error) {
-	// 打开文件
-	file, err := os.Open(filePath)
+	records, _, err := readCSVWithMetadata(filePath)
+	return records, err
+}
+
+// ReadCSVMetadata 读取CSV文件开头的元数据，没有元数据时返回空map
+func ReadCSVMetadata(filePath string) (map[string]string, error) {
+	_, metadata, err := readCSVWithMetadata(filePath)
+	return metadata, err
+}
+
+// readCSVWithMetadata 读取CSV文件的数据和开头的元数据
+func readCSVWithMetadata(filePath string) ([][]string, map[string]string, error) {
+	// 读取文件
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("打开文件失败: %v", err)
+		return nil, nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+
+	// 解析元数据行
+	metadata := make(map[string]string)
+	text := string(content)
+	for strings.HasPrefix(text, CSVMetadataPrefix) {
+		line, rest, _ := strings.Cut(text, "\n")
+		if key, value, ok := strings.Cut(strings.TrimPrefix(strings.TrimSuffix(line, "\r"), CSVMetadataPrefix), "="); ok {
+			metadata[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		text = rest
 	}
-	defer file.Close()
 
 	// 读取CSV
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(strings.NewReader(text))
 	records, err := reader.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("读取CSV失败: %v", err)
+		return nil, nil, fmt.Errorf("读取CSV失败: %v", err)
 	}
 
-	return records, nil
+	return records, metadata, nil
+}
+
+// DetectCSVPayloadFormat 检测单列报文CSV的格式，返回 xml、json，无法识别时返回空字符串
+// 报文列之后允许有一列用例标签（LABEL）
+func DetectCSVPayloadFormat(headers []string) string {
+	if len(headers) == 0 || len(headers) > 2 {
+		return ""
+	}
+	if len(headers) == 2 && !strings.EqualFold(headers[1], CaseLabelHeader) {
+		return ""
+	}
+	switch strings.ToUpper(headers[0]) {
+	case "XML":
+		return "xml"
+	case "JSON":
+		return "json"
+	default:
+		return ""
+	}
 }
 
 // ReadFileContent 读取文件内容并返回字符串
@@ -129,3 +129,26 @@ func contains(s, substr string) bool {
 			return false
 		}()))
 }
+
+// TestCSVMetadata 测试CSV元数据的写入和读取
+func TestCSVMetadata(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "cases.csv")
+	rows := [][]string{{"JSON", CaseLabelHeader}, {`{"name":"# 张三"}`, "positive"}}
+	if err := SaveToCSVWithMetadata(rows, filePath, map[string]string{"seed": "42", "mode": "random"}); err != nil {
+		t.Fatalf("保存CSV失败: %v", err)
+	}
+
+	content, _ := os.ReadFile(filePath)
+	if !contains(string(content), "# mode=random\n# seed=42\nJSON,LABEL\n") {
+		t.Errorf("元数据应按键名顺序写在表头之前: %s", content)
+	}
+
+	records, err := ReadCSV(filePath)
+	if err != nil || len(records) != 2 || records[0][0] != "JSON" || records[1][0] != `{"name":"# 张三"}` {
+		t.Errorf("读取CSV应跳过元数据行: %v, %v", records, err)
+	}
+	metadata, err := ReadCSVMetadata(filePath)
+	if err != nil || metadata["seed"] != "42" || len(metadata) != 2 {
+		t.Errorf("读取元数据错误: %v, %v", metadata, err)
+	}
+}
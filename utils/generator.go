@@ -0,0 +1,82 @@
+// Package utils 提供测试用例生成器，保存单次生成所需的全部状态
+package utils
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultVariationRate 默认的随机化因子
+const DefaultVariationRate = 0.5
+
+// ReferenceDateLayout 参考日期的格式，用于命令行参数、配置文件和CSV元数据
+const ReferenceDateLayout = "2006-01-02"
+
+// Schema 表示解析正例报文时记录的报文结构，用于按原始格式输出生成的用例
+type Schema struct {
+	KeyOrder          []string          // 顶层字段的原始顺序
+	ValueTypes        map[string]string // 顶层字段的原始类型，生成随机用例时记录
+	RootElementName   string            // XML根元素名称
+	HasXMLDeclaration bool              // 原始XML是否包含XML声明
+	XMLDeclaration    string            // 原始XML声明
+}
+
+// Generator 测试用例生成器，保存报文结构、约束配置、随机化因子、随机数生成器以及序列和唯一值的生成状态
+// 不同的Generator互不影响，可以在多个goroutine中分别使用；同一个Generator不能并发使用
+type Generator struct {
+	Schema        Schema            // 最近一次解析的报文结构
+	Constraints   *ConstraintConfig // 约束配置，为nil时不使用约束
+	VariationRate float64           // 随机化因子（0.0-1.0）
+	ReferenceDate time.Time         // 年龄约束（min_age、max_age）的参考日期，默认为创建生成器的当天（UTC）
+	rng           *rand.Rand
+	sequences     map[string]int             // 各序列约束已生成的值的个数
+	uniqueValues  map[string]map[string]bool // 各唯一值约束已生成的值
+	err           error                      // 生成过程中遇到的第一个错误
+}
+
+// defaultGenerator 包级生成函数使用的默认生成器
+// 包级函数共享该生成器，不能并发调用；需要并发生成时应为每个goroutine创建独立的Generator
+var defaultGenerator = NewGenerator(nil, DefaultVariationRate, NewRandomSeed())
+
+// NewGenerator 创建测试用例生成器，种子和输入相同时生成的用例相同
+func NewGenerator(constraints *ConstraintConfig, variationRate float64, seed int64) *Generator {
+	return &Generator{
+		Constraints:   constraints,
+		VariationRate: variationRate,
+		ReferenceDate: Today(),
+		rng:           rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Today 返回当天的日期（UTC零点），作为默认的参考日期
+func Today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// NewRandomSeed 返回基于当前时间的随机数种子
+func NewRandomSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// Seed 重新设置生成器的随机数种子
+func (g *Generator) Seed(seed int64) {
+	g.rng = rand.New(rand.NewSource(seed))
+}
+
+// setErr 记录生成过程中遇到的第一个错误
+func (g *Generator) setErr(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// SetRandomSeed 设置默认生成器的随机数种子，种子和输入相同时生成的用例相同
+func SetRandomSeed(seed int64) {
+	defaultGenerator.Seed(seed)
+}
+
+// SetConstraintConfig 设置默认生成器使用的约束配置，为nil时不使用约束
+func SetConstraintConfig(config *ConstraintConfig) {
+	defaultGenerator.Constraints = config
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestGeneratorSeed 测试相同种子生成相同的用例
+func TestGeneratorSeed(t *testing.T) {
+	minAge, maxAge := 18.0, 60.0
+	constraints := &ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"age":  {Type: "integer", Min: &minAge, Max: &maxAge},
+		"name": {Type: "chinese_name"},
+	}}
+	data := map[string]any{"name": "张三", "user": map[string]any{"age": 30, "city": "beijing", "vip": true}, "amount": 12.5}
+
+	for _, config := range []*ConstraintConfig{nil, constraints} {
+		generate := func(seed int64) string {
+			g := NewGenerator(config, DefaultVariationRate, seed)
+			g.Schema.KeyOrder = []string{"name", "user", "amount"}
+			return fmt.Sprint(g.ConvertToJSONRows(g.GenerateTestCases(data, 20)))
+		}
+		first := generate(42)
+		if again := generate(42); again != first {
+			t.Errorf("相同种子生成的用例不同（约束: %v）", config != nil)
+		}
+		if other := generate(43); other == first {
+			t.Errorf("不同种子生成的用例相同（约束: %v）", config != nil)
+		}
+	}
+}
+
+// TestGeneratorConcurrent 测试多个生成器并发解析和生成时互不影响
+func TestGeneratorConcurrent(t *testing.T) {
+	payloads := []struct {
+		content string
+		isXML   bool
+	}{
+		{content: `{"name":"张三","age":25}`},
+		{content: `<?xml version="1.0" encoding="GBK"?><order><id>1001</id><amount>9.99</amount></order>`, isXML: true},
+		{content: `{"user":{"id":"u1"},"tags":["a","b"],"ok":true}`},
+		{content: `<request><city>beijing</city></request>`, isXML: true},
+	}
+
+	generate := func(content string, isXML bool, seed int64) string {
+		g := NewGenerator(nil, DefaultVariationRate, seed)
+		if isXML {
+			data, err := g.ParseXML(content)
+			if err != nil {
+				return err.Error()
+			}
+			return fmt.Sprint(g.ConvertToXMLRows(g.GenerateTestCases(data, 5)))
+		}
+		data, err := g.ParseJSON(content)
+		if err != nil {
+			return err.Error()
+		}
+		return fmt.Sprint(g.ConvertToJSONRows(g.GenerateTestCases(data, 5)))
+	}
+
+	want := make([]string, len(payloads))
+	for i, payload := range payloads {
+		want[i] = generate(payload.content, payload.isXML, int64(i))
+	}
+
+	var wg sync.WaitGroup
+	got := make([]string, len(payloads)*10)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			payload := payloads[i%len(payloads)]
+			got[i] = generate(payload.content, payload.isXML, int64(i%len(payloads)))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range got {
+		if result != want[i%len(payloads)] {
+			t.Errorf("并发生成的结果与单独生成不同:\n期望 %s\n实际 %s", want[i%len(payloads)], result)
+		}
+	}
+	if want[1] == want[3] || !contains(want[1], `<?xml version="1.0" encoding="GBK"?>`) || contains(want[3], "<?xml") {
+		t.Errorf("XML声明和根元素应只属于各自的生成器: %s / %s", want[1], want[3])
+	}
+}
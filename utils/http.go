@@ -5,35 +5,186 @@ package utils
 import (
 	"bytes"
 	"crypto/tls"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
 	"time"
 )
 
 // HTTPRequest HTTP请求结构体
 type HTTPRequest struct {
-	URL       string            `json:"url"`       // 请求URL
-	Method    string            `json:"method"`    // 请求方法
-	Headers   map[string]string `json:"headers"`   // 请求头
-	Body      string            `json:"body"`      // 请求体
-	Timeout   int               `json:"timeout"`   // 超时时间（秒）
-	IgnoreTLS bool              `json:"ignore_tls"` // 忽略TLS证书验证
+	URL       string              `json:"url"`           // 请求URL
+	Method    string              `json:"method"`        // 请求方法
+	Headers   map[string]string   `json:"headers"`       // 请求头
+	Body      string              `json:"body"`          // 请求体
+	Timeout   int                 `json:"timeout"`       // 超时时间（秒）
+	IgnoreTLS bool                `json:"ignore_tls"`    // 忽略TLS证书验证
+	Redirect  RedirectPolicy      `json:"redirect"`      // 重定向策略
+	Response  ResponseBodyOptions `json:"response_body"` // 响应体读取选项
+}
+
+// RedirectPolicy 重定向策略
+// 零值与Go默认行为一致：跟随重定向，最多10次
+type RedirectPolicy struct {
+	Disabled     bool `json:"disabled"`       // 不跟随重定向，直接返回3xx响应
+	MaxRedirects int  `json:"max_redirects"`  // 最大重定向次数（0表示使用默认值10）
+	SameHostOnly bool `json:"same_host_only"` // 只跟随同一主机内的重定向
+}
+
+// defaultMaxRedirects 默认最大重定向次数，与net/http保持一致
+const defaultMaxRedirects = 10
+
+// RedirectHop 表示重定向链中的一跳
+type RedirectHop struct {
+	URL        string `json:"url"`         // 返回重定向的请求URL
+	StatusCode int    `json:"status_code"` // 重定向状态码
+	Location   string `json:"location"`    // Location响应头
 }
 
 // HTTPResponse 表示HTTP响应的结构
 type HTTPResponse struct {
-	StatusCode int
-	Headers    map[string][]string
-	Body       string
-	Error      error
-	Duration   time.Duration
+	StatusCode     int
+	Headers        map[string][]string
+	Body           string
+	Error          error
+	Duration       time.Duration
+	FinalURL       string              // 跟随重定向后的最终URL
+	Redirects      []RedirectHop       // 重定向链（包含未被跟随的最后一跳）
+	RequestHeaders map[string][]string // 实际写入连接的请求头（包含Transport自动添加的头）
+	Timing         HTTPTiming          // 各阶段耗时
+	BodyInfo       ResponseBodyInfo    // 响应体读取统计信息
+}
+
+// HTTPTiming 表示基于httptrace统计的请求各阶段耗时
+// 发生重定向时各阶段只统计最后一跳，包含所有跳的总耗时见 HTTPResponse.Duration
+type HTTPTiming struct {
+	DNS      time.Duration // DNS解析耗时
+	Connect  time.Duration // TCP连接耗时
+	TLS      time.Duration // TLS握手耗时
+	TTFB     time.Duration // 请求写完到收到首字节的耗时
+	Download time.Duration // 收到首字节到读完响应体的耗时
+}
+
+// requestTracer 记录单个请求的httptrace事件
+// 发生重定向时各阶段会多次触发，每一跳开始时重置，耗时和请求头均以最后一跳为准
+type requestTracer struct {
+	mu             sync.Mutex
+	dnsStart       time.Time
+	connectStart   time.Time
+	tlsStart       time.Time
+	wroteRequest   time.Time
+	firstByte      time.Time
+	timing         HTTPTiming
+	requestHeaders map[string][]string
+}
+
+// clientTrace 构建绑定到当前tracer的httptrace.ClientTrace
+func (t *requestTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// 每一跳开始时重置耗时和请求头
+			t.timing = HTTPTiming{}
+			t.dnsStart, t.connectStart, t.tlsStart, t.wroteRequest, t.firstByte = time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}
+			t.requestHeaders = make(map[string][]string)
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !t.dnsStart.IsZero() {
+				t.timing.DNS += time.Since(t.dnsStart)
+			}
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !t.connectStart.IsZero() {
+				t.timing.Connect += time.Since(t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !t.tlsStart.IsZero() {
+				t.timing.TLS += time.Since(t.tlsStart)
+			}
+		},
+		WroteHeaderField: func(key string, value []string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.requestHeaders == nil {
+				t.requestHeaders = make(map[string][]string)
+			}
+			t.requestHeaders[key] = append(t.requestHeaders[key], value...)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.wroteRequest = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Now()
+			if !t.wroteRequest.IsZero() {
+				t.timing.TTFB += t.firstByte.Sub(t.wroteRequest)
+			}
+		},
+	}
+}
+
+// finish 在响应体读取完成后记录下载耗时并返回统计结果
+func (t *requestTracer) finish() (HTTPTiming, map[string][]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.firstByte.IsZero() {
+		t.timing.Download = time.Since(t.firstByte)
+	}
+	return t.timing, t.requestHeaders
+}
+
+// 共享的Transport，以便在多个请求之间复用连接
+// 关闭自动解压，由readResponseBody负责解压并统计压缩前后的大小
+var (
+	defaultTransport  = newTransport(false)
+	insecureTransport = newTransport(true)
+)
+
+// newTransport 创建HTTP Transport
+func newTransport(ignoreTLS bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+	if ignoreTLS {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return transport
 }
 
 // SendRequest 发送HTTP请求
-func SendRequest(req HTTPRequest) HTTPResponse {
+func SendRequest(req HTTPRequest) (response HTTPResponse) {
 	start := time.Now()
-	response := HTTPResponse{}
+	// 所有返回路径都记录总耗时
+	defer func() {
+		response.Duration = time.Since(start)
+	}()
 
 	// 设置请求方法和URL
 	httpMethod := req.Method
@@ -53,6 +204,15 @@ func SendRequest(req HTTPRequest) HTTPResponse {
 		httpReq.Header.Set(key, value)
 	}
 
+	// 未显式指定时主动声明支持gzip，与net/http的默认行为保持一致
+	if httpReq.Header.Get("Accept-Encoding") == "" {
+		httpReq.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	// 挂载httptrace以统计各阶段耗时和实际发送的请求头
+	tracer := &requestTracer{}
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), tracer.clientTrace()))
+
 	// 设置超时时间
 	timeout := 30 // 默认30秒
 	if req.Timeout > 0 {
@@ -61,40 +221,81 @@ func SendRequest(req HTTPRequest) HTTPResponse {
 
 	// 创建客户端
 	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: defaultTransport,
 	}
 
 	// 如果配置了忽略TLS证书验证，则直接使用不安全的客户端
 	if req.IgnoreTLS {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		client.Transport = insecureTransport
+	}
+
+	// 设置重定向策略并记录重定向链
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if next.Response != nil {
+			response.Redirects = append(response.Redirects, RedirectHop{
+				URL:        via[len(via)-1].URL.String(),
+				StatusCode: next.Response.StatusCode,
+				Location:   next.Response.Header.Get("Location"),
+			})
 		}
+		return checkRedirect(req.Redirect, next, via)
 	}
 
 	// 发送请求
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		response.Error = fmt.Errorf("发送请求失败: %v", err)
+		response.Timing, response.RequestHeaders = tracer.finish()
 		return response
 	}
 	defer resp.Body.Close()
 
+	// 设置响应信息
+	response.StatusCode = resp.StatusCode
+	response.Headers = resp.Header
+	if resp.Request != nil && resp.Request.URL != nil {
+		response.FinalURL = resp.Request.URL.String()
+	}
+
 	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
+	body, bodyInfo, err := readResponseBody(resp.Body, resp.Header, req.Response)
+	response.Timing, response.RequestHeaders = tracer.finish()
+	response.BodyInfo = bodyInfo
 	if err != nil {
-		response.Error = fmt.Errorf("读取响应失败: %v", err)
+		response.Error = err
 		return response
 	}
-
-	// 设置响应信息
-	response.StatusCode = resp.StatusCode
-	response.Headers = resp.Header
-	response.Body = string(body)
-	response.Duration = time.Since(start)
+	response.Body = body
 
 	return response
 }
 
+// errRedirectLimit 超过最大重定向次数
+var errRedirectLimit = errors.New("超过最大重定向次数")
+
+// checkRedirect 根据重定向策略决定是否跟随下一跳
+// 不跟随时返回http.ErrUseLastResponse，使调用方拿到原始3xx响应
+func checkRedirect(policy RedirectPolicy, next *http.Request, via []*http.Request) error {
+	if policy.Disabled {
+		return http.ErrUseLastResponse
+	}
+
+	if policy.SameHostOnly && next.URL.Host != via[0].URL.Host {
+		return http.ErrUseLastResponse
+	}
+
+	maxRedirects := policy.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	if len(via) > maxRedirects {
+		return fmt.Errorf("%w (%d)", errRedirectLimit, maxRedirects)
+	}
+
+	return nil
+}
+
 // SendConcurrentRequests 并发发送多个HTTP请求
 func SendConcurrentRequests(requests []HTTPRequest, concurrency int) []HTTPResponse {
 	if concurrency <= 0 {
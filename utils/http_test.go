@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSendRequestCapturesHeadersAndTiming 测试响应头、实际请求头、最终URL和耗时分解的记录
+func TestSendRequestCapturesHeadersAndTiming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Header().Set("X-Test", "ok")
+		_, _ = w.Write([]byte(`{"result":"ok"}`))
+	}))
+	defer server.Close()
+
+	resp := SendRequest(HTTPRequest{
+		URL:     server.URL + "/old",
+		Method:  "POST",
+		Body:    `{"name":"test"}`,
+		Headers: map[string]string{"Content-Type": "application/json"},
+	})
+	if resp.Error != nil {
+		t.Fatalf("请求失败: %v", resp.Error)
+	}
+
+	if resp.FinalURL != server.URL+"/new" {
+		t.Errorf("最终URL错误: 期望 %s，实际 %s", server.URL+"/new", resp.FinalURL)
+	}
+	if got := resp.Headers["X-Test"]; len(got) != 1 || got[0] != "ok" {
+		t.Errorf("响应头未正确记录: %v", resp.Headers)
+	}
+	// Transport会自动添加User-Agent，应出现在实际发送的请求头中
+	if _, ok := resp.RequestHeaders["User-Agent"]; !ok {
+		t.Errorf("实际发送的请求头中缺少User-Agent: %v", resp.RequestHeaders)
+	}
+	if resp.Timing.TTFB <= 0 {
+		t.Errorf("首字节耗时应大于0，实际: %v", resp.Timing.TTFB)
+	}
+
+	// 重定向的最后一跳复用了连接，新服务器的首个请求才会记录连接耗时
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer direct.Close()
+	if resp := SendRequest(HTTPRequest{URL: direct.URL}); resp.Timing.Connect <= 0 {
+		t.Errorf("连接耗时应大于0，实际: %v", resp.Timing.Connect)
+	}
+}
+
+// TestSendRequestTimingFinalHop 测试发生重定向时耗时分解只统计最后一跳，总耗时包含所有跳
+func TestSendRequestTimingFinalHop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+			http.Redirect(w, r, "/fast", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp := SendRequest(HTTPRequest{URL: server.URL + "/slow"})
+	if resp.Error != nil {
+		t.Fatalf("请求失败: %v", resp.Error)
+	}
+	if resp.Timing.TTFB >= 100*time.Millisecond {
+		t.Errorf("首字节耗时应只统计最后一跳，实际: %v", resp.Timing.TTFB)
+	}
+	if resp.Duration < 100*time.Millisecond {
+		t.Errorf("总耗时应包含所有跳，实际: %v", resp.Duration)
+	}
+
+	// 创建请求失败时同样记录总耗时
+	resp = SendRequest(HTTPRequest{URL: "://invalid"})
+	if resp.Error == nil || resp.Duration <= 0 {
+		t.Errorf("创建请求失败时应返回错误并记录耗时: %v %v", resp.Error, resp.Duration)
+	}
+}
+
+// TestSendRequestRedirectPolicy 测试重定向策略与重定向链记录
+func TestSendRequestRedirectPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/external":
+			http.Redirect(w, r, "http://example.invalid/login", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		policy       RedirectPolicy
+		wantStatus   int
+		wantHops     int
+		wantErr      bool
+		wantFinalURL string
+	}{
+		{"默认跟随", "/a", RedirectPolicy{}, http.StatusOK, 2, false, server.URL + "/login"},
+		{"不跟随", "/a", RedirectPolicy{Disabled: true}, http.StatusMovedPermanently, 1, false, server.URL + "/a"},
+		{"超过最大次数", "/a", RedirectPolicy{MaxRedirects: 1}, 0, 2, true, ""},
+		{"仅同主机", "/external", RedirectPolicy{SameHostOnly: true}, http.StatusFound, 1, false, server.URL + "/external"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := SendRequest(HTTPRequest{URL: server.URL + tt.path, Method: "GET", Redirect: tt.policy})
+			if (resp.Error != nil) != tt.wantErr {
+				t.Fatalf("错误不符合预期: %v", resp.Error)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("状态码错误: 期望 %d，实际 %d", tt.wantStatus, resp.StatusCode)
+			}
+			if len(resp.Redirects) != tt.wantHops {
+				t.Errorf("重定向链长度错误: 期望 %d，实际 %d (%v)", tt.wantHops, len(resp.Redirects), resp.Redirects)
+			}
+			if resp.FinalURL != tt.wantFinalURL {
+				t.Errorf("最终URL错误: 期望 %s，实际 %s", tt.wantFinalURL, resp.FinalURL)
+			}
+			if len(resp.Redirects) > 0 && resp.Redirects[0].Location == "" {
+				t.Errorf("重定向链缺少Location: %v", resp.Redirects)
+			}
+		})
+	}
+}
+
+// TestSendRequestBodyHandling 测试响应体大小限制、gzip解压统计与二进制内容处理
+func TestSendRequestBodyHandling(t *testing.T) {
+	largeText := strings.Repeat("a", 4096)
+	binaryData := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write([]byte(largeText))
+			_ = gz.Close()
+		case "/binary":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(binaryData)
+		case "/zlib":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "deflate")
+			zw := zlib.NewWriter(w)
+			_, _ = zw.Write([]byte(largeText))
+			_ = zw.Close()
+		case "/raw-deflate":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "deflate")
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			_, _ = fw.Write([]byte(largeText))
+			_ = fw.Close()
+		}
+	}))
+	defer server.Close()
+
+	t.Run("gzip解压与截断", func(t *testing.T) {
+		resp := SendRequest(HTTPRequest{URL: server.URL + "/gzip", Response: ResponseBodyOptions{MaxSize: 1024}})
+		if resp.Error != nil {
+			t.Fatalf("请求失败: %v", resp.Error)
+		}
+		if len(resp.Body) != 1024 || !resp.BodyInfo.Truncated {
+			t.Errorf("响应体应被截断为1024字节，实际 %d 字节，截断标记 %v", len(resp.Body), resp.BodyInfo.Truncated)
+		}
+		if resp.BodyInfo.ContentEncoding != "gzip" || resp.BodyInfo.CompressedSize <= 0 {
+			t.Errorf("压缩统计错误: %+v", resp.BodyInfo)
+		}
+	})
+
+	t.Run("deflate按zlib格式解压并兼容原始deflate", func(t *testing.T) {
+		for _, path := range []string{"/zlib", "/raw-deflate"} {
+			resp := SendRequest(HTTPRequest{URL: server.URL + path})
+			if resp.Error != nil {
+				t.Fatalf("%s 请求失败: %v", path, resp.Error)
+			}
+			if resp.Body != largeText || resp.BodyInfo.Size != int64(len(largeText)) || resp.BodyInfo.ContentEncoding != "deflate" {
+				t.Errorf("%s 解压错误: %d 字节 %+v", path, len(resp.Body), resp.BodyInfo)
+			}
+		}
+	})
+
+	t.Run("截断后继续统计大小", func(t *testing.T) {
+		resp := SendRequest(HTTPRequest{URL: server.URL + "/gzip", Response: ResponseBodyOptions{MaxSize: 1024}})
+		if resp.BodyInfo.Size != int64(len(largeText)) {
+			t.Errorf("截断时应统计完整大小 %d，实际 %d", len(largeText), resp.BodyInfo.Size)
+		}
+
+		// 超过统计上限时大小未知
+		resp = SendRequest(HTTPRequest{URL: server.URL + "/gzip", Response: ResponseBodyOptions{MaxSize: 1024, MaxSavedSize: 2048}})
+		if !resp.BodyInfo.Truncated || resp.BodyInfo.Size != UnknownBodySize {
+			t.Errorf("超过统计上限时大小应为未知: %+v", resp.BodyInfo)
+		}
+	})
+
+	t.Run("保存的文件不超过保存上限", func(t *testing.T) {
+		dir := t.TempDir()
+		resp := SendRequest(HTTPRequest{
+			URL:      server.URL + "/gzip",
+			Response: ResponseBodyOptions{MaxSize: 1024, MaxSavedSize: 2048, SaveDir: dir},
+		})
+		if resp.Error != nil {
+			t.Fatalf("请求失败: %v", resp.Error)
+		}
+		if !resp.BodyInfo.SavedTruncated || resp.BodyInfo.Size != UnknownBodySize || filepath.Base(resp.BodyInfo.SavedPath) != "response.truncated.txt" {
+			t.Errorf("保存的文件应标记为截断: %+v", resp.BodyInfo)
+		}
+		saved, err := os.ReadFile(resp.BodyInfo.SavedPath)
+		if err != nil || len(saved) != 2048 {
+			t.Errorf("保存的文件应为2048字节，实际 %d 字节: %v", len(saved), err)
+		}
+	})
+
+	t.Run("二进制内容默认保存哈希", func(t *testing.T) {
+		resp := SendRequest(HTTPRequest{URL: server.URL + "/binary"})
+		sum := sha256.Sum256(binaryData)
+		if resp.BodyInfo.Encoding != BodyEncodingSHA256 || resp.Body != hex.EncodeToString(sum[:]) {
+			t.Errorf("二进制响应体哈希错误: %s (%s)", resp.Body, resp.BodyInfo.Encoding)
+		}
+	})
+
+	t.Run("二进制内容保存Base64并写入文件", func(t *testing.T) {
+		dir := t.TempDir()
+		resp := SendRequest(HTTPRequest{
+			URL:      server.URL + "/binary",
+			Response: ResponseBodyOptions{BinaryMode: BinaryBodyBase64, SaveDir: dir},
+		})
+		if resp.BodyInfo.Encoding != BodyEncodingBase64 || resp.Body != base64.StdEncoding.EncodeToString(binaryData) {
+			t.Errorf("二进制响应体Base64错误: %s", resp.Body)
+		}
+		saved, err := os.ReadFile(resp.BodyInfo.SavedPath)
+		if err != nil {
+			t.Fatalf("读取保存的响应体失败: %v", err)
+		}
+		if !bytes.Equal(saved, binaryData) {
+			t.Errorf("保存的响应体内容不一致")
+		}
+		if filepath.Ext(resp.BodyInfo.SavedPath) != ".png" {
+			t.Errorf("响应体文件扩展名错误: %s", resp.BodyInfo.SavedPath)
+		}
+	})
+}
+
+// TestParseByteSize 测试字节大小解析
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1024", 1024, false},
+		{"512KB", 512 * 1024, false},
+		{"10MB", 10 * 1024 * 1024, false},
+		{"1.5k", 1536, false},
+		{"2 GB", 2 * 1024 * 1024 * 1024, false},
+		{"abc", 0, true},
+		{"-1MB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseByteSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseByteSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,497 @@
+// Package utils 提供LLM测试用例生成的提供方抽象
+package utils
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 支持的LLM提供方
+const (
+	ProviderDify   = "dify"   // Dify Chatflow（默认）
+	ProviderOpenAI = "openai" // OpenAI兼容的 /chat/completions 接口（vLLM、Ollama等）
+	ProviderOllama = "ollama" // Ollama原生 /api/chat 接口
+)
+
+// llmRequestTimeout LLM请求的整体超时时间
+const llmRequestTimeout = 300 * time.Second
+
+// builtinSystemPrompt 内置的系统提示词，在没有Dify工作流时使用
+//
+//go:embed prompts/system_prompt.md
+var builtinSystemPrompt string
+
+// builtinAnalysisPrompt 内置的失败分析系统提示词
+//
+//go:embed prompts/analysis_prompt.md
+var builtinAnalysisPrompt string
+
+// TaskAnalyze 失败分析任务，UserPrompt为完整的分析请求
+const TaskAnalyze = "analyze"
+
+// ErrPartialResult 表示LLM请求在重试耗尽后失败，同时返回的文本只包含中断前已生成的部分测试用例
+// 调用方可以使用这部分用例，但不应将其视为完整的响应（如写入缓存）
+var ErrPartialResult = errors.New("LLM响应不完整")
+
+// GenerationRequest 表示一次测试用例生成请求
+type GenerationRequest struct {
+	PositiveExample  string                     // 正例报文
+	Format           string                     // 报文格式（json或xml）
+	Num              int                        // 生成的用例个数
+	UserPrompt       string                     // 自定义提示词
+	ExtraInputs      map[string]any             // 额外的输入参数（Dify工作流的inputs）
+	Constraints      map[string]FieldConstraint // 字段约束，序列化后作为constraints输入
+	CheckConstraints bool                       // 使用约束校验生成的用例
+	Debug            bool                       // 调试模式
+	Quiet            bool                       // 静默模式，不实时输出流式文本（并发分批生成时使用）
+	RepairBroken     bool                       // 请求LLM重新生成结构损坏的用例
+	Batch            int                        // 批次序号（分批生成时从1开始，用于区分缓存）
+	Task             string                     // 任务类型（为空表示生成测试用例，TaskAnalyze表示失败分析）
+	PromptTemplate   *PromptTemplate            // 提示词模板，每次请求按Num渲染后放在自定义提示词之前
+}
+
+// generationInputs 返回生成请求中除正例报文外的输入参数，与Dify工作流的inputs一致
+func generationInputs(req GenerationRequest) map[string]any {
+	inputs := map[string]any{
+		"post_type": req.Format, // 报文格式（json或xml）
+		"test_num":  req.Num,    // 生成的用例个数
+	}
+
+	// 如果有自定义提示词，添加到inputs中
+	if req.UserPrompt != "" {
+		inputs["user_prompt"] = req.UserPrompt
+	}
+	// Dify工作流可根据 task 变量区分生成用例和失败分析
+	if req.Task != "" {
+		inputs["task"] = req.Task
+	}
+	// 字段约束以JSON数组的形式传入，Dify工作流可通过 constraints 变量引用
+	if len(req.Constraints) > 0 {
+		inputs["constraints"] = SerializeConstraints(req.Constraints)
+	}
+	for key, value := range req.ExtraInputs {
+		inputs[key] = value
+	}
+	return inputs
+}
+
+// generateWithPromptTemplate 渲染请求中的提示词模板后调用LLM提供方
+func generateWithPromptTemplate(provider LLMProvider, req GenerationRequest) (string, error) {
+	req, err := RenderRequestPrompt(req)
+	if err != nil {
+		return "", err
+	}
+	return provider.Generate(req)
+}
+
+// LLMProvider 表示可以生成测试用例文本的LLM提供方
+type LLMProvider interface {
+	// Name 返回提供方名称
+	Name() string
+	// Generate 根据请求生成测试用例文本
+	Generate(req GenerationRequest) (string, error)
+}
+
+// NewLLMProvider 根据LLM配置创建提供方
+func NewLLMProvider(config LLMConfig) (LLMProvider, error) {
+	stream := true
+	if config.Stream != nil {
+		stream = *config.Stream
+	}
+
+	switch strings.ToLower(config.Provider) {
+	case "", ProviderDify:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("Dify 提供方必须指定 API Key")
+		}
+		retries := defaultDifyRetries
+		if config.Retries != nil {
+			retries = *config.Retries
+		}
+		return &DifyProvider{
+			BaseURL:      config.URL,
+			APIKey:       config.APIKey,
+			Timeout:      time.Duration(config.Timeout) * time.Second,
+			IdleTimeout:  time.Duration(config.IdleTimeout) * time.Second,
+			Retries:      retries,
+			RetryBackoff: time.Duration(config.RetryBackoff) * time.Second,
+		}, nil
+	case ProviderOpenAI:
+		if config.Model == "" {
+			return nil, fmt.Errorf("OpenAI 兼容提供方必须指定 model")
+		}
+		return &OpenAIProvider{
+			BaseURL:     config.URL,
+			APIKey:      config.APIKey,
+			Model:       config.Model,
+			Temperature: config.Temperature,
+			MaxTokens:   config.MaxTokens,
+			Stream:      stream,
+			Timeout:     time.Duration(config.Timeout) * time.Second,
+		}, nil
+	case ProviderOllama:
+		if config.Model == "" {
+			return nil, fmt.Errorf("Ollama 提供方必须指定 model")
+		}
+		return &OllamaProvider{
+			BaseURL:     config.URL,
+			Model:       config.Model,
+			Temperature: config.Temperature,
+			MaxTokens:   config.MaxTokens,
+			Stream:      stream,
+			Timeout:     time.Duration(config.Timeout) * time.Second,
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的LLM提供方 '%s'，支持: %s, %s, %s", config.Provider, ProviderDify, ProviderOpenAI, ProviderOllama)
+	}
+}
+
+// GenerateTestCases 调用LLM提供方生成测试用例并保存为CSV文件
+func GenerateTestCases(provider LLMProvider, req GenerationRequest, outputFile string) error {
+	generatedText, err := generateWithPromptTemplate(provider, req)
+	if isUsablePartialResult(generatedText, err) {
+		fmt.Printf("⚠️  %v，使用已生成的部分测试用例\n", err)
+	} else if err != nil {
+		return err
+	}
+
+	if generatedText == "" {
+		fmt.Println("\n⚠️  当前API未返回测试用例数据，不生成文件")
+		return nil
+	}
+
+	fmt.Println("\n📝 正在解析生成的测试用例...")
+
+	testCases := parseGeneratedTestCases(generatedText, req.Format)
+	if len(testCases) == 0 {
+		return fmt.Errorf("未能解析出有效的测试用例")
+	}
+
+	return saveValidatedTestCases(provider, req, testCases, outputFile)
+}
+
+// defaultBatchSize 分批生成时每批的默认用例数量
+const defaultBatchSize = 50
+
+// BatchOptions 分批生成选项
+type BatchOptions struct {
+	Size        int // 每批生成的用例数量（0表示使用默认值50）
+	Concurrency int // 同时进行的批次数（0表示逐批串行）
+}
+
+// BatchResult 单个批次的生成结果
+type BatchResult struct {
+	Index     int   // 批次序号（从1开始）
+	Requested int   // 请求生成的用例数量
+	Parsed    int   // 解析出的用例数量
+	Added     int   // 与之前批次去重后新增的用例数量
+	Partial   bool  // 响应是否不完整（重试耗尽后只保留了中断前已生成的用例）
+	Err       error // 生成失败的原因
+}
+
+// isUsablePartialResult 判断生成结果是否为可以使用的不完整响应
+func isUsablePartialResult(text string, err error) bool {
+	return errors.Is(err, ErrPartialResult) && strings.TrimSpace(text) != ""
+}
+
+// GenerateTestCasesInBatches 将大数量的生成请求拆分为多个批次并发调用LLM提供方，
+// 跨批次去重后保存为CSV文件。部分批次失败时仍保存成功批次的用例
+func GenerateTestCasesInBatches(provider LLMProvider, req GenerationRequest, opts BatchOptions, outputFile string) ([]BatchResult, error) {
+	batchSize := opts.Size
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	// 拆分批次，最后一批为剩余数量
+	var batchNums []int
+	for remaining := req.Num; remaining > 0; remaining -= batchSize {
+		batchNums = append(batchNums, min(remaining, batchSize))
+	}
+
+	// 只有一个批次时保持原有的单次生成行为
+	if len(batchNums) <= 1 {
+		return nil, GenerateTestCases(provider, req, outputFile)
+	}
+
+	fmt.Printf("📦 分批生成: 共 %d 批，每批最多 %d 条，并发数 %d\n", len(batchNums), batchSize, concurrency)
+
+	// 并发调用LLM，并发时关闭流式文本的实时输出，避免多个批次的输出交错
+	texts := make([]string, len(batchNums))
+	results := make([]BatchResult, len(batchNums))
+	jobs := make(chan int, len(batchNums))
+	var wg sync.WaitGroup
+	for i, num := range batchNums {
+		results[i] = BatchResult{Index: i + 1, Requested: num}
+		jobs <- i
+	}
+	close(jobs)
+
+	// 启动工作协程，按批次顺序领取任务
+	for w := 0; w < min(concurrency, len(batchNums)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				batchReq := req
+				batchReq.Num = results[i].Requested
+				batchReq.Batch = i + 1
+				batchReq.Quiet = req.Quiet || concurrency > 1
+				texts[i], results[i].Err = generateWithPromptTemplate(provider, batchReq)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 按批次顺序解析并跨批次去重，保证结果顺序稳定
+	var testCases []string
+	seenTestCases := make(map[string]bool)
+	for i := range results {
+		result := &results[i]
+		if isUsablePartialResult(texts[i], result.Err) {
+			fmt.Printf("⚠️  批次 %d/%d 响应不完整，使用已生成的部分用例: %v\n", result.Index, len(results), result.Err)
+			result.Partial = true
+			result.Err = nil
+		}
+		if result.Err == nil && texts[i] == "" {
+			result.Err = fmt.Errorf("API未返回测试用例数据")
+		}
+		if result.Err != nil {
+			fmt.Printf("❌ 批次 %d/%d 生成失败: %v\n", result.Index, len(results), result.Err)
+			continue
+		}
+
+		// 批次内的去重由parseGeneratedTestCases完成，这里再与之前批次的用例去重
+		parsed := parseGeneratedTestCases(texts[i], req.Format)
+		result.Parsed = len(parsed)
+		for _, testCase := range parsed {
+			if !seenTestCases[testCase] {
+				testCases = append(testCases, testCase)
+				seenTestCases[testCase] = true
+				result.Added++
+			}
+		}
+		fmt.Printf("✅ 批次 %d/%d: 请求 %d 条，解析 %d 条，去重后新增 %d 条\n",
+			result.Index, len(results), result.Requested, result.Parsed, result.Added)
+	}
+
+	if len(testCases) == 0 {
+		return results, fmt.Errorf("所有批次均未生成有效的测试用例")
+	}
+	if len(testCases) < req.Num {
+		fmt.Printf("⚠️  共生成 %d 条测试用例，少于请求的 %d 条\n", len(testCases), req.Num)
+	}
+
+	return results, saveValidatedTestCases(provider, req, testCases, outputFile)
+}
+
+// maxReportedIssues 校验报告中每个用例最多展示的问题数
+const maxReportedIssues = 3
+
+// saveValidatedTestCases 将生成的用例与正例报文的结构比较并分类，
+// 按需重新生成结构损坏的用例，输出分类报告后保存为CSV文件
+func saveValidatedTestCases(provider LLMProvider, req GenerationRequest, testCases []string, outputFile string) error {
+	schema, err := ExtractPayloadSchema(req.PositiveExample, req.Format)
+	if err != nil {
+		fmt.Printf("⚠️  无法解析正例报文结构，跳过用例校验: %v\n", err)
+		return writeTestCasesCSV(testCases, req.Format, outputFile)
+	}
+
+	validations := schema.ValidateCases(testCases)
+	if req.RepairBroken {
+		testCases, validations = repairBrokenTestCases(provider, req, schema, testCases, validations)
+	}
+	printCaseValidationReport(validations)
+	if req.CheckConstraints && len(req.Constraints) > 0 {
+		printConstraintCheckReport(CheckCasesConstraints(testCases, req.Format, req.Constraints))
+	}
+
+	if len(testCases) == 0 {
+		return fmt.Errorf("未能生成有效的测试用例")
+	}
+	return writeTestCasesCSV(testCases, req.Format, outputFile)
+}
+
+// repairBrokenTestCases 请求LLM重新生成结构损坏的用例，用新生成的非损坏用例替换，
+// 无法替换的损坏用例将被丢弃
+func repairBrokenTestCases(provider LLMProvider, req GenerationRequest, schema *PayloadSchema, testCases []string, validations []CaseValidation) ([]string, []CaseValidation) {
+	var brokenIssues []string
+	seenTestCases := make(map[string]bool)
+	for i, validation := range validations {
+		seenTestCases[testCases[i]] = true
+		if validation.Class == CaseBroken {
+			brokenIssues = append(brokenIssues, validation.Issues...)
+		}
+	}
+	brokenCount := SummarizeCaseValidations(validations)[CaseBroken]
+	if brokenCount == 0 {
+		return testCases, validations
+	}
+
+	fmt.Printf("\n🔧 正在重新生成 %d 个结构损坏的用例...\n", brokenCount)
+
+	// 在提示词中说明损坏原因，要求保持与正例一致的结构
+	repairReq := req
+	repairReq.Num = brokenCount
+	repairReq.Quiet = true
+	repairReq.UserPrompt = strings.TrimSpace(req.UserPrompt + "\n" +
+		"之前生成的部分用例结构与正例报文不一致（" + strings.Join(uniqueStrings(brokenIssues, maxReportedIssues), "；") + "），" +
+		"请重新生成，不要添加正例报文中不存在的字段，也不要改变根节点")
+
+	var replacements []string
+	generatedText, err := generateWithPromptTemplate(provider, repairReq)
+	if err != nil && !isUsablePartialResult(generatedText, err) {
+		fmt.Printf("⚠️  重新生成失败: %v\n", err)
+	} else {
+		for _, testCase := range parseGeneratedTestCases(generatedText, req.Format) {
+			if !seenTestCases[testCase] && schema.ValidateCase(testCase).Class != CaseBroken {
+				replacements = append(replacements, testCase)
+				seenTestCases[testCase] = true
+			}
+		}
+	}
+
+	// 按原顺序替换损坏的用例
+	var repaired []string
+	dropped := 0
+	for i, validation := range validations {
+		if validation.Class != CaseBroken {
+			repaired = append(repaired, testCases[i])
+			continue
+		}
+		if len(replacements) > 0 {
+			repaired = append(repaired, replacements[0])
+			replacements = replacements[1:]
+			continue
+		}
+		dropped++
+	}
+
+	fmt.Printf("🔧 已替换 %d 个损坏用例", brokenCount-dropped)
+	if dropped > 0 {
+		fmt.Printf("，丢弃 %d 个无法修复的用例", dropped)
+	}
+	fmt.Println()
+
+	return repaired, schema.ValidateCases(repaired)
+}
+
+// printCaseValidationReport 输出用例结构校验的分类报告
+func printCaseValidationReport(validations []CaseValidation) {
+	summary := SummarizeCaseValidations(validations)
+	fmt.Println("\n🔎 用例结构校验结果:")
+	fmt.Printf("   ✅ 正例: %d\n", summary[CaseValid])
+	fmt.Printf("   🧪 反例: %d\n", summary[CaseNegative])
+	fmt.Printf("   ❌ 结构损坏: %d\n", summary[CaseBroken])
+
+	for _, validation := range validations {
+		if validation.Class != CaseBroken {
+			continue
+		}
+		issues := validation.Issues
+		if len(issues) > maxReportedIssues {
+			issues = append(issues[:maxReportedIssues:maxReportedIssues], fmt.Sprintf("等%d个问题", len(validation.Issues)))
+		}
+		fmt.Printf("   用例 %d: %s\n", validation.Index, strings.Join(issues, "；"))
+	}
+}
+
+// printConstraintCheckReport 输出用例约束校验结果
+// 反例用例本身可能有意违反约束，因此只报告而不丢弃
+func printConstraintCheckReport(results []ConstraintCheckResult) {
+	var satisfied, violated, unparsed int
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			unparsed++
+		case len(result.Violations) > 0:
+			violated++
+		default:
+			satisfied++
+		}
+	}
+
+	fmt.Println("\n🔒 字段约束校验结果:")
+	fmt.Printf("   ✅ 满足约束: %d\n", satisfied)
+	fmt.Printf("   ⚠️  违反约束: %d\n", violated)
+	if unparsed > 0 {
+		fmt.Printf("   ❌ 无法解析: %d\n", unparsed)
+	}
+
+	for _, result := range results {
+		if len(result.Violations) == 0 {
+			continue
+		}
+		var issues []string
+		for _, violation := range result.Violations {
+			issues = append(issues, fmt.Sprintf("%s=%v（%s）", violation.Field, violation.Value, violation.Message))
+		}
+		if len(issues) > maxReportedIssues {
+			issues = append(issues[:maxReportedIssues:maxReportedIssues], fmt.Sprintf("等%d个问题", len(result.Violations)))
+		}
+		fmt.Printf("   用例 %d: %s\n", result.Index, strings.Join(issues, "；"))
+	}
+}
+
+// uniqueStrings 返回去重后的前limit个字符串
+func uniqueStrings(values []string, limit int) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if len(result) >= limit {
+			break
+		}
+		if !seen[value] {
+			result = append(result, value)
+			seen[value] = true
+		}
+	}
+	return result
+}
+
+// systemPromptFor 返回请求使用的系统提示词，失败分析任务始终使用内置的分析提示词
+func systemPromptFor(req GenerationRequest, configured string) string {
+	if req.Task == TaskAnalyze {
+		return builtinAnalysisPrompt
+	}
+	if configured != "" {
+		return configured
+	}
+	return builtinSystemPrompt
+}
+
+// streamOutput 返回实时输出流式文本的目标，静默模式下丢弃
+func streamOutput(quiet bool) io.Writer {
+	if quiet {
+		return io.Discard
+	}
+	return os.Stdout
+}
+
+// buildUserMessage 构建发送给通用对话模型的用户消息
+func buildUserMessage(req GenerationRequest) string {
+	if req.Task == TaskAnalyze {
+		return req.UserPrompt
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("报文格式: %s\n", req.Format))
+	builder.WriteString(fmt.Sprintf("生成数量: %d\n", req.Num))
+	if req.UserPrompt != "" {
+		builder.WriteString(fmt.Sprintf("额外要求: %s\n", req.UserPrompt))
+	}
+	if len(req.Constraints) > 0 {
+		builder.WriteString(fmt.Sprintf("字段约束: %s\n", SerializeConstraints(req.Constraints)))
+	}
+	builder.WriteString("\n正例报文:\n")
+	builder.WriteString(req.PositiveExample)
+	return builder.String()
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubProvider 用于测试的LLM提供方，按调用顺序返回预设的结果
+type stubProvider struct {
+	mu       sync.Mutex
+	calls    int
+	nums     []int
+	generate func(call int, req GenerationRequest) (string, error)
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+func (p *stubProvider) Generate(req GenerationRequest) (string, error) {
+	p.mu.Lock()
+	call := p.calls
+	p.calls++
+	p.nums = append(p.nums, req.Num)
+	p.mu.Unlock()
+	return p.generate(call, req)
+}
+
+// TestGenerateTestCasesInBatches 测试分批生成、跨批次去重和部分失败时的结果保存
+func TestGenerateTestCasesInBatches(t *testing.T) {
+	provider := &stubProvider{
+		generate: func(call int, req GenerationRequest) (string, error) {
+			if call == 2 {
+				return "", fmt.Errorf("模拟超时")
+			}
+			// 每批都包含一个重复用例 {"id":0}
+			cases := []string{`{"id":0}`}
+			for i := 1; i < req.Num; i++ {
+				cases = append(cases, fmt.Sprintf(`{"id":%d}`, call*100+i))
+			}
+			return "[" + strings.Join(cases, ",") + "]", nil
+		},
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "cases.csv")
+	req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 10}
+	results, err := GenerateTestCasesInBatches(provider, req, BatchOptions{Size: 4}, outputFile)
+	if err != nil {
+		t.Fatalf("分批生成失败: %v", err)
+	}
+
+	// 10条按每批4条拆分为 4、4、2
+	if len(results) != 3 || fmt.Sprint(provider.nums) != "[4 4 2]" {
+		t.Fatalf("批次拆分错误: 结果 %d 个，请求数量 %v", len(results), provider.nums)
+	}
+	if results[0].Added != 4 || results[1].Parsed != 4 || results[1].Added != 3 {
+		t.Errorf("跨批次去重统计错误: %+v", results)
+	}
+	if results[2].Err == nil {
+		t.Errorf("第3批应记录失败原因: %+v", results[2])
+	}
+
+	records, err := ReadCSV(outputFile)
+	if err != nil {
+		t.Fatalf("读取CSV失败: %v", err)
+	}
+	if len(records) != 8 {
+		t.Errorf("期望表头加7条用例，实际 %d 行: %v", len(records), records)
+	}
+}
+
+// TestGenerateTestCasesInBatchesPromptTemplate 测试提示词模板按每个批次的生成数量分别渲染
+func TestGenerateTestCasesInBatchesPromptTemplate(t *testing.T) {
+	tmpl, err := parsePromptTemplate("count", "生成 {{.Count}} 条{{.Format}}测试用例")
+	if err != nil {
+		t.Fatalf("解析模板失败: %v", err)
+	}
+
+	var mu sync.Mutex
+	var prompts []string
+	provider := &stubProvider{
+		generate: func(call int, req GenerationRequest) (string, error) {
+			mu.Lock()
+			prompts = append(prompts, req.UserPrompt)
+			mu.Unlock()
+			cases := make([]string, req.Num)
+			for i := range cases {
+				cases[i] = fmt.Sprintf(`{"id":%d}`, call*100+i)
+			}
+			return "[" + strings.Join(cases, ",") + "]", nil
+		},
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "cases.csv")
+	req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 5, UserPrompt: "不要包含空值", PromptTemplate: tmpl}
+	if _, err := GenerateTestCasesInBatches(provider, req, BatchOptions{Size: 3}, outputFile); err != nil {
+		t.Fatalf("分批生成失败: %v", err)
+	}
+
+	want := []string{"生成 3 条JSON测试用例\n\n不要包含空值", "生成 2 条JSON测试用例\n\n不要包含空值"}
+	if fmt.Sprintf("%q", prompts) != fmt.Sprintf("%q", want) {
+		t.Errorf("各批次的提示词 %q，期望 %q", prompts, want)
+	}
+}
+
+// TestGenerateTestCasesInBatchesPartialResult 测试不完整的批次响应保留已生成的用例并标记为不完整
+func TestGenerateTestCasesInBatchesPartialResult(t *testing.T) {
+	provider := &stubProvider{
+		generate: func(call int, req GenerationRequest) (string, error) {
+			if call == 1 {
+				return `[{"id":101}]`, fmt.Errorf("%w: 模拟中断", ErrPartialResult)
+			}
+			return fmt.Sprintf(`[{"id":%d},{"id":%d}]`, call*100+1, call*100+2), nil
+		},
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "cases.csv")
+	req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 4}
+	results, err := GenerateTestCasesInBatches(provider, req, BatchOptions{Size: 2}, outputFile)
+	if err != nil {
+		t.Fatalf("分批生成失败: %v", err)
+	}
+	if results[0].Partial || !results[1].Partial || results[1].Err != nil || results[1].Added != 1 {
+		t.Errorf("不完整批次的统计错误: %+v", results)
+	}
+}
+
+// TestGenerateTestCasesInBatchesAllFailed 测试所有批次失败时返回错误
+func TestGenerateTestCasesInBatchesAllFailed(t *testing.T) {
+	provider := &stubProvider{
+		generate: func(int, GenerationRequest) (string, error) {
+			return "", nil
+		},
+	}
+
+	req := GenerationRequest{Format: "json", Num: 6}
+	results, err := GenerateTestCasesInBatches(provider, req, BatchOptions{Size: 2, Concurrency: 3}, filepath.Join(t.TempDir(), "cases.csv"))
+	if err == nil {
+		t.Fatal("所有批次失败时应返回错误")
+	}
+	for _, result := range results {
+		if result.Err == nil {
+			t.Errorf("批次 %d 未返回数据时应记录失败原因", result.Index)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+// Package utils 提供反例用例生成使用的字段变异算子和用例标签
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 本地生成模式
+const (
+	GenerationModeRandom   = "random"   // 随机变化模式（默认）
+	GenerationModeNegative = "negative" // 反例模式，对每个字段系统地应用变异算子
+	GenerationModeMixed    = "mixed"    // 混合模式，按比例生成正例和反例
+)
+
+// DefaultNegativeRatio 混合模式下反例的默认占比
+const DefaultNegativeRatio = 0.3
+
+// 用例类型
+const (
+	CaseTypePositive = "positive" // 正例
+	CaseTypeNegative = "negative" // 反例
+)
+
+// 字段变异算子
+const (
+	MutationMissing       = "missing"          // 删除字段
+	MutationNull          = "null"             // 字段值为null
+	MutationEmpty         = "empty"            // 字段值为空字符串、空数组或空对象
+	MutationWrongType     = "wrong_type"       // 字段值类型错误
+	MutationOverlong      = "overlong"         // 字符串超长
+	MutationMalformedDate = "malformed_date"   // 日期格式非法
+	MutationPattern       = "pattern_mismatch" // 字符串不匹配约束的正则表达式
+	MutationChecksum      = "bad_checksum"     // 身份证号或银行卡号的校验位错误
+)
+
+// 边界值标签
+const (
+	BoundaryValid   = "boundary"         // 约束范围内的边界值
+	BoundaryInvalid = "boundary_invalid" // 超出约束的边界值
+)
+
+// mutationOperators 变异算子的应用顺序
+var mutationOperators = []string{MutationMissing, MutationNull, MutationEmpty, MutationWrongType, MutationOverlong, MutationMalformedDate, MutationPattern, MutationChecksum}
+
+// CaseLabelHeader CSV中用例标签列的列名
+const CaseLabelHeader = "LABEL"
+
+// overlongStringLength 超长字符串的最小长度
+const overlongStringLength = 1024
+
+// dateLayouts 识别日期字段时尝试的格式
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "2006/01/02", "20060102"}
+
+// malformedDateReplacer 将日期格式替换为不存在的日期（13月45日25时61分61秒）
+var malformedDateReplacer = strings.NewReplacer("2006", "2023", "01", "13", "02", "45", "15", "25", "04", "61", "05", "61", "Z07:00", "+08:00")
+
+// Mutation 表示对某个字段应用的变异
+type Mutation struct {
+	Field    string // 字段路径，嵌套字段使用 a.b 形式
+	Operator string // 变异算子
+}
+
+// Label 返回变异标签，如 missing:user.name
+func (m Mutation) Label() string {
+	return m.Operator + ":" + m.Field
+}
+
+// Description 返回变异的中文说明
+func (m Mutation) Description() string {
+	switch m.Operator {
+	case MutationMissing:
+		return fmt.Sprintf("缺少字段 %s", m.Field)
+	case MutationNull:
+		return fmt.Sprintf("字段 %s 为null", m.Field)
+	case MutationEmpty:
+		return fmt.Sprintf("字段 %s 为空", m.Field)
+	case MutationWrongType:
+		return fmt.Sprintf("字段 %s 类型错误", m.Field)
+	case MutationOverlong:
+		return fmt.Sprintf("字段 %s 超长", m.Field)
+	case MutationMalformedDate:
+		return fmt.Sprintf("字段 %s 日期格式非法", m.Field)
+	case MutationPattern:
+		return fmt.Sprintf("字段 %s 不匹配格式", m.Field)
+	case MutationChecksum:
+		return fmt.Sprintf("字段 %s 校验位错误", m.Field)
+	case BoundaryValid, BoundaryInvalid:
+		field, point, _ := strings.Cut(m.Field, "=")
+		if m.Operator == BoundaryValid {
+			return fmt.Sprintf("字段 %s 取边界值 %s", field, point)
+		}
+		return fmt.Sprintf("字段 %s 取越界值 %s", field, point)
+	case CombinationLabel:
+		return fmt.Sprintf("组合用例 %s", m.Field)
+	default:
+		return fmt.Sprintf("字段 %s 变异（%s）", m.Field, m.Operator)
+	}
+}
+
+// LabeledTestCase 表示带有类型和标签的测试用例
+type LabeledTestCase struct {
+	Data        map[string]any // 用例数据
+	Type        string         // 用例类型（positive 或 negative）
+	Label       string         // 用例标签，反例为变异标签
+	Description string         // 用例说明
+}
+
+// ListMutations 使用默认生成器的约束配置列出可应用于报文各字段的变异
+func ListMutations(data map[string]any, keys []string, isXML bool) []Mutation {
+	return defaultGenerator.ListMutations(data, keys, isXML)
+}
+
+// ListMutations 按算子顺序列出可应用于报文各字段的变异
+// 同一算子依次作用于所有字段，反例数量不足以覆盖全部变异时优先覆盖更多字段
+func (g *Generator) ListMutations(data map[string]any, keys []string, isXML bool) []Mutation {
+	fields := collectMutationFields(data, keys, "")
+
+	var mutations []Mutation
+	for _, operator := range mutationOperators {
+		for _, field := range fields {
+			if g.mutationApplies(operator, field.value, field.path, isXML) {
+				mutations = append(mutations, Mutation{Field: field.path, Operator: operator})
+			}
+		}
+	}
+	return mutations
+}
+
+// ApplyMutation 使用默认生成器的约束配置返回应用变异后的报文副本，原报文不变
+func ApplyMutation(data map[string]any, mutation Mutation, isXML bool) map[string]any {
+	return defaultGenerator.ApplyMutation(data, mutation, isXML)
+}
+
+// ApplyMutation 返回应用变异后的报文副本，原报文不变
+func (g *Generator) ApplyMutation(data map[string]any, mutation Mutation, isXML bool) map[string]any {
+	return updateField(data, mutation.Field, func(value any) (any, bool) {
+		switch mutation.Operator {
+		case MutationMissing:
+			return nil, false
+		case MutationPattern:
+			// 根据字段约束的正则表达式生成相近但不匹配的字符串
+			if constraint := g.FindFieldConstraint(mutation.Field); constraint != nil {
+				return g.generatePatternMismatch(constraint, value), true
+			}
+		case MutationChecksum:
+			// 保留号码的其余部分，只将校验位替换为错误的值
+			if constraint := g.FindFieldConstraint(mutation.Field); constraint != nil {
+				return g.generateChecksumMismatch(constraint, value), true
+			}
+		default:
+			return mutateValue(mutation.Operator, value, isXML), true
+		}
+		return value, true
+	})
+}
+
+// updateField 返回修改指定字段后的报文副本，字段路径不存在时返回未修改的副本
+// 字段路径支持数组下标（如 items[0].price）；update 返回字段的新值，keep 为 false 时删除字段或数组元素
+func updateField(data map[string]any, field string, update func(value any) (newValue any, keep bool)) map[string]any {
+	result := deepCopyValue(data).(map[string]any)
+
+	segments, err := splitFieldPath(field)
+	if err != nil {
+		return result
+	}
+	updatePathValue(result, segments, update)
+	return result
+}
+
+// updatePathValue 修改容器中路径片段指向的值，返回修改后的容器（删除数组元素时数组会变化）
+func updatePathValue(container any, segments []string, update func(value any) (any, bool)) any {
+	segment := segments[0]
+	isIndex := strings.HasPrefix(segment, "[")
+	switch c := container.(type) {
+	case map[string]any:
+		if isIndex {
+			return c
+		}
+		value, exists := c[segment]
+		if len(segments) > 1 {
+			if exists {
+				c[segment] = updatePathValue(value, segments[1:], update)
+			}
+			return c
+		}
+		if newValue, keep := update(value); keep {
+			c[segment] = newValue
+		} else {
+			delete(c, segment)
+		}
+		return c
+	case []any:
+		index, err := strconv.Atoi(strings.Trim(segment, "[]"))
+		if !isIndex || err != nil || index < 0 || index >= len(c) {
+			return c
+		}
+		if len(segments) > 1 {
+			c[index] = updatePathValue(c[index], segments[1:], update)
+			return c
+		}
+		if newValue, keep := update(c[index]); keep {
+			c[index] = newValue
+			return c
+		}
+		return append(c[:index:index], c[index+1:]...)
+	}
+	return container
+}
+
+// GenerateNegativeTestCases 使用默认生成器对正例报文的每个字段应用变异算子生成反例
+func GenerateNegativeTestCases(data map[string]any, count int, isXML bool) []LabeledTestCase {
+	return defaultGenerator.GenerateNegativeTestCases(data, count, isXML)
+}
+
+// GenerateNegativeTestCases 对正例报文的每个字段应用变异算子生成反例，最多生成count个
+func (g *Generator) GenerateNegativeTestCases(data map[string]any, count int, isXML bool) []LabeledTestCase {
+	mutations := g.ListMutations(data, g.mutationKeyOrder(data), isXML)
+	if count < len(mutations) {
+		mutations = mutations[:count]
+	}
+
+	testCases := make([]LabeledTestCase, len(mutations))
+	for i, mutation := range mutations {
+		testCases[i] = LabeledTestCase{
+			Data:        g.ApplyMutation(data, mutation, isXML),
+			Type:        CaseTypeNegative,
+			Label:       mutation.Label(),
+			Description: mutation.Description(),
+		}
+	}
+	return testCases
+}
+
+// GenerateMixedTestCases 使用默认生成器按反例占比生成正例和反例
+func GenerateMixedTestCases(data map[string]any, count int, negativeRatio, variationRate float64, useConstraints, isXML bool) []LabeledTestCase {
+	return defaultGenerator.generateMixedTestCases(data, count, negativeRatio, variationRate, useConstraints, isXML)
+}
+
+// GenerateMixedTestCases 按反例占比生成正例和反例，正例按生成器的随机化因子和约束配置生成
+func (g *Generator) GenerateMixedTestCases(data map[string]any, count int, negativeRatio float64, isXML bool) []LabeledTestCase {
+	return g.generateMixedTestCases(data, count, negativeRatio, g.VariationRate, g.Constraints != nil, isXML)
+}
+
+// generateMixedTestCases 按反例占比生成正例和反例，正例使用随机变化模式生成
+// 可生成的反例不足时使用正例补足数量
+func (g *Generator) generateMixedTestCases(data map[string]any, count int, negativeRatio, variationRate float64, useConstraints, isXML bool) []LabeledTestCase {
+	negativeCount := int(math.Round(float64(count) * negativeRatio))
+	negatives := g.GenerateNegativeTestCases(data, negativeCount, isXML)
+
+	positives := g.generateTestCases(data, count-len(negatives), variationRate, useConstraints)
+	testCases := LabelPositiveTestCases(positives)
+	return append(testCases, negatives...)
+}
+
+// LabelPositiveTestCases 将随机变化模式生成的用例标记为正例
+func LabelPositiveTestCases(testCases []map[string]any) []LabeledTestCase {
+	labeled := make([]LabeledTestCase, len(testCases))
+	for i, testCase := range testCases {
+		labeled[i] = LabeledTestCase{Data: testCase, Type: CaseTypePositive, Label: CaseTypePositive, Description: "随机变化的正例"}
+	}
+	return labeled
+}
+
+// ParseCaseLabel 根据CSV中的用例标签返回用例类型和说明
+func ParseCaseLabel(label string) (string, string) {
+	if label == "" || label == CaseTypePositive {
+		return CaseTypePositive, "随机变化的正例"
+	}
+	operator, field, ok := strings.Cut(label, ":")
+	if !ok {
+		return CaseTypeNegative, label
+	}
+	caseType := CaseTypeNegative
+	if operator == BoundaryValid || operator == CombinationLabel {
+		caseType = CaseTypePositive
+	}
+	return caseType, Mutation{Field: field, Operator: operator}.Description()
+}
+
+// ConvertToLabeledRows 使用默认生成器将带标签的用例转换为CSV行
+func ConvertToLabeledRows(testCases []LabeledTestCase, isXML bool) [][]string {
+	return defaultGenerator.ConvertToLabeledRows(testCases, isXML)
+}
+
+// ConvertToLabeledRows 将带标签的用例转换为CSV行，第一列为完整报文，第二列为用例标签
+func (g *Generator) ConvertToLabeledRows(testCases []LabeledTestCase, isXML bool) [][]string {
+	data := make([]map[string]any, len(testCases))
+	for i, testCase := range testCases {
+		data[i] = testCase.Data
+	}
+
+	var rows [][]string
+	if isXML {
+		rows = g.ConvertToXMLRows(data)
+	} else {
+		rows = g.ConvertToJSONRows(data)
+	}
+	if len(rows) == 0 {
+		return rows
+	}
+
+	rows[0] = append(rows[0], CaseLabelHeader)
+	for i, testCase := range testCases {
+		rows[i+1] = append(rows[i+1], testCase.Label)
+	}
+	return rows
+}
+
+// mutationField 表示可变异的字段
+type mutationField struct {
+	path  string
+	value any
+}
+
+// collectMutationFields 按字段顺序收集可变异的字段，嵌套对象的子字段按字段名排序，
+// 数组元素按下标收集，路径与约束匹配使用的路径一致（如 items[0].price）
+func collectMutationFields(data map[string]any, keys []string, prefix string) []mutationField {
+	var fields []mutationField
+	for _, key := range keys {
+		value, ok := data[key]
+		if !ok {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fields = append(fields, collectMutationValue(value, path)...)
+	}
+	return fields
+}
+
+// collectMutationValue 收集字段本身以及嵌套对象的子字段和数组元素
+func collectMutationValue(value any, path string) []mutationField {
+	fields := []mutationField{{path: path, value: value}}
+	switch v := value.(type) {
+	case map[string]any:
+		fields = append(fields, collectMutationFields(v, sortedKeys(v), path)...)
+	case []any:
+		for i, item := range v {
+			fields = append(fields, collectMutationValue(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return fields
+}
+
+// mutationKeyOrder 返回顶层字段顺序，优先使用解析报文时保存的原始顺序
+func (g *Generator) mutationKeyOrder(data map[string]any) []string {
+	if len(g.Schema.KeyOrder) > 0 {
+		return g.Schema.KeyOrder
+	}
+	return sortedKeys(data)
+}
+
+// mutationApplies 判断变异算子是否适用于字段值
+func (g *Generator) mutationApplies(operator string, value any, field string, isXML bool) bool {
+	str, isString := value.(string)
+	switch operator {
+	case MutationMissing:
+		return true
+	case MutationNull:
+		// XML中null与空元素无法区分，只生成空值用例
+		return !isXML && value != nil
+	case MutationEmpty:
+		switch v := value.(type) {
+		case string:
+			return v != ""
+		case []any:
+			return !isXML && len(v) > 0
+		case map[string]any:
+			return len(v) > 0
+		}
+		return false
+	case MutationWrongType:
+		if isString && isNumericString(str) {
+			return true
+		}
+		switch value.(type) {
+		case nil:
+			return false
+		case string:
+			// XML中所有值都是文本，普通字符串不存在类型错误
+			return !isXML
+		case map[string]any, []any:
+			return !isXML
+		}
+		return true
+	case MutationOverlong:
+		return isString
+	case MutationMalformedDate:
+		if constraint := g.FindFieldConstraint(field); constraint != nil && (constraint.Type == "date" || constraint.Type == "datetime") {
+			return true
+		}
+		return isString && detectDateLayout(str) != ""
+	case MutationPattern:
+		constraint := g.FindFieldConstraint(field)
+		return constraint != nil && constraint.Type == "pattern"
+	case MutationChecksum:
+		constraint := g.FindFieldConstraint(field)
+		return constraint != nil && (constraint.Type == "id_card" || constraint.Type == "bank_card")
+	}
+	return false
+}
+
+// mutateValue 根据变异算子生成字段的新值
+func mutateValue(operator string, value any, isXML bool) any {
+	switch operator {
+	case MutationNull:
+		return nil
+	case MutationEmpty:
+		switch value.(type) {
+		case []any:
+			return []any{}
+		case map[string]any:
+			return map[string]any{}
+		}
+		return ""
+	case MutationWrongType:
+		if str, ok := value.(string); ok && !isNumericString(str) {
+			return 12345
+		}
+		return "abc"
+	case MutationOverlong:
+		length := overlongStringLength
+		if str, ok := value.(string); ok && len([]rune(str))*2 > length {
+			length = len([]rune(str)) * 2
+		}
+		return strings.Repeat("A", length)
+	case MutationMalformedDate:
+		layout := detectDateLayout(fmt.Sprint(value))
+		if layout == "" {
+			layout = "2006-01-02"
+		}
+		return malformedDateReplacer.Replace(layout)
+	}
+	return value
+}
+
+// detectDateLayout 返回字符串匹配的日期格式，不是日期时返回空字符串
+func detectDateLayout(value string) string {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return layout
+		}
+	}
+	return ""
+}
+
+// isNumericString 判断字符串是否为数值
+func isNumericString(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// deepCopyValue 深拷贝报文中的对象和数组
+func deepCopyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = deepCopyValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = deepCopyValue(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
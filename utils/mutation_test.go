@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestListMutations 测试按字段类型列出适用的变异算子
+func TestListMutations(t *testing.T) {
+	data := map[string]any{
+		"name":     "张三",
+		"age":      25,
+		"birthday": "2000-01-01",
+		"tags":     []any{"a"},
+		"user":     map[string]any{"id": "123"},
+	}
+	keys := []string{"name", "age", "birthday", "tags", "user"}
+
+	tests := []struct {
+		name   string
+		isXML  bool
+		labels []string
+	}{
+		{
+			name:  "JSON报文",
+			isXML: false,
+			labels: []string{
+				"missing:name", "missing:age", "missing:birthday", "missing:tags", "missing:tags[0]", "missing:user", "missing:user.id",
+				"null:name", "null:age", "null:birthday", "null:tags", "null:tags[0]", "null:user", "null:user.id",
+				"empty:name", "empty:birthday", "empty:tags", "empty:tags[0]", "empty:user", "empty:user.id",
+				"wrong_type:name", "wrong_type:age", "wrong_type:birthday", "wrong_type:tags", "wrong_type:tags[0]", "wrong_type:user", "wrong_type:user.id",
+				"overlong:name", "overlong:birthday", "overlong:tags[0]", "overlong:user.id",
+				"malformed_date:birthday",
+			},
+		},
+		{
+			name:  "XML报文不生成null和普通字符串的类型错误",
+			isXML: true,
+			labels: []string{
+				"missing:name", "missing:age", "missing:birthday", "missing:tags", "missing:tags[0]", "missing:user", "missing:user.id",
+				"empty:name", "empty:birthday", "empty:tags[0]", "empty:user", "empty:user.id",
+				"wrong_type:age", "wrong_type:user.id",
+				"overlong:name", "overlong:birthday", "overlong:tags[0]", "overlong:user.id",
+				"malformed_date:birthday",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var labels []string
+			for _, mutation := range ListMutations(data, keys, tt.isXML) {
+				labels = append(labels, mutation.Label())
+			}
+			if strings.Join(labels, ",") != strings.Join(tt.labels, ",") {
+				t.Errorf("变异列表错误:\n期望 %v\n实际 %v", tt.labels, labels)
+			}
+		})
+	}
+}
+
+// TestApplyMutation 测试变异只作用于目标字段且不修改原报文
+func TestApplyMutation(t *testing.T) {
+	data := map[string]any{
+		"name":     "张三",
+		"created":  "2024-01-02T15:04:05+08:00",
+		"user":     map[string]any{"id": "123", "age": 30},
+		"amount":   9.9,
+		"disabled": false,
+		"items":    []any{map[string]any{"price": 1.5}, map[string]any{"price": 2.5}},
+	}
+
+	tests := []struct {
+		mutation Mutation
+		check    func(result map[string]any) bool
+	}{
+		{Mutation{Field: "user.id", Operator: MutationMissing}, func(r map[string]any) bool {
+			_, ok := r["user"].(map[string]any)["id"]
+			return !ok && r["user"].(map[string]any)["age"] == 30
+		}},
+		{Mutation{Field: "name", Operator: MutationNull}, func(r map[string]any) bool { return r["name"] == nil }},
+		{Mutation{Field: "user", Operator: MutationEmpty}, func(r map[string]any) bool { return len(r["user"].(map[string]any)) == 0 }},
+		{Mutation{Field: "amount", Operator: MutationWrongType}, func(r map[string]any) bool { return r["amount"] == "abc" }},
+		{Mutation{Field: "name", Operator: MutationWrongType}, func(r map[string]any) bool { return r["name"] == 12345 }},
+		{Mutation{Field: "name", Operator: MutationOverlong}, func(r map[string]any) bool { return len(r["name"].(string)) == overlongStringLength }},
+		{Mutation{Field: "created", Operator: MutationMalformedDate}, func(r map[string]any) bool { return r["created"] == "2023-13-45T25:61:61+08:00" }},
+		{Mutation{Field: "items[1].price", Operator: MutationWrongType}, func(r map[string]any) bool {
+			items := r["items"].([]any)
+			return items[0].(map[string]any)["price"] == 1.5 && items[1].(map[string]any)["price"] == "abc"
+		}},
+		{Mutation{Field: "items[0]", Operator: MutationMissing}, func(r map[string]any) bool {
+			items := r["items"].([]any)
+			return len(items) == 1 && items[0].(map[string]any)["price"] == 2.5
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mutation.Label(), func(t *testing.T) {
+			result := ApplyMutation(data, tt.mutation, false)
+			if !tt.check(result) {
+				t.Errorf("变异结果错误: %v", result)
+			}
+			if data["name"] != "张三" || len(data["user"].(map[string]any)) != 2 || data["items"].([]any)[1].(map[string]any)["price"] != 2.5 {
+				t.Errorf("原报文被修改: %v", data)
+			}
+		})
+	}
+}
+
+// TestGenerateMixedTestCases 测试混合模式的用例数量、标签和CSV输出
+func TestGenerateMixedTestCases(t *testing.T) {
+	g := NewGenerator(nil, DefaultVariationRate, 1)
+	g.Schema.KeyOrder = []string{"name", "age"}
+	data := map[string]any{"name": "张三", "age": 25}
+
+	testCases := g.GenerateMixedTestCases(data, 10, 0.3, false)
+	if len(testCases) != 10 {
+		t.Fatalf("用例数量错误: %d", len(testCases))
+	}
+	var negatives []string
+	for _, testCase := range testCases {
+		if testCase.Type == CaseTypeNegative {
+			negatives = append(negatives, testCase.Label)
+		}
+	}
+	if strings.Join(negatives, ",") != "missing:name,missing:age,null:name" {
+		t.Errorf("反例标签错误: %v", negatives)
+	}
+
+	if negatives := g.GenerateNegativeTestCases(data, 100, false); len(negatives) != 8 {
+		t.Errorf("反例数量应以可用变异数为上限: %d", len(negatives))
+	}
+
+	rows := g.ConvertToLabeledRows(testCases, false)
+	if strings.Join(rows[0], ",") != "JSON,LABEL" || rows[10][1] != "null:name" || DetectCSVPayloadFormat(rows[0]) != "json" {
+		t.Errorf("CSV输出错误: %v", rows[0])
+	}
+	if caseType, description := ParseCaseLabel(rows[10][1]); caseType != CaseTypeNegative || description != "字段 name 为null" {
+		t.Errorf("解析用例标签错误: %s %s", caseType, description)
+	}
+}
@@ -0,0 +1,201 @@
+// Package utils 提供Ollama原生接口的LLM提供方实现
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultOllamaURL Ollama服务的默认地址
+const defaultOllamaURL = "http://localhost:11434"
+
+// OllamaProvider 基于Ollama原生 /api/chat 接口的LLM提供方
+// 适用于无法访问外网的离线环境
+type OllamaProvider struct {
+	BaseURL      string        // Ollama服务地址（为空时使用 http://localhost:11434）
+	Model        string        // 模型名称
+	Temperature  *float64      // 采样温度（为空时使用模型默认值）
+	MaxTokens    int           // 最大生成token数，对应 options.num_predict（0表示不限制）
+	Stream       bool          // 是否使用流式响应
+	SystemPrompt string        // 系统提示词（为空时使用内置提示词）
+	Timeout      time.Duration // 请求的整体超时时间（0表示使用默认值300秒）
+
+	usageRecorder
+}
+
+// OllamaChatRequest 表示发送给 /api/chat 的请求
+type OllamaChatRequest struct {
+	Model    string              `json:"model"`             // 模型名称
+	Messages []OpenAIChatMessage `json:"messages"`          // 对话消息（与OpenAI格式相同）
+	Stream   bool                `json:"stream"`            // 是否流式响应
+	Options  map[string]any      `json:"options,omitempty"` // 模型参数
+}
+
+// OllamaChatResponse 表示 /api/chat 的响应（流式响应每行一个JSON对象）
+type OllamaChatResponse struct {
+	Model   string            `json:"model"`
+	Message OpenAIChatMessage `json:"message"`
+	Done    bool              `json:"done"`
+	Error   string            `json:"error"`
+	// DoneReason 结束原因（stop 表示正常结束，length 表示达到最大token数被截断）
+	DoneReason string `json:"done_reason"`
+	// 以下用量字段仅在最后一个响应（done为true）中返回
+	PromptEvalCount int `json:"prompt_eval_count"` // 输入token数
+	EvalCount       int `json:"eval_count"`        // 输出token数
+}
+
+// Name 返回提供方名称
+func (p *OllamaProvider) Name() string {
+	return ProviderOllama
+}
+
+// Generate 调用Ollama /api/chat 接口生成测试用例文本
+func (p *OllamaProvider) Generate(req GenerationRequest) (string, error) {
+	systemPrompt := systemPromptFor(req, p.SystemPrompt)
+
+	reqBody := OllamaChatRequest{
+		Model: p.Model,
+		Messages: []OpenAIChatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: buildUserMessage(req)},
+		},
+		Stream: p.Stream,
+	}
+	options := make(map[string]any)
+	if p.Temperature != nil {
+		options["temperature"] = *p.Temperature
+	}
+	if p.MaxTokens > 0 {
+		options["num_predict"] = p.MaxTokens
+	}
+	if len(options) > 0 {
+		reqBody.Options = options
+	}
+
+	// 序列化请求体
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("序列化请求体失败: %v", err)
+	}
+
+	baseURL := p.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaURL
+	}
+	chatURL := fmt.Sprintf("%s/api/chat", strings.TrimSuffix(baseURL, "/"))
+	httpReq, err := http.NewRequest("POST", chatURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Debug模式：显示实际的请求信息
+	if req.Debug {
+		fmt.Println("\n🔍 ==================== DEBUG: HTTP请求详情 ====================")
+		fmt.Printf("📍 请求URL: %s\n", httpReq.URL.String())
+		fmt.Printf("🤖 模型: %s\n", p.Model)
+		fmt.Println("\n📦 请求体 (Request Body):")
+		var prettyJSON bytes.Buffer
+		if indentErr := json.Indent(&prettyJSON, jsonData, "", "  "); indentErr == nil {
+			fmt.Printf("%s\n", prettyJSON.String())
+		} else {
+			fmt.Printf("%s\n", string(jsonData))
+		}
+		fmt.Println("🔍 ============================================================")
+	}
+
+	// 发送请求
+	start := time.Now()
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = llmRequestTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 检查响应状态
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API请求失败，状态码: %d，响应: %s", resp.StatusCode, string(body))
+	}
+
+	text, usage, err := processOllamaResponse(resp.Body, streamOutput(req.Quiet), req.Debug)
+	usage.Calls = 1
+	usage.Latency = time.Since(start)
+	if usage.Model == "" {
+		usage.Model = p.Model
+	}
+	p.record(usage)
+	return text, err
+}
+
+// processOllamaResponse 处理Ollama的响应
+// 流式响应为逐行的JSON对象，非流式响应为单个JSON对象，两者可按同一方式处理
+// 未收到 done 为 true 的响应就结束或输出因达到最大token数被截断时，返回已收到的文本和 ErrPartialResult
+func processOllamaResponse(body io.Reader, out io.Writer, debug bool) (string, LLMUsage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	var collectedText strings.Builder
+	var usage LLMUsage
+	var doneReason string
+	done := false
+
+	fmt.Fprintln(out, "📡 开始接收流式数据...")
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if debug {
+			fmt.Printf("\n🔍 [DEBUG] 原始数据: %s\n", line)
+		}
+
+		var chunk OllamaChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			fmt.Printf("⚠️  解析数据块失败: %v\n", err)
+			continue
+		}
+		if chunk.Error != "" {
+			fmt.Printf("\n❌ 流式响应错误: %s\n", chunk.Error)
+			return "", LLMUsage{}, fmt.Errorf("API返回错误: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			fmt.Fprint(out, chunk.Message.Content) // 实时流式输出文本片段
+			collectedText.WriteString(chunk.Message.Content)
+		}
+		if chunk.Done {
+			usage = LLMUsage{
+				PromptTokens:     chunk.PromptEvalCount,
+				CompletionTokens: chunk.EvalCount,
+				TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+				Model:            chunk.Model,
+			}
+			done, doneReason = true, chunk.DoneReason
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return collectedText.String(), usage, fmt.Errorf("%w: 读取流式响应失败: %v", ErrPartialResult, err)
+	}
+	if !done {
+		return collectedText.String(), usage, fmt.Errorf("%w: 响应在 done 为 true 之前中断", ErrPartialResult)
+	}
+	if err := checkFinishReason(doneReason); err != nil {
+		return collectedText.String(), usage, err
+	}
+
+	fmt.Fprintln(out, "\n\n✅ 消息接收完成!")
+	return collectedText.String(), usage, nil
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOllamaProviderGenerate 测试Ollama提供方的流式与非流式响应处理
+func TestOllamaProviderGenerate(t *testing.T) {
+	content := "```xml\n<root><name>a</name></root>$$$$<root><name>b</name></root>\n```"
+
+	tests := []struct {
+		name   string
+		stream bool
+	}{
+		{name: "非流式响应", stream: false},
+		{name: "流式响应", stream: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received OllamaChatRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/chat" {
+					http.NotFound(w, r)
+					return
+				}
+				if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				if !received.Stream {
+					data, _ := json.Marshal(OllamaChatResponse{Model: received.Model, Message: OpenAIChatMessage{Role: "assistant", Content: content}, Done: true})
+					fmt.Fprintf(w, "%s\n", data)
+					return
+				}
+				// 流式响应：每行一个JSON对象，最后一行done为true
+				for _, chunk := range strings.SplitAfter(content, "$$$$") {
+					data, _ := json.Marshal(OllamaChatResponse{Model: received.Model, Message: OpenAIChatMessage{Role: "assistant", Content: chunk}})
+					fmt.Fprintf(w, "%s\n", data)
+				}
+				fmt.Fprintf(w, `{"model":%q,"message":{"role":"assistant","content":""},"done":true}`+"\n", received.Model)
+			}))
+			defer server.Close()
+
+			provider := &OllamaProvider{BaseURL: server.URL, Model: "qwen2.5:7b", MaxTokens: 256, Stream: tt.stream}
+			outputFile := filepath.Join(t.TempDir(), "cases.csv")
+			req := GenerationRequest{PositiveExample: "<root><name>test</name></root>", Format: "xml", Num: 2}
+			if err := GenerateTestCases(provider, req, outputFile); err != nil {
+				t.Fatalf("生成测试用例失败: %v", err)
+			}
+
+			if received.Model != "qwen2.5:7b" || received.Stream != tt.stream {
+				t.Errorf("请求参数错误: %+v", received)
+			}
+			if received.Options["num_predict"] != float64(256) {
+				t.Errorf("max_tokens 应映射为 options.num_predict: %v", received.Options)
+			}
+			if len(received.Messages) != 2 || received.Messages[0].Content != builtinSystemPrompt {
+				t.Errorf("未使用内置系统提示词: %+v", received.Messages)
+			}
+
+			records, err := ReadCSV(outputFile)
+			if err != nil {
+				t.Fatalf("读取CSV失败: %v", err)
+			}
+			if len(records) != 3 || records[0][0] != "XML" {
+				t.Errorf("期望表头加2条用例，实际: %v", records)
+			}
+		})
+	}
+}
+
+// TestOllamaProviderStreamError 测试流式响应中返回错误时的处理
+func TestOllamaProviderStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"error":"model 'missing' not found"}`)
+	}))
+	defer server.Close()
+
+	provider := &OllamaProvider{BaseURL: server.URL, Model: "missing", Stream: true}
+	_, err := provider.Generate(GenerationRequest{PositiveExample: "{}", Format: "json", Num: 1})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("期望返回流式错误，实际: %v", err)
+	}
+}
+
+// TestOllamaProviderIncompleteResponse 测试响应中断或输出被截断时返回已收到的文本和 ErrPartialResult
+func TestOllamaProviderIncompleteResponse(t *testing.T) {
+	chunk := `{"model":"qwen","message":{"role":"assistant","content":"[{\"id\":1},"},"done":false}` + "\n"
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+	}{
+		{name: "未收到done", response: chunk, wantErr: "done"},
+		{name: "达到最大token数", response: chunk + `{"model":"qwen","message":{"role":"assistant","content":""},"done":true,"done_reason":"length"}` + "\n", wantErr: "finish_reason=length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.response)
+			}))
+			defer server.Close()
+
+			provider := &OllamaProvider{BaseURL: server.URL, Model: "qwen", Stream: true}
+			text, err := provider.Generate(GenerationRequest{PositiveExample: `{"id":0}`, Format: "json", Num: 3, Quiet: true})
+			if !errors.Is(err, ErrPartialResult) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("期望返回包含 %q 的 ErrPartialResult，实际: %v", tt.wantErr, err)
+			}
+			if text != `[{"id":1},` {
+				t.Errorf("应返回已收到的文本，实际: %q", text)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Package utils 提供OpenAI兼容接口的LLM提供方实现
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider 基于OpenAI兼容 /chat/completions 接口的LLM提供方
+// 适用于OpenAI以及vLLM、Ollama等提供兼容接口的本地推理服务
+type OpenAIProvider struct {
+	BaseURL      string        // API Base URL（如 http://localhost:8000/v1）
+	APIKey       string        // API Key（本地服务可为空）
+	Model        string        // 模型名称
+	Temperature  *float64      // 采样温度（为空时使用服务端默认值）
+	MaxTokens    int           // 最大生成token数（0表示不限制）
+	Stream       bool          // 是否使用流式响应
+	SystemPrompt string        // 系统提示词（为空时使用内置提示词）
+	Timeout      time.Duration // 请求的整体超时时间（0表示使用默认值300秒）
+
+	usageRecorder
+}
+
+// OpenAIChatMessage 表示一条对话消息
+type OpenAIChatMessage struct {
+	Role    string `json:"role"`    // 角色：system、user 或 assistant
+	Content string `json:"content"` // 消息内容
+}
+
+// OpenAIChatRequest 表示发送给 /chat/completions 的请求
+type OpenAIChatRequest struct {
+	Model       string              `json:"model"`                 // 模型名称
+	Messages    []OpenAIChatMessage `json:"messages"`              // 对话消息
+	Temperature *float64            `json:"temperature,omitempty"` // 采样温度
+	MaxTokens   int                 `json:"max_tokens,omitempty"`  // 最大生成token数
+	Stream      bool                `json:"stream"`                // 是否流式响应
+	// StreamOptions 流式响应的选项，用于请求在最后一个数据块中返回token用量
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+// OpenAIStreamOptions 表示流式响应的选项
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // 是否在最后一个数据块中返回token用量
+}
+
+// OpenAIUsage 表示响应中的token用量
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// OpenAIChatResponse 表示 /chat/completions 的响应（流式响应的每个数据块结构相同）
+type OpenAIChatResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index        int               `json:"index"`
+		Message      OpenAIChatMessage `json:"message"` // 非流式响应的完整消息
+		Delta        OpenAIChatMessage `json:"delta"`   // 流式响应的增量消息
+		FinishReason string            `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage"` // token用量（流式响应仅在最后一个数据块中返回）
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// Name 返回提供方名称
+func (p *OpenAIProvider) Name() string {
+	return ProviderOpenAI
+}
+
+// Generate 调用 /chat/completions 接口生成测试用例文本
+func (p *OpenAIProvider) Generate(req GenerationRequest) (string, error) {
+	systemPrompt := systemPromptFor(req, p.SystemPrompt)
+
+	reqBody := OpenAIChatRequest{
+		Model: p.Model,
+		Messages: []OpenAIChatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: buildUserMessage(req)},
+		},
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxTokens,
+		Stream:      p.Stream,
+	}
+	if p.Stream {
+		reqBody.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
+
+	// 序列化请求体
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("序列化请求体失败: %v", err)
+	}
+
+	completionsURL := fmt.Sprintf("%s/chat/completions", strings.TrimSuffix(p.BaseURL, "/"))
+	httpReq, err := http.NewRequest("POST", completionsURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	if p.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	// Debug模式：显示实际的请求信息
+	if req.Debug {
+		fmt.Println("\n🔍 ==================== DEBUG: HTTP请求详情 ====================")
+		fmt.Printf("📍 请求URL: %s\n", httpReq.URL.String())
+		fmt.Printf("🤖 模型: %s\n", p.Model)
+		if p.APIKey != "" {
+			fmt.Printf("🔑 API Key: %s\n", maskAPIKey(p.APIKey))
+		}
+		fmt.Println("\n📦 请求体 (Request Body):")
+		var prettyJSON bytes.Buffer
+		if indentErr := json.Indent(&prettyJSON, jsonData, "", "  "); indentErr == nil {
+			fmt.Printf("%s\n", prettyJSON.String())
+		} else {
+			fmt.Printf("%s\n", string(jsonData))
+		}
+		fmt.Println("🔍 ============================================================")
+	}
+
+	// 发送请求
+	start := time.Now()
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = llmRequestTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 检查响应状态
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API请求失败，状态码: %d，响应: %s", resp.StatusCode, string(body))
+	}
+
+	var text string
+	var usage LLMUsage
+	if p.Stream {
+		text, usage, err = processOpenAIStream(resp.Body, streamOutput(req.Quiet), req.Debug)
+	} else {
+		text, usage, err = processOpenAIResponse(resp.Body, streamOutput(req.Quiet), req.Debug)
+	}
+	usage.Calls = 1
+	usage.Latency = time.Since(start)
+	if usage.Model == "" {
+		usage.Model = p.Model
+	}
+	p.record(usage)
+	return text, err
+}
+
+// usage 将响应中的token用量转换为LLMUsage
+func (u *OpenAIUsage) usage(model string) LLMUsage {
+	if u == nil {
+		return LLMUsage{Model: model}
+	}
+	return LLMUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens, Model: model}
+}
+
+// processOpenAIResponse 处理非流式响应
+func processOpenAIResponse(body io.Reader, out io.Writer, debug bool) (string, LLMUsage, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", LLMUsage{}, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if debug {
+		fmt.Printf("\n🔍 [DEBUG] 原始响应: %s\n", string(data))
+	}
+
+	var chatResp OpenAIChatResponse
+	if err := json.Unmarshal(data, &chatResp); err != nil {
+		return "", LLMUsage{}, fmt.Errorf("解析响应失败: %v", err)
+	}
+	if chatResp.Error != nil {
+		return "", LLMUsage{}, fmt.Errorf("API返回错误: %s", chatResp.Error.Message)
+	}
+	usage := chatResp.Usage.usage(chatResp.Model)
+	if len(chatResp.Choices) == 0 {
+		return "", usage, nil
+	}
+
+	if err := checkFinishReason(chatResp.Choices[0].FinishReason); err != nil {
+		return chatResp.Choices[0].Message.Content, usage, err
+	}
+	fmt.Fprintln(out, "✅ 消息接收完成!")
+	return chatResp.Choices[0].Message.Content, usage, nil
+}
+
+// processOpenAIStream 处理流式响应，逐块写入out并收集文本
+// 未收到 [DONE] 就结束或输出因达到最大token数被截断时，返回已收到的文本和 ErrPartialResult
+func processOpenAIStream(body io.Reader, out io.Writer, debug bool) (string, LLMUsage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var collectedText strings.Builder
+	var usage LLMUsage
+	var finishReason string
+	done := false
+
+	fmt.Fprintln(out, "📡 开始接收流式数据...")
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		// 跳过空行和非data行
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		jsonData := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if jsonData == "" {
+			continue
+		}
+		// 流式响应结束标记
+		if jsonData == "[DONE]" {
+			done = true
+			break
+		}
+		if debug {
+			fmt.Printf("\n🔍 [DEBUG] 原始数据: %s\n", jsonData)
+		}
+
+		var chunk OpenAIChatResponse
+		if err := json.Unmarshal([]byte(jsonData), &chunk); err != nil {
+			fmt.Printf("⚠️  解析数据块失败: %v\n", err)
+			continue
+		}
+		if chunk.Error != nil {
+			fmt.Printf("\n❌ 流式响应错误: %s\n", chunk.Error.Message)
+			return "", LLMUsage{}, fmt.Errorf("API返回错误: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage(chunk.Model)
+		} else if chunk.Model != "" {
+			usage.Model = chunk.Model
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				fmt.Fprint(out, choice.Delta.Content) // 实时流式输出文本片段
+				collectedText.WriteString(choice.Delta.Content)
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return collectedText.String(), usage, fmt.Errorf("%w: 读取流式响应失败: %v", ErrPartialResult, err)
+	}
+	if !done {
+		return collectedText.String(), usage, fmt.Errorf("%w: 流式响应在收到 [DONE] 前中断", ErrPartialResult)
+	}
+	if err := checkFinishReason(finishReason); err != nil {
+		return collectedText.String(), usage, err
+	}
+
+	fmt.Fprintln(out, "\n\n✅ 消息接收完成!")
+	return collectedText.String(), usage, nil
+}
+
+// checkFinishReason 检查结束原因，输出因达到最大token数被截断时返回 ErrPartialResult
+func checkFinishReason(reason string) error {
+	if reason == "length" {
+		return fmt.Errorf("%w: 输出达到最大token数被截断（finish_reason=length）", ErrPartialResult)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newOpenAIStubServer 创建模拟 /chat/completions 接口的测试服务器，并记录收到的请求
+func newOpenAIStubServer(t *testing.T, content string, received *OpenAIChatRequest) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !received.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, content)
+			return
+		}
+
+		// 流式响应：按对象切分为多个数据块
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range strings.SplitAfter(content, "},") {
+			data, _ := json.Marshal(map[string]any{
+				"choices": []map[string]any{{"index": 0, "delta": map[string]string{"content": chunk}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+}
+
+// TestOpenAIProviderGenerate 测试OpenAI兼容提供方的流式与非流式响应处理
+func TestOpenAIProviderGenerate(t *testing.T) {
+	content := `[{"name":"a"},{"name":"b"},{"name":"c"}]`
+	temperature := 0.2
+
+	tests := []struct {
+		name   string
+		stream bool
+	}{
+		{name: "非流式响应", stream: false},
+		{name: "流式响应", stream: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received OpenAIChatRequest
+			server := newOpenAIStubServer(t, content, &received)
+			defer server.Close()
+
+			provider := &OpenAIProvider{
+				BaseURL:     server.URL + "/v1/",
+				Model:       "test-model",
+				Temperature: &temperature,
+				MaxTokens:   512,
+				Stream:      tt.stream,
+			}
+			outputFile := filepath.Join(t.TempDir(), "cases.csv")
+			req := GenerationRequest{PositiveExample: `{"name":"test"}`, Format: "json", Num: 3}
+			if err := GenerateTestCases(provider, req, outputFile); err != nil {
+				t.Fatalf("生成测试用例失败: %v", err)
+			}
+
+			// 校验请求参数
+			if received.Model != "test-model" || received.MaxTokens != 512 || received.Temperature == nil || *received.Temperature != temperature {
+				t.Errorf("请求参数错误: %+v", received)
+			}
+			if len(received.Messages) != 2 || received.Messages[0].Role != "system" || received.Messages[0].Content != builtinSystemPrompt {
+				t.Errorf("未使用内置系统提示词: %+v", received.Messages)
+			}
+			if !strings.Contains(received.Messages[1].Content, `{"name":"test"}`) {
+				t.Errorf("用户消息中缺少正例报文: %s", received.Messages[1].Content)
+			}
+
+			// 校验保存的测试用例
+			records, err := ReadCSV(outputFile)
+			if err != nil {
+				t.Fatalf("读取CSV失败: %v", err)
+			}
+			if len(records) != 4 {
+				t.Errorf("期望表头加3条用例，实际 %d 行: %v", len(records), records)
+			}
+		})
+	}
+}
+
+// TestOpenAIProviderErrorStatus 测试接口返回错误状态码时的处理
+func TestOpenAIProviderErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"model not found"}}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider := &OpenAIProvider{BaseURL: server.URL, Model: "missing"}
+	_, err := provider.Generate(GenerationRequest{PositiveExample: "{}", Format: "json", Num: 1})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("期望返回包含状态码的错误，实际: %v", err)
+	}
+}
+
+// TestOpenAIProviderIncompleteResponse 测试流式响应中断或输出被截断时返回已收到的文本和 ErrPartialResult
+func TestOpenAIProviderIncompleteResponse(t *testing.T) {
+	chunk := `data: {"choices":[{"index":0,"delta":{"content":"[{\"id\":1},"}}]}` + "\n\n"
+	tests := []struct {
+		name     string
+		stream   bool
+		response string
+		wantErr  string
+	}{
+		{name: "流式响应未收到DONE", stream: true, response: chunk, wantErr: "[DONE]"},
+		{name: "流式响应达到最大token数", stream: true, response: chunk + `data: {"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}` + "\n\ndata: [DONE]\n\n", wantErr: "finish_reason=length"},
+		{name: "非流式响应达到最大token数", response: `{"choices":[{"index":0,"message":{"role":"assistant","content":"[{\"id\":1},"},"finish_reason":"length"}]}`, wantErr: "finish_reason=length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.response)
+			}))
+			defer server.Close()
+
+			provider := &OpenAIProvider{BaseURL: server.URL, Model: "test-model", Stream: tt.stream}
+			text, err := provider.Generate(GenerationRequest{PositiveExample: `{"id":0}`, Format: "json", Num: 3, Quiet: true})
+			if !errors.Is(err, ErrPartialResult) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("期望返回包含 %q 的 ErrPartialResult，实际: %v", tt.wantErr, err)
+			}
+			if text != `[{"id":1},` {
+				t.Errorf("应返回已收到的文本，实际: %q", text)
+			}
+		})
+	}
+}
+
+// TestNewLLMProvider 测试根据配置选择LLM提供方
+func TestNewLLMProvider(t *testing.T) {
+	stream := false
+
+	tests := []struct {
+		name     string
+		config   LLMConfig
+		wantName string
+		wantErr  bool
+	}{
+		{name: "默认使用Dify", config: LLMConfig{URL: "http://localhost/v1", APIKey: "app-xxx"}, wantName: ProviderDify},
+		{name: "Dify缺少API Key", config: LLMConfig{Provider: "dify"}, wantErr: true},
+		{name: "OpenAI兼容", config: LLMConfig{Provider: "OpenAI", URL: "http://localhost:8000/v1", Model: "qwen", Stream: &stream}, wantName: ProviderOpenAI},
+		{name: "OpenAI缺少模型", config: LLMConfig{Provider: "openai"}, wantErr: true},
+		{name: "Ollama", config: LLMConfig{Provider: "ollama", Model: "qwen2.5:7b"}, wantName: ProviderOllama},
+		{name: "Ollama缺少模型", config: LLMConfig{Provider: "ollama"}, wantErr: true},
+		{name: "不支持的提供方", config: LLMConfig{Provider: "unknown"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewLLMProvider(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLLMProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if provider.Name() != tt.wantName {
+				t.Errorf("提供方名称错误: 期望 %s，实际 %s", tt.wantName, provider.Name())
+			}
+			if openai, ok := provider.(*OpenAIProvider); ok && openai.Stream {
+				t.Error("配置 stream = false 时不应使用流式响应")
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Package utils 提供正则表达式约束的字符串生成和校验
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// maxPatternLength 按正则表达式生成的字符串的最大长度
+const maxPatternLength = 1024
+
+// patternFallbackRunes 任意字符（.）和过大的字符类使用的候选字符
+const patternFallbackRunes = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// parseConstraintPattern 解析约束中的正则表达式，检查生成字符串所需的限制
+// 不支持无上限的重复（*、+、{n,}）和单词边界，生成的字符串最大长度不能超过 maxPatternLength
+func parseConstraintPattern(pattern string) (*syntax.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("正则表达式 'pattern' 不能为空")
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式: %v", err)
+	}
+	if err := checkPatternNode(re); err != nil {
+		return nil, err
+	}
+	if length := patternMaxLength(re); length > maxPatternLength {
+		return nil, fmt.Errorf("正则表达式匹配的字符串最长为 %d 个字符，超过上限 %d", length, maxPatternLength)
+	}
+	return re, nil
+}
+
+// checkPatternNode 递归检查正则表达式中是否包含无法生成字符串的结构
+func checkPatternNode(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return fmt.Errorf("正则表达式包含无上限的重复 '%s'，请使用 {m,n} 指定重复次数的上限", re)
+	case syntax.OpRepeat:
+		if re.Max < 0 {
+			return fmt.Errorf("正则表达式包含无上限的重复 '%s'，请使用 {m,n} 指定重复次数的上限", re)
+		}
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return fmt.Errorf("正则表达式不支持单词边界 \\b 和 \\B")
+	case syntax.OpNoMatch:
+		return fmt.Errorf("正则表达式不匹配任何字符串")
+	}
+	for _, sub := range re.Sub {
+		if err := checkPatternNode(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patternMaxLength 返回正则表达式匹配的字符串的最大长度（字符数）
+func patternMaxLength(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return 1
+	case syntax.OpCapture, syntax.OpQuest:
+		return patternMaxLength(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Max * patternMaxLength(re.Sub[0])
+	case syntax.OpConcat:
+		total := 0
+		for _, sub := range re.Sub {
+			total += patternMaxLength(sub)
+		}
+		return total
+	case syntax.OpAlternate:
+		longest := 0
+		for _, sub := range re.Sub {
+			longest = max(longest, patternMaxLength(sub))
+		}
+		return longest
+	}
+	return 0
+}
+
+// compileConstraintPattern 编译用于校验的正则表达式，要求整个字符串匹配
+func compileConstraintPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+// checkPatternValue 校验字段值是否完整匹配约束的正则表达式
+func checkPatternValue(constraint *FieldConstraint, value any) error {
+	re, err := compileConstraintPattern(constraint.Pattern)
+	if err != nil {
+		return fmt.Errorf("无效的正则表达式: %v", err)
+	}
+	return checkPattern(value, re, fmt.Sprintf("不匹配格式 %s", constraint.Pattern))
+}
+
+// generatePatternValue 生成完整匹配约束正则表达式的字符串，正则表达式无效时返回原值
+func (g *Generator) generatePatternValue(constraint *FieldConstraint, originalValue any) any {
+	re, err := parseConstraintPattern(constraint.Pattern)
+	if err != nil {
+		return originalValue
+	}
+	var builder strings.Builder
+	g.writePattern(&builder, re)
+	return builder.String()
+}
+
+// writePattern 按正则表达式语法树随机生成匹配的字符串
+func (g *Generator) writePattern(builder *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			// 忽略大小写的字面量随机选择大小写
+			if re.Flags&syntax.FoldCase != 0 && g.rng.Intn(2) == 0 {
+				r = unicode.SimpleFold(r)
+			}
+			builder.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		builder.WriteRune(g.randomClassRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		builder.WriteByte(patternFallbackRunes[g.rng.Intn(len(patternFallbackRunes))])
+	case syntax.OpCapture:
+		g.writePattern(builder, re.Sub[0])
+	case syntax.OpQuest:
+		if g.rng.Intn(2) == 0 {
+			g.writePattern(builder, re.Sub[0])
+		}
+	case syntax.OpRepeat:
+		count := re.Min + g.rng.Intn(re.Max-re.Min+1)
+		for i := 0; i < count; i++ {
+			g.writePattern(builder, re.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			g.writePattern(builder, sub)
+		}
+	case syntax.OpAlternate:
+		g.writePattern(builder, re.Sub[g.rng.Intn(len(re.Sub))])
+	}
+}
+
+// randomClassRune 从字符类中随机选择一个字符
+// 字符类包含可打印ASCII字符时只从中选择，避免生成不可见字符或罕见的Unicode字符
+func (g *Generator) randomClassRune(ranges []rune) rune {
+	var printable []rune
+	total := 0
+	for i := 0; i+1 < len(ranges); i += 2 {
+		low, high := ranges[i], ranges[i+1]
+		total += int(high-low) + 1
+		for r := max(low, ' '); r <= min(high, '~'); r++ {
+			printable = append(printable, r)
+		}
+	}
+	if len(printable) > 0 {
+		return printable[g.rng.Intn(len(printable))]
+	}
+
+	// 没有可打印ASCII字符时按字符数在全部区间中均匀选择
+	n := g.rng.Intn(total)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n)
+		}
+		n -= size
+	}
+	return ranges[0]
+}
+
+// generatePatternMismatch 生成与约束正则表达式相近但不匹配的字符串
+// 依次尝试替换某个字符的类别、删除最后一个字符和追加字符，都失败时返回超过最大长度的字符串
+func (g *Generator) generatePatternMismatch(constraint *FieldConstraint, originalValue any) string {
+	re, err := parseConstraintPattern(constraint.Pattern)
+	if err != nil {
+		return ""
+	}
+	matcher, err := compileConstraintPattern(constraint.Pattern)
+	if err != nil {
+		return ""
+	}
+
+	// 以匹配格式的原值为基础，原值不匹配时生成一个匹配的值
+	valid := fmt.Sprint(originalValue)
+	if !matcher.MatchString(valid) {
+		var builder strings.Builder
+		g.writePattern(&builder, re)
+		valid = builder.String()
+	}
+	runes := []rune(valid)
+
+	var candidates []string
+	for _, i := range g.rng.Perm(len(runes)) {
+		replaced := append([]rune{}, runes...)
+		replaced[i] = mismatchRune(runes[i])
+		candidates = append(candidates, string(replaced))
+	}
+	if len(runes) > 0 {
+		candidates = append(candidates, string(runes[:len(runes)-1]))
+		candidates = append(candidates, valid+string(runes[len(runes)-1]))
+	}
+	candidates = append(candidates, valid+"#")
+	for _, candidate := range candidates {
+		if !matcher.MatchString(candidate) {
+			return candidate
+		}
+	}
+
+	// 正则表达式匹配的字符串长度有上限，超过上限的字符串一定不匹配
+	return strings.Repeat("A", patternMaxLength(re)+1)
+}
+
+// mismatchRune 返回与给定字符类别不同的字符：数字替换为字母，字母替换为数字，其他字符替换为字母
+func mismatchRune(r rune) rune {
+	switch {
+	case unicode.IsDigit(r):
+		return 'X'
+	case unicode.IsLetter(r):
+		return '0'
+	default:
+		return 'x'
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseConstraintPattern 测试正则表达式约束的语法和重复次数检查
+func TestParseConstraintPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr string
+	}{
+		{name: "订单号", pattern: `ORD-\d{8}-[A-Z]{2}`},
+		{name: "车牌号", pattern: `[京沪粤][A-HJ-NP-Z][A-HJ-NP-Z0-9]{5}`},
+		{name: "可选部分和分组", pattern: `^(?:v\d{1,2})(\.\d{1,3}){0,2}(-beta)?$`},
+		{name: "空正则", pattern: ``, wantErr: "不能为空"},
+		{name: "语法错误", pattern: `ORD-[0-9`, wantErr: "无效的正则表达式"},
+		{name: "星号", pattern: `ORD-\d*`, wantErr: "无上限的重复"},
+		{name: "加号", pattern: `[A-Z]+`, wantErr: "无上限的重复"},
+		{name: "只有下限", pattern: `\d{3,}`, wantErr: "无上限的重复"},
+		{name: "单词边界", pattern: `\bORD\d{3}`, wantErr: "单词边界"},
+		{name: "超过最大长度", pattern: `[A-Z]{600}[0-9]{600}`, wantErr: "超过上限"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConstraintPattern(tt.pattern)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("不应返回错误: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("期望包含 %q 的错误，实际: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// 配置校验同样报告正则表达式错误
+	err := ValidateConstraintConfig(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"order_no": {Type: "pattern", Pattern: `ORD-\d+`},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "order_no") {
+		t.Errorf("配置校验应报告无上限的重复: %v", err)
+	}
+}
+
+// TestGeneratePatternValue 测试按正则表达式生成的字符串完整匹配且种子相同时结果相同
+func TestGeneratePatternValue(t *testing.T) {
+	patterns := []string{
+		`ORD-\d{8}-[A-Z]{2}`,
+		`[京沪粤][A-HJ-NP-Z][A-HJ-NP-Z0-9]{5}`,
+		`(?i)abc-[^0-9\s]{2}`,
+		`(v\d{1,2})(\.\d{1,3}){0,2}(-beta|-rc\d)?`,
+		`.{4}@[a-z]{3,6}\.com`,
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			constraint := &FieldConstraint{Type: "pattern", Pattern: pattern}
+			g := NewGenerator(nil, DefaultVariationRate, 7)
+			for i := 0; i < 50; i++ {
+				value := g.GenerateConstrainedValue(constraint, "")
+				if err := CheckConstrainedValue(constraint, value); err != nil {
+					t.Fatalf("生成的值 %q 不匹配: %v", value, err)
+				}
+			}
+
+			first := NewGenerator(nil, DefaultVariationRate, 1).GenerateConstrainedValue(constraint, "")
+			second := NewGenerator(nil, DefaultVariationRate, 1).GenerateConstrainedValue(constraint, "")
+			if first != second {
+				t.Errorf("相同种子生成的值应相同: %q %q", first, second)
+			}
+		})
+	}
+}
+
+// TestGeneratePatternMismatch 测试反例模式生成与格式相近但不匹配的字符串
+func TestGeneratePatternMismatch(t *testing.T) {
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"order_no": {Type: "pattern", Pattern: `ORD-\d{8}-[A-Z]{2}`},
+		"flag":     {Type: "pattern", Pattern: `[A-Za-z0-9#x]{1,2}`},
+	}}, DefaultVariationRate, 3)
+	data := map[string]any{"order_no": "ORD-20240101-AB", "flag": "Y", "name": "张三"}
+	g.Schema.KeyOrder = []string{"order_no", "flag", "name"}
+
+	var patternCases []LabeledTestCase
+	for _, testCase := range g.GenerateNegativeTestCases(data, 100, false) {
+		if strings.HasPrefix(testCase.Label, MutationPattern+":") {
+			patternCases = append(patternCases, testCase)
+		}
+	}
+	if len(patternCases) != 2 || patternCases[0].Label != "pattern_mismatch:order_no" || patternCases[0].Description != "字段 order_no 不匹配格式" {
+		t.Fatalf("不匹配格式的反例错误: %+v", patternCases)
+	}
+
+	for _, testCase := range patternCases {
+		field := strings.TrimPrefix(testCase.Label, MutationPattern+":")
+		value := testCase.Data[field]
+		if err := CheckConstrainedValue(g.FindFieldConstraint(field), value); err == nil {
+			t.Errorf("字段 %s 的反例值 %q 不应匹配格式", field, value)
+		}
+	}
+	// 订单号只改动一个字符，长度与原值相同
+	if value := patternCases[0].Data["order_no"].(string); len(value) != len("ORD-20240101-AB") {
+		t.Errorf("订单号的反例应与原值相近: %q", value)
+	}
+	if data["order_no"] != "ORD-20240101-AB" {
+		t.Error("生成反例不应修改正例")
+	}
+}
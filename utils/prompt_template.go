@@ -0,0 +1,223 @@
+// Package utils 提供llm-gen使用的提示词模板库
+package utils
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// builtinPromptTemplates 随工具发布的内置提示词模板
+//
+//go:embed prompts/templates/*.tmpl
+var builtinPromptTemplates embed.FS
+
+// promptTemplateExt 提示词模板文件扩展名
+const promptTemplateExt = ".tmpl"
+
+// promptTemplateMetaPattern 匹配模板开头的元数据注释块
+var promptTemplateMetaPattern = regexp.MustCompile(`(?s)^\s*\{\{/\*(.*?)\*/\}\}`)
+
+// PromptTemplate 表示一个命名、带版本的提示词模板
+// 模板文件开头可以使用注释块声明元数据：
+//
+//	{{/*
+//	version: 1
+//	description: 边界值测试
+//	*/}}
+type PromptTemplate struct {
+	Name        string // 模板名称（文件名去掉扩展名）
+	Version     string // 模板版本
+	Description string // 模板说明
+	Source      string // 模板来源（builtin 或文件路径）
+
+	tmpl *template.Template
+}
+
+// PromptTemplateField 模板中可用的字段信息
+type PromptTemplateField struct {
+	Path string // 字段路径（如 user.name、items[].price、@version）
+	Type string // 字段类型（object、array、string、number、bool、null、text）
+}
+
+// PromptTemplateConstraint 模板中可用的字段约束信息
+type PromptTemplateConstraint struct {
+	Field       string // 字段名
+	Type        string // 约束类型
+	Description string // 约束描述
+}
+
+// PromptTemplateData 渲染提示词模板时可用的变量
+type PromptTemplateData struct {
+	PositiveExample string                     // 正例报文
+	Format          string                     // 报文格式（JSON 或 XML）
+	Count           int                        // 生成数量
+	Fields          []PromptTemplateField      // 正例报文中的字段列表
+	Constraints     []PromptTemplateConstraint // 字段约束列表
+}
+
+// Render 使用给定变量渲染模板
+func (t *PromptTemplate) Render(data PromptTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染提示词模板 %s 失败: %v", t.Name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// LoadPromptTemplates 加载内置模板和指定目录中的模板
+// 目录中的模板与内置模板同名时覆盖内置模板，dir为空时只加载内置模板
+func LoadPromptTemplates(dir string) (map[string]*PromptTemplate, error) {
+	templates := make(map[string]*PromptTemplate)
+	if err := loadPromptTemplatesFS(templates, builtinPromptTemplates, "prompts/templates", "builtin"); err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return templates, nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("提示词模板目录不存在: %s", dir)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("提示词模板路径不是目录: %s", dir)
+	}
+	if err := loadPromptTemplatesFS(templates, os.DirFS(dir), ".", dir); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// FindPromptTemplate 按名称查找提示词模板
+func FindPromptTemplate(name, dir string) (*PromptTemplate, error) {
+	templates, err := LoadPromptTemplates(dir)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("提示词模板不存在: %s（可用模板: %s）", name, strings.Join(SortedPromptTemplateNames(templates), ", "))
+	}
+	return tmpl, nil
+}
+
+// SortedPromptTemplateNames 返回按名称排序的模板名列表
+func SortedPromptTemplateNames(templates map[string]*PromptTemplate) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewPromptTemplateData 根据正例报文和约束配置构建模板变量
+func NewPromptTemplateData(positiveExample, format string, count int, constraints map[string]FieldConstraint) (PromptTemplateData, error) {
+	data := PromptTemplateData{
+		PositiveExample: positiveExample,
+		Format:          strings.ToUpper(format),
+		Count:           count,
+	}
+
+	schema, err := ExtractPayloadSchema(positiveExample, format)
+	if err != nil {
+		return data, err
+	}
+	for _, field := range sortedKeys(schema.Fields) {
+		data.Fields = append(data.Fields, PromptTemplateField{Path: field, Type: schema.Fields[field]})
+	}
+
+	for _, name := range sortedKeys(constraints) {
+		constraint := constraints[name]
+		data.Constraints = append(data.Constraints, PromptTemplateConstraint{
+			Field:       name,
+			Type:        constraint.Type,
+			Description: constraint.Description,
+		})
+	}
+	return data, nil
+}
+
+// RenderRequestPrompt 按请求的生成数量渲染提示词模板，渲染结果放在自定义提示词之前
+// 分批生成和重新生成损坏用例时每个请求的数量不同，因此需要在发送每个请求前分别渲染
+func RenderRequestPrompt(req GenerationRequest) (GenerationRequest, error) {
+	if req.PromptTemplate == nil {
+		return req, nil
+	}
+
+	data, err := NewPromptTemplateData(req.PositiveExample, req.Format, req.Num, req.Constraints)
+	if err != nil {
+		return req, fmt.Errorf("解析正例报文失败: %v", err)
+	}
+	prompt, err := req.PromptTemplate.Render(data)
+	if err != nil {
+		return req, err
+	}
+	if req.UserPrompt != "" {
+		prompt += "\n\n" + req.UserPrompt
+	}
+	req.UserPrompt = prompt
+	req.PromptTemplate = nil
+	return req, nil
+}
+
+// loadPromptTemplatesFS 从文件系统中加载所有模板文件
+func loadPromptTemplatesFS(templates map[string]*PromptTemplate, fsys fs.FS, dir, source string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*"+promptTemplateExt))
+	if err != nil {
+		return fmt.Errorf("读取提示词模板目录失败: %v", err)
+	}
+
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("读取提示词模板 %s 失败: %v", file, err)
+		}
+
+		name := strings.TrimSuffix(path.Base(file), promptTemplateExt)
+		tmpl, err := parsePromptTemplate(name, string(content))
+		if err != nil {
+			return err
+		}
+		tmpl.Source = source
+		if source != "builtin" {
+			tmpl.Source = filepath.Join(source, path.Base(file))
+		}
+		templates[name] = tmpl
+	}
+	return nil
+}
+
+// parsePromptTemplate 解析模板内容和元数据
+func parsePromptTemplate(name, content string) (*PromptTemplate, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("解析提示词模板 %s 失败: %v", name, err)
+	}
+
+	result := &PromptTemplate{Name: name, tmpl: tmpl}
+	if match := promptTemplateMetaPattern.FindStringSubmatch(content); match != nil {
+		for _, line := range strings.Split(match[1], "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "version":
+				result.Version = strings.TrimSpace(value)
+			case "description":
+				result.Description = strings.TrimSpace(value)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBuiltinPromptTemplates 测试内置提示词模板的加载和渲染
+func TestBuiltinPromptTemplates(t *testing.T) {
+	templates, err := LoadPromptTemplates("")
+	if err != nil {
+		t.Fatalf("加载内置模板失败: %v", err)
+	}
+
+	minAge, maxAge := 0.0, 150.0
+	constraints := map[string]FieldConstraint{
+		"age": {Type: "integer", Min: &minAge, Max: &maxAge, Description: "年龄范围0-150"},
+	}
+	data, err := NewPromptTemplateData(`{"name":"test","age":25}`, "json", 8, constraints)
+	if err != nil {
+		t.Fatalf("构建模板变量失败: %v", err)
+	}
+
+	for _, name := range []string{"boundary", "security", "missing-fields", "type-confusion", "business-rules"} {
+		t.Run(name, func(t *testing.T) {
+			tmpl, ok := templates[name]
+			if !ok {
+				t.Fatalf("缺少内置模板 %s", name)
+			}
+			if tmpl.Version == "" || tmpl.Description == "" || tmpl.Source != "builtin" {
+				t.Errorf("模板元数据不完整: %+v", tmpl)
+			}
+			prompt, err := tmpl.Render(data)
+			if err != nil {
+				t.Fatalf("渲染模板失败: %v", err)
+			}
+			if !strings.Contains(prompt, "8 条") || !strings.Contains(prompt, "JSON") {
+				t.Errorf("渲染结果缺少数量或格式: %s", prompt)
+			}
+			if strings.Contains(prompt, "version:") {
+				t.Errorf("渲染结果不应包含元数据注释: %s", prompt)
+			}
+		})
+	}
+
+	boundary, _ := templates["boundary"].Render(data)
+	for _, want := range []string{"age（number）", "name（string）", "年龄范围0-150"} {
+		if !strings.Contains(boundary, want) {
+			t.Errorf("边界值模板缺少 %s: %s", want, boundary)
+		}
+	}
+}
+
+// TestLoadPromptTemplatesFromDir 测试从目录加载模板并覆盖内置模板
+func TestLoadPromptTemplatesFromDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"boundary.tmpl": "{{/*\nversion: 2\ndescription: 自定义边界值\n*/}}生成{{.Count}}条，字段数{{len .Fields}}",
+		"custom.tmpl":   "正例：{{.PositiveExample}}",
+		"ignored.txt":   "不是模板文件",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("写入模板文件失败: %v", err)
+		}
+	}
+
+	templates, err := LoadPromptTemplates(dir)
+	if err != nil {
+		t.Fatalf("加载模板目录失败: %v", err)
+	}
+	if _, ok := templates["ignored"]; ok {
+		t.Error("非 .tmpl 文件不应被加载")
+	}
+	if _, ok := templates["security"]; !ok {
+		t.Error("内置模板应保留")
+	}
+
+	boundary := templates["boundary"]
+	if boundary.Version != "2" || boundary.Source != filepath.Join(dir, "boundary.tmpl") {
+		t.Errorf("目录中的模板应覆盖内置模板: %+v", boundary)
+	}
+	prompt, err := boundary.Render(PromptTemplateData{Count: 3, Fields: []PromptTemplateField{{Path: "a", Type: "string"}}})
+	if err != nil || prompt != "生成3条，字段数1" {
+		t.Errorf("渲染结果错误: %q, 错误: %v", prompt, err)
+	}
+
+	if _, err := FindPromptTemplate("not-exist", dir); err == nil || !strings.Contains(err.Error(), "custom") {
+		t.Errorf("查找不存在的模板应返回可用模板列表，实际: %v", err)
+	}
+
+	broken := filepath.Join(t.TempDir(), "broken.tmpl")
+	if err := os.WriteFile(broken, []byte("{{.Count"), 0644); err != nil {
+		t.Fatalf("写入模板文件失败: %v", err)
+	}
+	if _, err := LoadPromptTemplates(filepath.Dir(broken)); err == nil {
+		t.Error("模板语法错误时应返回错误")
+	}
+}
@@ -0,0 +1,301 @@
+// Package utils 提供基于正例报文结构校验生成用例的功能
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 生成用例的分类
+const (
+	CaseValid    = "valid"    // 结构与正例一致的正例
+	CaseNegative = "negative" // 有意构造的反例（缺失字段、类型变化等）
+	CaseBroken   = "broken"   // 结构损坏（无法解析、根元素不同、虚构字段、差异过大等）
+)
+
+// 字段类型
+const (
+	kindObject = "object"
+	kindArray  = "array"
+	kindString = "string"
+	kindNumber = "number"
+	kindBool   = "bool"
+	kindNull   = "null"
+	kindText   = "text" // XML叶子节点和属性
+)
+
+// PayloadSchema 表示从正例报文提取的结构信息
+// 字段以路径表示，对象字段用 "." 连接，数组元素用 "[]" 表示，XML属性用 "@" 前缀
+type PayloadSchema struct {
+	Format string            // 报文格式（json或xml）
+	Root   string            // 根节点类型（JSON）或根元素名称（XML）
+	Fields map[string]string // 字段路径 -> 字段类型
+}
+
+// CaseValidation 表示单个生成用例的结构校验结果
+type CaseValidation struct {
+	Index  int      // 用例序号（从1开始）
+	Class  string   // 分类：valid、negative 或 broken
+	Issues []string // 与正例结构的差异
+}
+
+// ExtractPayloadSchema 从正例报文中提取结构信息
+func ExtractPayloadSchema(payload, format string) (*PayloadSchema, error) {
+	root, fields, err := flattenPayload(payload, format)
+	if err != nil {
+		return nil, err
+	}
+	return &PayloadSchema{Format: strings.ToLower(format), Root: root, Fields: fields}, nil
+}
+
+// ValidateCases 校验一组生成用例的结构
+func (s *PayloadSchema) ValidateCases(testCases []string) []CaseValidation {
+	validations := make([]CaseValidation, len(testCases))
+	for i, testCase := range testCases {
+		validations[i] = s.ValidateCase(testCase)
+		validations[i].Index = i + 1
+	}
+	return validations
+}
+
+// ValidateCase 将单个用例的结构与正例比较并分类
+// 没有差异的为正例；只有字段缺失或类型变化且差异不超过一半字段的视为有意构造的反例；
+// 无法解析、根节点不同、出现正例中不存在的字段或差异过大的视为结构损坏
+func (s *PayloadSchema) ValidateCase(payload string) CaseValidation {
+	root, fields, err := flattenPayload(payload, s.Format)
+	if err != nil {
+		return CaseValidation{Class: CaseBroken, Issues: []string{fmt.Sprintf("无法解析: %v", err)}}
+	}
+	if root != s.Root {
+		return CaseValidation{Class: CaseBroken, Issues: []string{fmt.Sprintf("根节点由 %s 变为 %s", s.Root, root)}}
+	}
+
+	var issues []string
+	var deviated []string // 缺失或类型变化的字段，其子字段不再单独比较
+
+	for _, path := range sortedKeys(s.Fields) {
+		if hasAncestor(path, deviated) || inEmptyArray(fields, path) {
+			continue
+		}
+		kind, exists := fields[path]
+		switch {
+		case !exists:
+			issues = append(issues, fmt.Sprintf("缺失字段 %s", path))
+			deviated = append(deviated, path)
+		case kind != s.Fields[path]:
+			issues = append(issues, fmt.Sprintf("字段 %s 类型由 %s 变为 %s", path, s.Fields[path], kind))
+			deviated = append(deviated, path)
+		}
+	}
+
+	broken := false
+	for _, path := range sortedKeys(fields) {
+		if _, exists := s.Fields[path]; exists || hasAncestor(path, deviated) || inEmptyArray(s.Fields, path) {
+			continue
+		}
+		issues = append(issues, fmt.Sprintf("正例中不存在的字段 %s", path))
+		broken = true
+	}
+
+	// 统计受影响的字段数（含子字段），超过一半视为结构损坏
+	affected := 0
+	for path := range s.Fields {
+		if hasAncestor(path, deviated) || containsString(deviated, path) {
+			affected++
+		}
+	}
+	if affected*2 > len(s.Fields) {
+		broken = true
+	}
+
+	switch {
+	case broken:
+		return CaseValidation{Class: CaseBroken, Issues: issues}
+	case len(issues) > 0:
+		return CaseValidation{Class: CaseNegative, Issues: issues}
+	default:
+		return CaseValidation{Class: CaseValid}
+	}
+}
+
+// inEmptyArray 判断字段是否位于fields中为空数组的元素内
+// 正例中的空数组无法约束元素结构，用例中的空数组也不视为缺失元素字段
+func inEmptyArray(fields map[string]string, path string) bool {
+	for offset := 0; ; {
+		index := strings.Index(path[offset:], "[]")
+		if index == -1 {
+			return false
+		}
+		arrayPath := path[:offset+index]
+		if _, hasElements := fields[arrayPath+"[]"]; fields[arrayPath] == kindArray && !hasElements {
+			return true
+		}
+		offset += index + 2
+	}
+}
+
+// SummarizeCaseValidations 统计各分类的用例数量
+func SummarizeCaseValidations(validations []CaseValidation) map[string]int {
+	summary := map[string]int{CaseValid: 0, CaseNegative: 0, CaseBroken: 0}
+	for _, validation := range validations {
+		summary[validation.Class]++
+	}
+	return summary
+}
+
+// hasAncestor 判断路径是否为roots中某个路径的子字段
+func hasAncestor(path string, roots []string) bool {
+	for _, root := range roots {
+		if strings.HasPrefix(path, root+".") || strings.HasPrefix(path, root+"[]") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys 返回排序后的map键，保证问题列表、校验结果和随机生成的顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flattenPayload 将报文展开为字段路径和类型
+func flattenPayload(payload, format string) (string, map[string]string, error) {
+	fields := make(map[string]string)
+	switch strings.ToLower(format) {
+	case "json":
+		var data any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(payload)), &data); err != nil {
+			return "", nil, fmt.Errorf("无效的JSON格式: %v", err)
+		}
+		flattenJSONValue("", data, fields)
+		return jsonKind(data), fields, nil
+	case "xml":
+		root, err := flattenXML(payload, fields)
+		if err != nil {
+			return "", nil, err
+		}
+		return root, fields, nil
+	default:
+		return "", nil, fmt.Errorf("不支持的格式: %s，仅支持 xml 或 json", format)
+	}
+}
+
+// flattenJSONValue 递归展开JSON值
+func flattenJSONValue(prefix string, value any, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			fields[path] = jsonKind(child)
+			flattenJSONValue(path, child, fields)
+		}
+	case []any:
+		for _, element := range v {
+			// 数组元素类型以第一个非null元素为准
+			if existing, exists := fields[prefix+"[]"]; !exists || existing == kindNull {
+				fields[prefix+"[]"] = jsonKind(element)
+			}
+			flattenJSONValue(prefix+"[]", element, fields)
+		}
+	}
+}
+
+// jsonKind 返回JSON值的类型名称
+func jsonKind(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return kindObject
+	case []any:
+		return kindArray
+	case string:
+		return kindString
+	case float64:
+		return kindNumber
+	case bool:
+		return kindBool
+	default:
+		return kindNull
+	}
+}
+
+// xmlEncodingRegex 匹配XML声明中的编码
+var xmlEncodingRegex = regexp.MustCompile(`encoding=["']([^"']+)["']`)
+
+// flattenXML 展开XML元素和属性，返回根元素名称
+// 包含子元素的元素类型为object，其余元素和属性类型为text
+func flattenXML(payload string, fields map[string]string) (string, error) {
+	// 与ValidateXMLFormat保持一致，将非UTF-8编码声明视为UTF-8解析
+	payload = xmlEncodingRegex.ReplaceAllString(strings.TrimSpace(payload), `encoding="UTF-8"`)
+	decoder := xml.NewDecoder(strings.NewReader(payload))
+
+	var root string
+	var stack []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("无效的XML格式: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root == "" {
+				root = t.Name.Local
+				stack = append(stack, "")
+			} else {
+				parent := stack[len(stack)-1]
+				path := t.Name.Local
+				if parent != "" {
+					path = parent + "." + t.Name.Local
+					fields[parent] = kindObject
+				}
+				if _, exists := fields[path]; !exists {
+					fields[path] = kindText
+				}
+				stack = append(stack, path)
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				prefix := stack[len(stack)-1]
+				if prefix != "" {
+					prefix += "."
+				}
+				fields[prefix+"@"+attr.Name.Local] = kindText
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if root == "" {
+		return "", fmt.Errorf("无效的XML格式: 未找到根元素")
+	}
+	return root, nil
+}
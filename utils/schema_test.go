@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPayloadSchemaValidateCase 测试生成用例与正例结构的比较和分类
+func TestPayloadSchemaValidateCase(t *testing.T) {
+	jsonExample := `{"user":{"name":"张三","age":25,"tags":["a"]},"items":[{"price":1.5,"count":2}],"extra":[],"active":true}`
+	xmlExample := `<?xml version="1.0" encoding="GBK"?><request version="1"><name>test</name><info><id>1</id><type>a</type></info></request>`
+
+	tests := []struct {
+		name      string
+		format    string
+		example   string
+		payload   string
+		wantClass string
+	}{
+		{name: "JSON结构一致", format: "json", example: jsonExample, payload: `{"user":{"name":"","age":-1,"tags":[]},"items":[{"price":0,"count":0}],"extra":[1],"active":false}`, wantClass: CaseValid},
+		{name: "JSON缺失字段", format: "json", example: jsonExample, payload: `{"user":{"name":"a","tags":["b"]},"items":[{"price":1,"count":1}],"extra":[],"active":true}`, wantClass: CaseNegative},
+		{name: "JSON类型变化", format: "json", example: jsonExample, payload: `{"user":{"name":"a","age":"abc","tags":["b"]},"items":[{"price":1,"count":1}],"extra":[],"active":true}`, wantClass: CaseNegative},
+		{name: "JSON虚构字段", format: "json", example: jsonExample, payload: `{"user":{"name":"a","age":1,"tags":["b"],"phone":"1"},"items":[{"price":1,"count":1}],"extra":[],"active":true}`, wantClass: CaseBroken},
+		{name: "JSON差异过大", format: "json", example: jsonExample, payload: `{"active":true,"extra":[]}`, wantClass: CaseBroken},
+		{name: "JSON根节点类型不同", format: "json", example: jsonExample, payload: `[1,2]`, wantClass: CaseBroken},
+		{name: "XML结构一致", format: "xml", example: xmlExample, payload: `<request version="2"><name></name><info><id>abc</id><type>b</type></info></request>`, wantClass: CaseValid},
+		{name: "XML缺失元素", format: "xml", example: xmlExample, payload: `<request version="1"><name>test</name><info><id>1</id></info></request>`, wantClass: CaseNegative},
+		{name: "XML根元素不同", format: "xml", example: xmlExample, payload: `<response><name>test</name></response>`, wantClass: CaseBroken},
+		{name: "XML格式错误", format: "xml", example: xmlExample, payload: `<request><name>test</request>`, wantClass: CaseBroken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ExtractPayloadSchema(tt.example, tt.format)
+			if err != nil {
+				t.Fatalf("提取正例结构失败: %v", err)
+			}
+			got := schema.ValidateCase(tt.payload)
+			if got.Class != tt.wantClass {
+				t.Errorf("分类错误: 期望 %s，实际 %s，问题: %v", tt.wantClass, got.Class, got.Issues)
+			}
+		})
+	}
+}
+
+// TestGenerateTestCasesRepairBroken 测试重新生成结构损坏的用例
+func TestGenerateTestCasesRepairBroken(t *testing.T) {
+	provider := &stubProvider{
+		generate: func(call int, req GenerationRequest) (string, error) {
+			if call == 0 {
+				return `[{"name":"a","age":1},{"name":"b","age":2,"invented":true},{"name":"c"}]`, nil
+			}
+			if !strings.Contains(req.UserPrompt, "invented") || req.Num != 1 {
+				t.Errorf("重新生成请求错误: Num=%d, 提示词=%s", req.Num, req.UserPrompt)
+			}
+			return `[{"name":"d","age":4}]`, nil
+		},
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "cases.csv")
+	req := GenerationRequest{PositiveExample: `{"name":"test","age":25}`, Format: "json", Num: 3, RepairBroken: true}
+	if err := GenerateTestCases(provider, req, outputFile); err != nil {
+		t.Fatalf("生成测试用例失败: %v", err)
+	}
+
+	records, err := ReadCSV(outputFile)
+	if err != nil {
+		t.Fatalf("读取CSV失败: %v", err)
+	}
+	want := []string{"JSON", `{"age":1,"name":"a"}`, `{"age":4,"name":"d"}`, `{"name":"c"}`}
+	if len(records) != len(want) {
+		t.Fatalf("期望 %d 行，实际 %d 行: %v", len(want), len(records), records)
+	}
+	for i, row := range records {
+		if row[0] != want[i] {
+			t.Errorf("第%d行错误: 期望 %s，实际 %s", i+1, want[i], row[0])
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package utils 提供约束名称的路径选择器匹配
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConstraintMatch 表示报文字段与匹配到的约束
+type ConstraintMatch struct {
+	Field      string // 字段路径，如 items[0].price
+	Constraint string // 匹配到的约束名称，没有匹配到约束时为空
+}
+
+// isPathSelector 判断约束名称是否为路径选择器（包含路径分隔符、数组下标或通配符）
+func isPathSelector(name string) bool {
+	return strings.ContainsAny(name, ".[*")
+}
+
+// splitFieldPath 将字段路径拆分为片段，如 items[0].price 拆分为 items、[0]、price
+func splitFieldPath(path string) ([]string, error) {
+	var segments []string
+	expectName := true // 路径开头和 . 之后必须是字段名
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '[':
+			if expectName {
+				return nil, fmt.Errorf("数组下标前缺少字段名")
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("数组下标缺少 ]")
+			}
+			segments = append(segments, path[i:i+end+1])
+			i += end + 1
+			if i < len(path) && path[i] != '.' && path[i] != '[' {
+				return nil, fmt.Errorf("数组下标之后应为 . 或 [")
+			}
+		case '.':
+			if expectName {
+				return nil, fmt.Errorf("路径中有空的字段名")
+			}
+			expectName = true
+			i++
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, path[i:i+end])
+			expectName = false
+			i += end
+		}
+	}
+	if expectName {
+		return nil, fmt.Errorf("路径中有空的字段名")
+	}
+	return segments, nil
+}
+
+// matchPathSelector 判断路径选择器是否匹配字段路径，匹配时返回选择器中通配符所在的片段位置
+// * 匹配任意一个对象字段名，[*] 匹配任意数组下标，字段名不区分大小写
+func matchPathSelector(selector, path string) ([]int, bool) {
+	selectorSegments, err := splitFieldPath(selector)
+	if err != nil {
+		return nil, false
+	}
+	pathSegments, err := splitFieldPath(path)
+	if err != nil || len(selectorSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	var wildcards []int
+	for i, segment := range selectorSegments {
+		isIndex := strings.HasPrefix(pathSegments[i], "[")
+		switch {
+		case segment == "*" && !isIndex, segment == "[*]" && isIndex:
+			wildcards = append(wildcards, i)
+		case !strings.EqualFold(segment, pathSegments[i]):
+			return nil, false
+		}
+	}
+	return wildcards, true
+}
+
+// moreSpecificSelector 判断通配符位置为 a 的选择器是否比 b 更具体：
+// 通配符较少的更具体；数量相同时，第一个不同的通配符位置靠后（前缀中的固定字段更多）的更具体
+func moreSpecificSelector(a, b []int) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
+
+// lookupPathSelector 在约束映射中查找匹配字段路径的路径选择器，多个选择器匹配时返回最具体的一个
+func lookupPathSelector(constraints map[string]FieldConstraint, fieldName string) (string, *FieldConstraint) {
+	var bestName string
+	var bestWildcards []int
+	for name := range constraints {
+		if !isPathSelector(name) {
+			continue
+		}
+		wildcards, ok := matchPathSelector(name, fieldName)
+		if !ok {
+			continue
+		}
+		// 具体程度相同时按名称排序，保证匹配结果稳定
+		if bestName == "" || moreSpecificSelector(wildcards, bestWildcards) ||
+			(!moreSpecificSelector(bestWildcards, wildcards) && name < bestName) {
+			bestName, bestWildcards = name, wildcards
+		}
+	}
+	if bestName == "" {
+		return "", nil
+	}
+	constraint := constraints[bestName]
+	return bestName, &constraint
+}
+
+// validateConstraintSelector 验证约束名称中路径选择器的语法
+func validateConstraintSelector(name string) []ValidationError {
+	if !isPathSelector(name) {
+		return nil
+	}
+
+	invalid := func(reason string) []ValidationError {
+		return []ValidationError{{
+			Field:   name,
+			Message: "无效的路径选择器: " + reason,
+		}}
+	}
+
+	segments, err := splitFieldPath(name)
+	if err != nil {
+		return invalid(err.Error())
+	}
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "[") {
+			index := segment[1 : len(segment)-1]
+			if index != "*" && (index == "" || strings.Trim(index, "0123456789") != "") {
+				return invalid(fmt.Sprintf("数组下标 %s 应为非负整数或 *", segment))
+			}
+		} else if segment != "*" && strings.ContainsAny(segment, "*]") {
+			return invalid(fmt.Sprintf("字段名 %s 中不能包含 * 或 ]，通配符 * 只能匹配完整的字段名", segment))
+		}
+	}
+	return nil
+}
+
+// MatchCaseConstraints 列出报文中每个字段匹配到的约束，字段路径的构造方式与 generateVariationWithConstraints 一致
+// 对象整体匹配到约束时只列出对象本身；keep_original 约束的对象会继续列出其子字段
+func MatchCaseConstraints(payload, format string, constraints map[string]FieldConstraint) ([]ConstraintMatch, error) {
+	data, err := payloadToMap(payload, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []ConstraintMatch
+	for _, key := range sortedKeys(data) {
+		matches = append(matches, matchValueConstraints(data[key], key, constraints)...)
+	}
+	return matches, nil
+}
+
+// matchValueConstraints 递归查找字段及其子字段匹配到的约束
+func matchValueConstraints(value any, fieldName string, constraints map[string]FieldConstraint) []ConstraintMatch {
+	switch v := value.(type) {
+	case map[string]any:
+		var matches []ConstraintMatch
+		if name, constraint := lookupFieldConstraintEntry(constraints, fieldName); constraint != nil {
+			matches = append(matches, ConstraintMatch{Field: fieldName, Constraint: name})
+			if constraint.Type != "keep_original" && (constraint.KeepOriginal == nil || !*constraint.KeepOriginal) {
+				return matches
+			}
+		}
+		for _, key := range sortedKeys(v) {
+			matches = append(matches, matchValueConstraints(v[key], fieldName+"."+key, constraints)...)
+		}
+		return matches
+	case []any:
+		var matches []ConstraintMatch
+		for i, item := range v {
+			matches = append(matches, matchValueConstraints(item, fmt.Sprintf("%s[%d]", fieldName, i), constraints)...)
+		}
+		return matches
+	default:
+		name, _ := lookupFieldConstraintEntry(constraints, fieldName)
+		return []ConstraintMatch{{Field: fieldName, Constraint: name}}
+	}
+}
+
+// UnmatchedConstraints 返回没有匹配到任何字段的约束名称，按名称排序
+func UnmatchedConstraints(matches []ConstraintMatch, constraints map[string]FieldConstraint) []string {
+	matched := make(map[string]bool)
+	for _, match := range matches {
+		matched[match.Constraint] = true
+	}
+
+	var names []string
+	for name := range constraints {
+		if !matched[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
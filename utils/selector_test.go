@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLookupFieldConstraintPrecedence 测试完整路径、路径选择器和简单字段名的匹配优先级
+func TestLookupFieldConstraintPrecedence(t *testing.T) {
+	constraints := map[string]FieldConstraint{
+		"name":           {Type: "chinese_name"},
+		"user.name":      {Type: "chinese_name"},
+		"*.name":         {Type: "keep_original"},
+		"items[*].price": {Type: "float"},
+		"items[0].price": {Type: "integer"},
+		"*[*].price":     {Type: "float"},
+		"items[*].*":     {Type: "keep_original"},
+		"orders[*].ID":   {Type: "sequence"},
+		"*.title":        {Type: "keep_original"},
+		"book.*":         {Type: "keep_original"},
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		want  string
+	}{
+		{name: "完整路径", field: "user.name", want: "user.name"},
+		{name: "顶层字段", field: "name", want: "name"},
+		{name: "对象字段通配符优先于简单字段名", field: "product.name", want: "*.name"},
+		{name: "多层路径不匹配单层通配符", field: "order.product.name", want: "name"},
+		{name: "指定数组下标", field: "items[0].price", want: "items[0].price"},
+		{name: "数组下标通配符", field: "items[3].price", want: "items[*].price"},
+		{name: "任意数组的元素", field: "gifts[1].price", want: "*[*].price"},
+		{name: "固定前缀更多的选择器优先", field: "book.title", want: "book.*"},
+		{name: "通配符少的选择器优先", field: "items[2].price", want: "items[*].price"},
+		{name: "字段名通配符", field: "items[2].count", want: "items[*].*"},
+		{name: "字段名不区分大小写", field: "orders[1].id", want: "orders[*].ID"},
+		{name: "数组下标不匹配字段名", field: "items.price", want: ""},
+		{name: "没有匹配", field: "remark", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, constraint := lookupFieldConstraintEntry(constraints, tt.field)
+			if name != tt.want || (tt.want == "") != (constraint == nil) {
+				t.Errorf("字段 %s 匹配到约束 %q，期望 %q", tt.field, name, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateConstraintSelector 测试路径选择器的语法校验
+func TestValidateConstraintSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		wantErr  string
+	}{
+		{name: "简单字段名", selector: "name"},
+		{name: "嵌套路径", selector: "user.address.city"},
+		{name: "通配符", selector: "*.items[*].price"},
+		{name: "多维数组", selector: "matrix[0][*]"},
+		{name: "空字段名", selector: "user..name", wantErr: "空的字段名"},
+		{name: "以点结尾", selector: "user.", wantErr: "空的字段名"},
+		{name: "缺少右括号", selector: "items[0.price", wantErr: "缺少 ]"},
+		{name: "下标前缺少字段名", selector: "[0].price", wantErr: "缺少字段名"},
+		{name: "无效下标", selector: "items[a].price", wantErr: "非负整数或 *"},
+		{name: "部分通配", selector: "user.na*", wantErr: "完整的字段名"},
+		{name: "下标后缺少点", selector: "items[0]price", wantErr: ". 或 ["},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateConstraintSelector(tt.selector)
+			if tt.wantErr == "" && len(errors) > 0 {
+				t.Fatalf("不应返回错误: %v", errors)
+			}
+			if tt.wantErr != "" && (len(errors) == 0 || !strings.Contains(errors[0].Message, tt.wantErr)) {
+				t.Errorf("期望包含 %q 的错误，实际: %v", tt.wantErr, errors)
+			}
+		})
+	}
+}
+
+// TestGenerateWithPathSelectors 测试生成用例时按路径选择器区分同名字段和数组元素
+func TestGenerateWithPathSelectors(t *testing.T) {
+	minPrice, maxPrice := 1.0, 9.0
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"user.name":      {Type: "chinese_name"},
+		"product.name":   {Type: "enum", Values: []any{"手机", "电脑"}},
+		"items[*].price": {Type: "integer", Min: &minPrice, Max: &maxPrice},
+	}}, DefaultVariationRate, 3)
+	data := map[string]any{
+		"user":    map[string]any{"name": "张三"},
+		"product": map[string]any{"name": "手机"},
+		"items":   []any{map[string]any{"price": 100}, map[string]any{"price": 200}},
+	}
+
+	for _, testCase := range g.GenerateTestCases(data, 20) {
+		if name := testCase["product"].(map[string]any)["name"]; name != "手机" && name != "电脑" {
+			t.Fatalf("product.name 应从候选值中生成: %v", name)
+		}
+		if name := testCase["user"].(map[string]any)["name"]; name == "手机" || name == "电脑" {
+			t.Fatalf("user.name 不应使用 product.name 的约束: %v", name)
+		}
+		for _, item := range testCase["items"].([]any) {
+			if price := item.(map[string]any)["price"].(int); price < 1 || price > 9 {
+				t.Fatalf("数组元素的 price 应在1到9之间: %v", price)
+			}
+		}
+	}
+}
+
+// TestMatchCaseConstraints 测试列出正例字段匹配到的约束和未匹配任何字段的约束
+func TestMatchCaseConstraints(t *testing.T) {
+	constraints := map[string]FieldConstraint{
+		"user":           {Type: "keep_original"},
+		"name":           {Type: "chinese_name"},
+		"items[*].price": {Type: "float"},
+		"address":        {Type: "chinese_address"},
+		"unused":         {Type: "email"},
+	}
+	payload := `{"user":{"name":"张三","age":20},"items":[{"price":1.5}],"address":{"city":"北京"},"remark":"无"}`
+
+	matches, err := MatchCaseConstraints(payload, "json", constraints)
+	if err != nil {
+		t.Fatalf("匹配失败: %v", err)
+	}
+	var got []string
+	for _, match := range matches {
+		got = append(got, match.Field+"="+match.Constraint)
+	}
+	want := "address=address,items[0].price=items[*].price,remark=,user=user,user.age=,user.name=name"
+	if strings.Join(got, ",") != want {
+		t.Errorf("匹配结果 %v，期望 %s", got, want)
+	}
+	if unmatched := UnmatchedConstraints(matches, constraints); strings.Join(unmatched, ",") != "unused" {
+		t.Errorf("未匹配的约束 %v，期望 [unused]", unmatched)
+	}
+}
+
+// TestLoadNestedConstraintTables 测试配置文件中嵌套的约束表展开为路径选择器
+func TestLoadNestedConstraintTables(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	content := `[constraints.user.name]
+type = "chinese_name"
+
+[constraints.items."[*]".price]
+type = "float"
+
+[constraints."orders[*].id"]
+type = "sequence"
+
+[constraints.phone]
+type = "phone"
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	for name, wantType := range map[string]string{"user.name": "chinese_name", "items[*].price": "float", "orders[*].id": "sequence", "phone": "phone"} {
+		if constraint, exists := config.Constraints.Constraints[name]; !exists || constraint.Type != wantType {
+			t.Errorf("约束 %s 加载错误: %+v", name, config.Constraints.Constraints)
+		}
+	}
+	if len(config.Constraints.Constraints) != 4 {
+		t.Errorf("约束数量 %d，期望 4: %+v", len(config.Constraints.Constraints), config.Constraints.Constraints)
+	}
+}
@@ -0,0 +1,145 @@
+// Package utils 提供唯一值约束和序列约束的生成
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sequenceVerbPattern 序列格式中的整数占位符，如 %d、%06d
+var sequenceVerbPattern = regexp.MustCompile(`%[-+ 0]*[0-9]*d`)
+
+// maxUniqueAttempts 生成唯一值时的最大尝试次数，超过后认为取值空间已用尽
+const maxUniqueAttempts = 1000
+
+// Err 返回生成过程中遇到的第一个错误（如唯一值的取值空间已用尽），没有错误时返回nil
+func (g *Generator) Err() error {
+	return g.err
+}
+
+// generateFieldValue 按约束生成字段值，处理序列计数和唯一值
+// name 为匹配到的约束名称，同一约束的序列计数和已生成的值在整个生成过程中共享
+func (g *Generator) generateFieldValue(name string, constraint *FieldConstraint, originalValue any) any {
+	if constraint.Type == "sequence" && (constraint.KeepOriginal == nil || !*constraint.KeepOriginal) {
+		return g.nextSequenceValue(name, constraint, originalValue)
+	}
+	if !constraint.Unique {
+		return g.GenerateConstrainedValue(constraint, originalValue)
+	}
+
+	if g.uniqueValues == nil {
+		g.uniqueValues = make(map[string]map[string]bool)
+	}
+	used := g.uniqueValues[name]
+	if used == nil {
+		used = make(map[string]bool)
+		g.uniqueValues[name] = used
+	}
+
+	var value any
+	for i := 0; i < maxUniqueAttempts; i++ {
+		value = g.GenerateConstrainedValue(constraint, originalValue)
+		if key := fmt.Sprint(value); !used[key] {
+			used[key] = true
+			return value
+		}
+	}
+	g.setErr(fmt.Errorf("约束 %s 的取值空间已用尽：已生成 %d 个不同的值，连续 %d 次未生成新的值", name, len(used), maxUniqueAttempts))
+	return value
+}
+
+// nextSequenceValue 返回序列的下一个值：start、start+step、start+2*step……
+// 设置了 format 时按格式输出字符串（如 U%06d），否则保持正例字段的类型
+func (g *Generator) nextSequenceValue(name string, constraint *FieldConstraint, originalValue any) any {
+	if g.sequences == nil {
+		g.sequences = make(map[string]int)
+	}
+	index := g.sequences[name]
+	g.sequences[name]++
+
+	start, step := sequenceStartStep(constraint)
+	value := start + index*step
+	if constraint.Format != "" {
+		return fmt.Sprintf(constraint.Format, value)
+	}
+	return preserveValueType(value, originalValue)
+}
+
+// sequenceStartStep 返回序列的起始值和步长，默认从1开始、步长为1
+func sequenceStartStep(constraint *FieldConstraint) (int, int) {
+	start, step := 1, 1
+	if constraint.Start != nil {
+		start = *constraint.Start
+	}
+	if constraint.Step != nil {
+		step = *constraint.Step
+	}
+	return start, step
+}
+
+// checkSequenceValue 校验字段值是否为序列中的值
+func checkSequenceValue(constraint *FieldConstraint, value any) error {
+	var number int
+	if constraint.Format != "" {
+		// 去掉占位符前后的固定部分后解析序号
+		str := fmt.Sprint(value)
+		loc := sequenceVerbPattern.FindStringIndex(constraint.Format)
+		if loc == nil {
+			return fmt.Errorf("无效的序列格式 %s", constraint.Format)
+		}
+		prefix := strings.ReplaceAll(constraint.Format[:loc[0]], "%%", "%")
+		suffix := strings.ReplaceAll(constraint.Format[loc[1]:], "%%", "%")
+		if !strings.HasPrefix(str, prefix) || !strings.HasSuffix(str, suffix) || len(str) < len(prefix)+len(suffix) {
+			return fmt.Errorf("不符合序列格式 %s", constraint.Format)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(str[len(prefix) : len(str)-len(suffix)]))
+		if err != nil || fmt.Sprintf(constraint.Format, n) != str {
+			return fmt.Errorf("不符合序列格式 %s", constraint.Format)
+		}
+		number = n
+	} else {
+		n, err := constraintNumber(value)
+		if err != nil {
+			return err
+		}
+		if n != float64(int(n)) {
+			return fmt.Errorf("不是整数")
+		}
+		number = int(n)
+	}
+
+	start, step := sequenceStartStep(constraint)
+	if step == 0 {
+		step = 1
+	}
+	if (number-start)%step != 0 || (number-start)/step < 0 {
+		return fmt.Errorf("不是从 %d 开始、步长为 %d 的序列值", start, step)
+	}
+	return nil
+}
+
+// validateSequenceConstraint 验证序列约束的步长和格式
+func validateSequenceConstraint(fieldName string, constraint FieldConstraint) []ValidationError {
+	var errors []ValidationError
+
+	if constraint.Step != nil && *constraint.Step == 0 {
+		errors = append(errors, ValidationError{
+			Field:   fieldName,
+			Message: "序列步长 'step' 不能为0",
+		})
+	}
+
+	if constraint.Format != "" {
+		// 格式中必须有且只有一个整数占位符
+		if len(sequenceVerbPattern.FindAllString(constraint.Format, -1)) != 1 || strings.Contains(fmt.Sprintf(constraint.Format, 1), "%!") {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("无效的序列格式 '%s'，应包含一个整数占位符，如 U%%06d", constraint.Format),
+			})
+		}
+	}
+
+	return errors
+}
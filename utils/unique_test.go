@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestSequenceConstraint 测试序列约束按起始值、步长和格式生成并校验
+func TestSequenceConstraint(t *testing.T) {
+	start, step, zero := 123, 5, 0
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"user_id": {Type: "sequence", Start: &start, Format: "U%06d"},
+		"order":   {Type: "sequence", Start: &start, Step: &step},
+	}}, DefaultVariationRate, 1)
+	data := map[string]any{"user_id": "U000001", "order": 1, "copy": map[string]any{"user_id": "U000001"}}
+
+	// 字段按名称排序生成，嵌套字段 copy.user_id 与顶层字段匹配同一个约束，共用一个序列
+	for i, testCase := range g.GenerateTestCases(data, 3) {
+		nested := testCase["copy"].(map[string]any)["user_id"]
+		if nested != fmt.Sprintf("U%06d", 123+i*2) || testCase["user_id"] != fmt.Sprintf("U%06d", 124+i*2) || testCase["order"] != 123+i*5 {
+			t.Errorf("第 %d 个用例的序列值错误: %v", i+1, testCase)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		constraint FieldConstraint
+		value      any
+		wantErr    bool
+	}{
+		{name: "格式匹配", constraint: FieldConstraint{Type: "sequence", Start: &start, Format: "U%06d"}, value: "U000128"},
+		{name: "格式不匹配", constraint: FieldConstraint{Type: "sequence", Format: "U%06d"}, value: "X000128", wantErr: true},
+		{name: "早于起始值", constraint: FieldConstraint{Type: "sequence", Start: &start, Format: "U%06d"}, value: "U000122", wantErr: true},
+		{name: "不在步长上", constraint: FieldConstraint{Type: "sequence", Start: &start, Step: &step}, value: 124, wantErr: true},
+		{name: "数字字符串", constraint: FieldConstraint{Type: "sequence", Start: &start, Step: &step}, value: "133"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckConstrainedValue(&tt.constraint, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("校验结果错误: %v", err)
+			}
+		})
+	}
+
+	err := ValidateConstraintConfig(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"a": {Type: "sequence", Step: &zero},
+		"b": {Type: "sequence", Format: "U%s"},
+		"c": {Type: "sequence", Format: "%d-%d"},
+	}})
+	for _, field := range []string{"'a'", "'b'", "'c'"} {
+		if err == nil || !strings.Contains(err.Error(), "字段 "+field) {
+			t.Errorf("应报告字段 %s 的序列配置错误: %v", field, err)
+		}
+	}
+}
+
+// TestUniqueConstraint 测试唯一值约束不生成重复值，取值空间用尽时返回错误
+func TestUniqueConstraint(t *testing.T) {
+	minValue, maxValue := 1.0, 20.0
+	g := NewGenerator(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"phone": {Type: "phone", Unique: true},
+		"level": {Type: "integer", Min: &minValue, Max: &maxValue, Unique: true},
+	}}, DefaultVariationRate, 1)
+	data := map[string]any{"phone": "13800138000", "level": 1}
+
+	phones, levels := map[any]bool{}, map[any]bool{}
+	for _, testCase := range g.GenerateTestCases(data, 20) {
+		if phones[testCase["phone"]] || levels[testCase["level"]] {
+			t.Fatalf("生成了重复的值: %v", testCase)
+		}
+		phones[testCase["phone"]], levels[testCase["level"]] = true, true
+	}
+	if g.Err() != nil {
+		t.Fatalf("取值空间未用尽时不应返回错误: %v", g.Err())
+	}
+
+	// 整数范围只有20个值，第21个用例无法生成新的值
+	g.GenerateTestCases(data, 1)
+	if err := g.Err(); err == nil || !strings.Contains(err.Error(), "level") {
+		t.Errorf("取值空间用尽时应返回错误: %v", err)
+	}
+
+	err := ValidateConstraintConfig(&ConstraintConfig{Constraints: map[string]FieldConstraint{
+		"name": {Type: "keep_original", Unique: true},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "unique") {
+		t.Errorf("保持原值的字段不能设置 unique: %v", err)
+	}
+}
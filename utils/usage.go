@@ -0,0 +1,177 @@
+// Package utils 提供LLM调用的token用量、耗时和费用统计
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LLMUsage 表示一次或多次LLM调用的用量
+type LLMUsage struct {
+	Calls            int           `json:"calls"`              // 调用次数
+	PromptTokens     int           `json:"prompt_tokens"`      // 输入token数
+	CompletionTokens int           `json:"completion_tokens"`  // 输出token数
+	TotalTokens      int           `json:"total_tokens"`       // 总token数
+	Latency          time.Duration `json:"-"`                  // 累计耗时（日志中以毫秒记录）
+	Model            string        `json:"model,omitempty"`    // 模型名称
+	Cost             float64       `json:"cost"`               // 费用
+	Currency         string        `json:"currency,omitempty"` // 货币单位
+}
+
+// Add 累加另一次调用的用量
+func (u *LLMUsage) Add(other LLMUsage) {
+	u.Calls += other.Calls
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Latency += other.Latency
+	u.Cost += other.Cost
+	if other.Model != "" {
+		u.Model = other.Model
+	}
+	if other.Currency != "" {
+		u.Currency = other.Currency
+	}
+}
+
+// MarshalJSON 将耗时以毫秒输出，便于日志统计
+func (u LLMUsage) MarshalJSON() ([]byte, error) {
+	type usageAlias LLMUsage
+	return json.Marshal(struct {
+		usageAlias
+		Latency int64 `json:"latency_ms"`
+	}{usageAlias(u), u.Latency.Milliseconds()})
+}
+
+// UnmarshalJSON 读取以毫秒记录的耗时
+func (u *LLMUsage) UnmarshalJSON(data []byte) error {
+	type usageAlias LLMUsage
+	aux := struct {
+		*usageAlias
+		Latency int64 `json:"latency_ms"`
+	}{usageAlias: (*usageAlias)(u)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	u.Latency = time.Duration(aux.Latency) * time.Millisecond
+	return nil
+}
+
+// UsageReporter 表示可以报告累计用量的LLM提供方
+type UsageReporter interface {
+	// Usage 返回累计用量
+	Usage() LLMUsage
+}
+
+// ProviderUsage 返回提供方的累计用量，不支持用量统计时返回false
+func ProviderUsage(provider LLMProvider) (LLMUsage, bool) {
+	reporter, ok := provider.(UsageReporter)
+	if !ok {
+		return LLMUsage{}, false
+	}
+	return reporter.Usage(), true
+}
+
+// usageRecorder 并发安全地累计LLM调用用量，嵌入各提供方使用
+type usageRecorder struct {
+	mu    sync.Mutex
+	usage LLMUsage
+}
+
+// record 记录一次调用的用量
+func (r *usageRecorder) record(usage LLMUsage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage.Add(usage)
+}
+
+// Usage 返回累计用量
+func (r *usageRecorder) Usage() LLMUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
+
+// LLMPricing 表示模型单价，用于估算提供方未返回费用时的成本
+type LLMPricing struct {
+	PromptPrice     float64 // 每百万输入token的价格
+	CompletionPrice float64 // 每百万输出token的价格
+	Currency        string  // 货币单位
+}
+
+// Apply 提供方未返回费用且配置了单价时，按token数估算费用
+func (p LLMPricing) Apply(usage LLMUsage) LLMUsage {
+	if usage.Cost > 0 || (p.PromptPrice == 0 && p.CompletionPrice == 0) {
+		return usage
+	}
+	usage.Cost = (float64(usage.PromptTokens)*p.PromptPrice + float64(usage.CompletionTokens)*p.CompletionPrice) / 1e6
+	usage.Currency = p.Currency
+	return usage
+}
+
+// PrintUsageSummary 输出LLM用量摘要
+func PrintUsageSummary(usage LLMUsage) {
+	if usage.Calls == 0 {
+		return
+	}
+	fmt.Println("\n📊 LLM用量统计:")
+	if usage.Model != "" {
+		fmt.Printf("   🧠 模型: %s\n", usage.Model)
+	}
+	fmt.Printf("   📞 调用次数: %d\n", usage.Calls)
+	fmt.Printf("   🔢 Token: 输入 %d，输出 %d，合计 %d\n", usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+	fmt.Printf("   ⏱️  耗时: %v\n", usage.Latency.Round(time.Millisecond))
+	if usage.Cost > 0 {
+		fmt.Printf("   💰 费用: %s %s\n", strconv.FormatFloat(usage.Cost, 'f', -1, 64), usage.Currency)
+	}
+}
+
+// GenerationLogEntry 表示生成日志中的一条记录（每次llm-gen一行JSON）
+type GenerationLogEntry struct {
+	Time      time.Time `json:"time"`            // 生成时间
+	Team      string    `json:"team,omitempty"`  // 团队标识
+	Provider  string    `json:"provider"`        // LLM提供方
+	URL       string    `json:"url,omitempty"`   // API Base URL
+	Model     string    `json:"model,omitempty"` // 模型名称
+	Format    string    `json:"format"`          // 报文格式
+	Num       int       `json:"num"`             // 请求生成的用例数量
+	Output    string    `json:"output"`          // 输出文件
+	CacheHits int64     `json:"cache_hits"`      // 缓存命中次数
+	Usage     LLMUsage  `json:"usage"`           // 用量
+	Error     string    `json:"error,omitempty"` // 生成失败时的错误信息
+}
+
+// DefaultGenerationLogPath 返回默认的生成日志路径（用户缓存目录下的 atc/generations.jsonl）
+func DefaultGenerationLogPath() string {
+	return filepath.Join(filepath.Dir(DefaultLLMCacheDir()), "generations.jsonl")
+}
+
+// AppendGenerationLog 向生成日志追加一条记录，path为空时使用默认路径
+func AppendGenerationLog(path string, entry GenerationLogEntry) error {
+	if path == "" {
+		path = DefaultGenerationLogPath()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建生成日志目录失败: %v", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化生成日志失败: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开生成日志失败: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入生成日志失败: %v", err)
+	}
+	return nil
+}
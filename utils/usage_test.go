@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProviderUsage 测试各提供方从响应中解析token用量
+func TestProviderUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		provider func(url string) LLMProvider
+		want     LLMUsage
+	}{
+		{
+			name: "Dify message_end元数据",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, `data: {"event":"message","answer":"[{\"id\":1}]"}`+"\n\n")
+				fmt.Fprint(w, `data: {"event":"message_end","metadata":{"usage":{"prompt_tokens":120,"completion_tokens":30,"total_tokens":150,"total_price":"0.0012","currency":"USD","latency":1.5}}}`+"\n\n")
+			},
+			provider: func(url string) LLMProvider { return &DifyProvider{BaseURL: url, APIKey: "app-test"} },
+			want:     LLMUsage{Calls: 1, PromptTokens: 120, CompletionTokens: 30, TotalTokens: 150, Cost: 0.0012, Currency: "USD"},
+		},
+		{
+			name: "OpenAI流式响应",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var req OpenAIChatRequest
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				if req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+					t.Errorf("流式请求应设置 stream_options.include_usage")
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, `data: {"model":"gpt-4o-mini","choices":[{"delta":{"content":"[{\"id\":1}]"}}]}`+"\n\n")
+				fmt.Fprint(w, `data: {"model":"gpt-4o-mini","choices":[],"usage":{"prompt_tokens":80,"completion_tokens":20,"total_tokens":100}}`+"\n\n")
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			},
+			provider: func(url string) LLMProvider {
+				return &OpenAIProvider{BaseURL: url, Model: "gpt-4o-mini", Stream: true}
+			},
+			want: LLMUsage{Calls: 1, PromptTokens: 80, CompletionTokens: 20, TotalTokens: 100, Model: "gpt-4o-mini"},
+		},
+		{
+			name: "OpenAI非流式响应",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"model":"qwen","choices":[{"message":{"role":"assistant","content":"[{\"id\":1}]"}}],"usage":{"prompt_tokens":5,"completion_tokens":6,"total_tokens":11}}`)
+			},
+			provider: func(url string) LLMProvider { return &OpenAIProvider{BaseURL: url, Model: "qwen"} },
+			want:     LLMUsage{Calls: 1, PromptTokens: 5, CompletionTokens: 6, TotalTokens: 11, Model: "qwen"},
+		},
+		{
+			name: "Ollama最终响应",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"model":"qwen2.5:7b","message":{"role":"assistant","content":"[{\"id\":1}]"},"done":false}`+"\n")
+				fmt.Fprint(w, `{"model":"qwen2.5:7b","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":40,"eval_count":10}`+"\n")
+			},
+			provider: func(url string) LLMProvider {
+				return &OllamaProvider{BaseURL: url, Model: "qwen2.5:7b", Stream: true}
+			},
+			want: LLMUsage{Calls: 1, PromptTokens: 40, CompletionTokens: 10, TotalTokens: 50, Model: "qwen2.5:7b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			provider := tt.provider(server.URL)
+			req := GenerationRequest{PositiveExample: `{"id":1}`, Format: "json", Num: 1, Quiet: true}
+			if _, err := provider.Generate(req); err != nil {
+				t.Fatalf("生成失败: %v", err)
+			}
+
+			// 通过缓存装饰器读取用量，验证用量可以穿透缓存
+			cached := &CachedProvider{Provider: provider, Cache: NewLLMCache(t.TempDir())}
+			got, ok := ProviderUsage(cached)
+			if !ok {
+				t.Fatal("提供方应支持用量统计")
+			}
+			if got.Latency <= 0 {
+				t.Errorf("应记录调用耗时: %v", got.Latency)
+			}
+			got.Latency = 0
+			if got != tt.want {
+				t.Errorf("用量错误: 期望 %+v，实际 %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestGenerationLog 测试费用估算和生成日志的写入
+func TestGenerationLog(t *testing.T) {
+	pricing := LLMPricing{PromptPrice: 2, CompletionPrice: 8, Currency: "CNY"}
+	usage := pricing.Apply(LLMUsage{Calls: 1, PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500})
+	if math.Abs(usage.Cost-0.006) > 1e-9 || usage.Currency != "CNY" {
+		t.Errorf("费用估算错误: %+v", usage)
+	}
+	reported := pricing.Apply(LLMUsage{PromptTokens: 1000, Cost: 0.1, Currency: "USD"})
+	if reported.Cost != 0.1 || reported.Currency != "USD" {
+		t.Errorf("提供方已返回费用时不应重新估算: %+v", reported)
+	}
+
+	logPath := filepath.Join(t.TempDir(), "logs", "generations.jsonl")
+	usage.Latency = 1500 * time.Millisecond
+	for _, team := range []string{"payment", "order"} {
+		entry := GenerationLogEntry{Time: time.Now(), Team: team, Provider: ProviderOpenAI, Format: "json", Num: 10, Usage: usage}
+		if err := AppendGenerationLog(logPath, entry); err != nil {
+			t.Fatalf("写入生成日志失败: %v", err)
+		}
+	}
+
+	file, err := os.Open(logPath)
+	if err != nil {
+		t.Fatalf("打开生成日志失败: %v", err)
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 2 || !strings.Contains(lines[0], `"latency_ms":1500`) {
+		t.Fatalf("生成日志内容错误: %v", lines)
+	}
+
+	var entry GenerationLogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("解析生成日志失败: %v", err)
+	}
+	if entry.Team != "order" || entry.Usage.TotalTokens != 1500 || entry.Usage.Latency != usage.Latency {
+		t.Errorf("生成日志记录错误: %+v", entry)
+	}
+}